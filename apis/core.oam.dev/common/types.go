@@ -18,6 +18,7 @@ package common
 
 import (
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
@@ -165,6 +166,10 @@ const (
 	ApplicationRendering ApplicationPhase = "rendering"
 	// ApplicationRunningWorkflow means the app is running workflow
 	ApplicationRunningWorkflow ApplicationPhase = "runningWorkflow"
+	// ApplicationWorkflowSuspending means the workflow of the app is suspended and waits to be resumed
+	ApplicationWorkflowSuspending ApplicationPhase = "workflowSuspending"
+	// ApplicationWorkflowTerminated means the workflow of the app is terminated and won't continue
+	ApplicationWorkflowTerminated ApplicationPhase = "workflowTerminated"
 	// ApplicationRunning means the app finished rendering and applied result to the cluster
 	ApplicationRunning ApplicationPhase = "running"
 	// ApplicationHealthChecking means the app finished rendering and applied result to the cluster, but still unhealthy
@@ -175,20 +180,41 @@ const (
 type ApplicationComponentStatus struct {
 	Name string `json:"name"`
 	// WorkloadDefinition is the definition of a WorkloadDefinition, such as deployments/apps.v1
-	WorkloadDefinition WorkloadGVK                      `json:"workloadDefinition,omitempty"`
-	Healthy            bool                             `json:"healthy"`
-	Message            string                           `json:"message,omitempty"`
-	Traits             []ApplicationTraitStatus         `json:"traits,omitempty"`
-	Scopes             []runtimev1alpha1.TypedReference `json:"scopes,omitempty"`
+	WorkloadDefinition WorkloadGVK `json:"workloadDefinition,omitempty"`
+	Healthy            bool        `json:"healthy"`
+	// HealthPhase is the phase of the health reported by the health policy of the component definition
+	HealthPhase HealthPhase `json:"healthPhase,omitempty"`
+	// HealthReason tells why the component is in the health phase
+	HealthReason string                           `json:"healthReason,omitempty"`
+	Message      string                           `json:"message,omitempty"`
+	Traits       []ApplicationTraitStatus         `json:"traits,omitempty"`
+	Scopes       []runtimev1alpha1.TypedReference `json:"scopes,omitempty"`
 }
 
 // ApplicationTraitStatus records the trait health status
 type ApplicationTraitStatus struct {
 	Type    string `json:"type"`
 	Healthy bool   `json:"healthy"`
-	Message string `json:"message,omitempty"`
+	// HealthPhase is the phase of the health reported by the health policy of the trait definition
+	HealthPhase HealthPhase `json:"healthPhase,omitempty"`
+	// HealthReason tells why the trait is in the health phase
+	HealthReason string `json:"healthReason,omitempty"`
+	Message      string `json:"message,omitempty"`
 }
 
+// HealthPhase is the phase of the health of a component or trait
+type HealthPhase string
+
+const (
+	// HealthPhaseHealthy means the component or trait is healthy
+	HealthPhaseHealthy HealthPhase = "healthy"
+	// HealthPhaseProgressing means the component or trait is on the way to be healthy, e.g., the replicas are not
+	// all ready yet
+	HealthPhaseProgressing HealthPhase = "progressing"
+	// HealthPhaseUnhealthy means the component or trait is unhealthy
+	HealthPhaseUnhealthy HealthPhase = "unhealthy"
+)
+
 // Revision has name and revision number
 type Revision struct {
 	Name     string `json:"name"`
@@ -207,15 +233,36 @@ type RawComponent struct {
 
 // WorkflowStepStatus record the status of a workflow step
 type WorkflowStepStatus struct {
-	Name        string                         `json:"name,omitempty"`
-	Type        string                         `json:"type,omitempty"`
-	Phase       WorkflowStepPhase              `json:"phase,omitempty"`
+	Name  string            `json:"name,omitempty"`
+	Type  string            `json:"type,omitempty"`
+	Phase WorkflowStepPhase `json:"phase,omitempty"`
+	// A human readable message indicating details about why the workflowStep is in this state.
+	Message     string                         `json:"message,omitempty"`
 	ResourceRef runtimev1alpha1.TypedReference `json:"resourceRef,omitempty"`
+
+	// Attempts is the number of times the step has been attempted, including retries.
+	Attempts int `json:"attempts,omitempty"`
+	// StartedAt is the time the step is executed for the first time.
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+	// FinishedAt is the time the last attempt of the step finished.
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+	// LastError is the error message of the last failed attempt of the step.
+	LastError string `json:"lastError,omitempty"`
+	// Outputs records the values exported by the step in json format.
+	Outputs map[string]string `json:"outputs,omitempty"`
 }
 
 // WorkflowStatus record the status of workflow
 type WorkflowStatus struct {
-	Steps []WorkflowStepStatus `json:"steps,omitempty"`
+	// AppRevision is the name of the application revision the workflow is executed for
+	AppRevision string `json:"appRevision,omitempty"`
+	// Suspend indicates the workflow is paused by a step or an operator and will not continue until resumed
+	Suspend bool `json:"suspend"`
+	// Terminated indicates the workflow is terminated by an operator or a failed step and will not continue until restarted
+	Terminated bool `json:"terminated"`
+	// Restarts is the number of times the workflow is restarted by an operator for the revision
+	Restarts int                  `json:"restarts,omitempty"`
+	Steps    []WorkflowStepStatus `json:"steps,omitempty"`
 }
 
 // AppStatus defines the observed state of Application
@@ -247,6 +294,61 @@ type AppStatus struct {
 	// LatestRevision of the application configuration it generates
 	// +optional
 	LatestRevision *Revision `json:"latestRevision,omitempty"`
+
+	// Clusters records the names of the clusters selected by the topology policy that the application is dispatched to,
+	// it's empty if the application is dispatched to the host cluster.
+	// +optional
+	Clusters []string `json:"clusters,omitempty"`
+
+	// GarbageCollect records the results of the latest garbage collection of the resources removed from the application.
+	// +optional
+	GarbageCollect []GarbageCollectResult `json:"garbageCollect,omitempty"`
+
+	// Drift records the dispatched resources changed outside of the application, found by the latest drift detection.
+	// +optional
+	Drift []ResourceDrift `json:"drift,omitempty"`
+}
+
+// ResourceDrift records the fields of a dispatched resource whose live values differ from the applied ones.
+type ResourceDrift struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	// Cluster is the cluster the resource is dispatched to, it's empty for the host cluster.
+	Cluster string `json:"cluster,omitempty"`
+	// Fields are the paths of the drifted fields, e.g., spec.replicas.
+	Fields []string `json:"fields,omitempty"`
+	// Missing means the resource is deleted.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// GarbageCollectResultType is the result of garbage collecting a resource.
+type GarbageCollectResultType string
+
+const (
+	// GarbageCollectResultDeleted means the resource is deleted.
+	GarbageCollectResultDeleted GarbageCollectResultType = "Deleted"
+	// GarbageCollectResultKept means the resource is kept until the application is deleted.
+	GarbageCollectResultKept GarbageCollectResultType = "Kept"
+	// GarbageCollectResultOrphaned means the resource is no longer managed by the application.
+	GarbageCollectResultOrphaned GarbageCollectResultType = "Orphaned"
+	// GarbageCollectResultPending means the resource waits for the resources deleted before it.
+	GarbageCollectResultPending GarbageCollectResultType = "Pending"
+	// GarbageCollectResultFailed means the resource failed to be garbage collected, it's retried in the next reconcile.
+	GarbageCollectResultFailed GarbageCollectResultType = "Failed"
+)
+
+// GarbageCollectResult is the result of garbage collecting a resource removed from the application.
+type GarbageCollectResult struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	// Cluster is the cluster the resource is dispatched to, it's empty for the host cluster.
+	Cluster string                   `json:"cluster,omitempty"`
+	Result  GarbageCollectResultType `json:"result"`
+	Message string                   `json:"message,omitempty"`
 }
 
 // WorkflowStepPhase describes the phase of a workflow step.
@@ -261,6 +363,9 @@ const (
 	WorkflowStepPhaseStopped WorkflowStepPhase = "stopped"
 	// WorkflowStepPhaseRunning will make the controller continue the workflow.
 	WorkflowStepPhaseRunning WorkflowStepPhase = "running"
+	// WorkflowStepPhaseSkipped means the step is not executed because its condition is false,
+	// the controller runs the next step.
+	WorkflowStepPhaseSkipped WorkflowStepPhase = "skipped"
 )
 
 // DefinitionType describes the type of DefinitionRevision.
@@ -292,4 +397,25 @@ type AppRolloutStatus struct {
 	// LastSourceAppRevision contains the name of the app that we need to upgrade from.
 	// We will restart the rollout if this is not the same as the spec
 	LastSourceAppRevision string `json:"LastSourceAppRevision,omitempty"`
+
+	// RollingBack indicates that the failed rollout is rolling back to the source app revision
+	// +optional
+	RollingBack bool `json:"rollingBack,omitempty"`
+
+	// RollbackReason is why the rollout is rolled back
+	// +optional
+	RollbackReason string `json:"rollbackReason,omitempty"`
+
+	// Components are the rollout status of each component when multiple components are rolled out,
+	// the overall rollout status follows the component being rolled out
+	// +optional
+	Components []ComponentRolloutStatus `json:"components,omitempty"`
+}
+
+// ComponentRolloutStatus is the rollout status of a component
+type ComponentRolloutStatus struct {
+	// Component is the name of the component
+	Component string `json:"component"`
+
+	v1alpha1.RolloutStatus `json:",inline"`
 }
@@ -28,6 +28,13 @@ import (
 func (in *AppRolloutStatus) DeepCopyInto(out *AppRolloutStatus) {
 	*out = *in
 	in.RolloutStatus.DeepCopyInto(&out.RolloutStatus)
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]ComponentRolloutStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppRolloutStatus.
@@ -72,6 +79,23 @@ func (in *AppStatus) DeepCopyInto(out *AppStatus) {
 		*out = new(Revision)
 		**out = **in
 	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GarbageCollect != nil {
+		in, out := &in.GarbageCollect, &out.GarbageCollect
+		*out = make([]GarbageCollectResult, len(*in))
+		copy(*out, *in)
+	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = make([]ResourceDrift, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppStatus.
@@ -162,6 +186,22 @@ func (in *ChildResourceKind) DeepCopy() *ChildResourceKind {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentRolloutStatus) DeepCopyInto(out *ComponentRolloutStatus) {
+	*out = *in
+	in.RolloutStatus.DeepCopyInto(&out.RolloutStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentRolloutStatus.
+func (in *ComponentRolloutStatus) DeepCopy() *ComponentRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DefinitionReference) DeepCopyInto(out *DefinitionReference) {
 	*out = *in
@@ -177,6 +217,21 @@ func (in *DefinitionReference) DeepCopy() *DefinitionReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GarbageCollectResult) DeepCopyInto(out *GarbageCollectResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GarbageCollectResult.
+func (in *GarbageCollectResult) DeepCopy() *GarbageCollectResult {
+	if in == nil {
+		return nil
+	}
+	out := new(GarbageCollectResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Helm) DeepCopyInto(out *Helm) {
 	*out = *in
@@ -263,6 +318,26 @@ func (in *RawComponent) DeepCopy() *RawComponent {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceDrift) DeepCopyInto(out *ResourceDrift) {
+	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceDrift.
+func (in *ResourceDrift) DeepCopy() *ResourceDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Revision) DeepCopyInto(out *Revision) {
 	*out = *in
@@ -349,7 +424,9 @@ func (in *WorkflowStatus) DeepCopyInto(out *WorkflowStatus) {
 	if in.Steps != nil {
 		in, out := &in.Steps, &out.Steps
 		*out = make([]WorkflowStepStatus, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -367,6 +444,21 @@ func (in *WorkflowStatus) DeepCopy() *WorkflowStatus {
 func (in *WorkflowStepStatus) DeepCopyInto(out *WorkflowStepStatus) {
 	*out = *in
 	out.ResourceRef = in.ResourceRef
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishedAt != nil {
+		in, out := &in.FinishedAt, &out.FinishedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowStepStatus.
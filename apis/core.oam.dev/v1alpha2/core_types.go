@@ -134,6 +134,12 @@ type TraitDefinitionSpec struct {
 	// +optional
 	ConflictsWith []string `json:"conflictsWith,omitempty"`
 
+	// MaxInstances specifies the maximum number of this trait which can be applied to one component.
+	// Traits that omit this field can be applied to a component any number of times.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxInstances *int32 `json:"maxInstances,omitempty"`
+
 	// Schematic defines the data format and template of the encapsulation of the trait
 	// +optional
 	Schematic *common.Schematic `json:"schematic,omitempty"`
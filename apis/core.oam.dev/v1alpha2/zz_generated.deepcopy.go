@@ -2042,6 +2042,11 @@ func (in *TraitDefinitionSpec) DeepCopyInto(out *TraitDefinitionSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.MaxInstances != nil {
+		in, out := &in.MaxInstances, &out.MaxInstances
+		*out = new(int32)
+		**out = **in
+	}
 	if in.Schematic != nil {
 		in, out := &in.Schematic, &out.Schematic
 		*out = new(common.Schematic)
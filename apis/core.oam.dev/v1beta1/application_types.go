@@ -70,10 +70,88 @@ type WorkflowStep struct {
 
 	// +kubebuilder:pruning:PreserveUnknownFields
 	Properties runtime.RawExtension `json:"properties,omitempty"`
+
+	// Inputs fill the values exported by previous steps into the parameter of this step.
+	Inputs []WorkflowStepInput `json:"inputs,omitempty"`
+
+	// Outputs export values of the step result into the workflow context, so that later steps can use them as inputs.
+	Outputs []WorkflowStepOutput `json:"outputs,omitempty"`
+
+	// DependsOn is the names of the steps which must succeed before this step is executed.
+	// It's only valid in dag mode.
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// Timeout is the duration the step is allowed to run including retries, e.g. `10m`.
+	// The step fails once it's exceeded. There is no timeout if it's not specified.
+	// +optional
+	Timeout string `json:"timeout,omitempty"`
+
+	// Retries is the number of times a failed step is retried with exponential backoff
+	// before the failure policy is applied.
+	// +optional
+	Retries int `json:"retries,omitempty"`
+
+	// OnFailure is the policy applied when the step fails, defaults to abort.
+	// +kubebuilder:validation:Enum=continue;abort;rollback
+	// +optional
+	OnFailure WorkflowStepFailurePolicy `json:"onFailure,omitempty"`
+
+	// If is a CUE boolean expression, the step is skipped if it's evaluated to false.
+	// It can refer to the application `context`, the `outputs` exported by previous steps
+	// and the `status` of executed steps, e.g. `status.deploy.phase == "failed"`.
+	// +optional
+	If string `json:"if,omitempty"`
+}
+
+// WorkflowStepFailurePolicy describes what the workflow does when a step fails.
+type WorkflowStepFailurePolicy string
+
+const (
+	// WorkflowStepFailureContinue ignores the failure and continues the workflow.
+	WorkflowStepFailureContinue WorkflowStepFailurePolicy = "continue"
+	// WorkflowStepFailureAbort terminates the workflow.
+	WorkflowStepFailureAbort WorkflowStepFailurePolicy = "abort"
+	// WorkflowStepFailureRollback terminates the workflow and rolls the resources back to the previous revision.
+	WorkflowStepFailureRollback WorkflowStepFailurePolicy = "rollback"
+)
+
+// WorkflowStepInput fills a value of the workflow context into the parameter of a workflow step.
+type WorkflowStepInput struct {
+	// From is the name of the value exported by the outputs of a previous step.
+	From string `json:"from"`
+
+	// ParameterKey is the dot separated path of the parameter to fill, e.g. `db.endpoint`.
+	ParameterKey string `json:"parameterKey"`
 }
 
+// WorkflowStepOutput exports a value of the workflow step result into the workflow context.
+type WorkflowStepOutput struct {
+	// Name is the name of the value in the workflow context. It must be unique in the workflow.
+	Name string `json:"name"`
+
+	// ValueFrom is the CUE expression evaluated in the step template after the step succeeded,
+	// e.g. `context.output.status.endpoint`.
+	ValueFrom string `json:"valueFrom"`
+}
+
+// WorkflowMode describes how the steps of a workflow are executed.
+type WorkflowMode string
+
+const (
+	// WorkflowModeStep executes the workflow steps one by one in array order.
+	WorkflowModeStep WorkflowMode = "step"
+	// WorkflowModeDAG executes a workflow step as soon as all steps it depends on have succeeded,
+	// steps without dependencies between them are executed concurrently.
+	WorkflowModeDAG WorkflowMode = "dag"
+)
+
 // Workflow defines workflow steps and other attributes
 type Workflow struct {
+	// Mode is the execution mode of the workflow steps, defaults to step.
+	// +kubebuilder:validation:Enum=step;dag
+	// +optional
+	Mode WorkflowMode `json:"mode,omitempty"`
+
 	Steps []WorkflowStep `json:"steps,omitempty"`
 }
 
@@ -87,10 +165,11 @@ type ApplicationSpec struct {
 	Policies []AppPolicy `json:"policies,omitempty"`
 
 	// Workflow defines how to customize the control logic.
-	// If workflow is specified, Vela won't apply any resource, but provide rendered output in AppRevision.
-	// Workflow steps are executed in array order, and each step:
-	// - will have a context in annotation.
-	// - should mark "finish" phase in status.conditions.
+	// If workflow is specified, Vela won't apply any resource directly, but executes the steps instead.
+	// Workflow steps are executed in array order by the application controller, and each step:
+	// - can be a built-in step (apply-component, apply-application) or a WorkflowStepDefinition.
+	// - will have a context in annotation on the resources it applies.
+	// - is finished once its health check passes or its resources mark "finish" phase in status.conditions.
 	Workflow *Workflow `json:"workflow,omitempty"`
 
 	// TODO(wonderflow): we should have application level scopes supported here
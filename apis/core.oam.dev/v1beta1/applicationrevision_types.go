@@ -43,6 +43,16 @@ type ApplicationRevisionSpec struct {
 	// ScopeDefinitions records the snapshot of the scopeDefinitions related with the created/modified Application
 	ScopeDefinitions map[string]ScopeDefinition `json:"scopeDefinitions,omitempty"`
 
+	// ComponentDefinitionRevisions records the definition revisions the componentDefinitions are resolved to,
+	// the keys are the same as ComponentDefinitions
+	// +optional
+	ComponentDefinitionRevisions map[string]common.Revision `json:"componentDefinitionRevisions,omitempty"`
+
+	// TraitDefinitionRevisions records the definition revisions the traitDefinitions are resolved to,
+	// the keys are the same as TraitDefinitions
+	// +optional
+	TraitDefinitionRevisions map[string]common.Revision `json:"traitDefinitionRevisions,omitempty"`
+
 	// Components records the rendered components from Application, it will contains the whole K8s CR of workload in it.
 	Components []common.RawComponent `json:"components,omitempty"`
 
@@ -56,17 +66,30 @@ type ApplicationRevisionSpec struct {
 	ResourcesConfigMap corev1.LocalObjectReference `json:"resourcesConfigMap,omitempty"`
 }
 
+// ApplicationRevisionStatus is the status of ApplicationRevision
+type ApplicationRevisionStatus struct {
+	// Workflow records the execution of the application workflow for this revision,
+	// it's kept after the application is updated to a new revision.
+	Workflow *common.WorkflowStatus `json:"workflow,omitempty"`
+
+	// RestartedWorkflows records the previous executions of the workflow for this revision,
+	// which are restarted by an operator.
+	RestartedWorkflows []common.WorkflowStatus `json:"restartedWorkflows,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 
 // ApplicationRevision is the Schema for the ApplicationRevision API
 // +kubebuilder:storageversion
 // +kubebuilder:resource:categories={oam},shortName=apprev
+// +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=".metadata.creationTimestamp"
 type ApplicationRevision struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec ApplicationRevisionSpec `json:"spec,omitempty"`
+	Spec   ApplicationRevisionSpec   `json:"spec,omitempty"`
+	Status ApplicationRevisionStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -23,6 +23,18 @@ import (
 	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
 )
 
+// ComponentRolloutStrategyType is the strategy to roll out multiple components
+type ComponentRolloutStrategyType string
+
+const (
+	// LockstepComponentRollout rolls out the components together, a component moves to the next batch only after
+	// all the components finish the current batch
+	LockstepComponentRollout ComponentRolloutStrategyType = "Lockstep"
+	// OrderedComponentRollout rolls out the components one by one, a component starts only after the components
+	// before it in the component list succeed
+	OrderedComponentRollout ComponentRolloutStrategyType = "Ordered"
+)
+
 // AppRolloutSpec defines how to describe an upgrade between different apps
 type AppRolloutSpec struct {
 	// TargetAppRevisionName contains the name of the applicationConfiguration that we need to upgrade to.
@@ -34,11 +46,16 @@ type AppRolloutSpec struct {
 	SourceAppRevisionName string `json:"sourceAppRevisionName,omitempty"`
 
 	// The list of component to upgrade in the application.
-	// We only support single component application so far
-	// TODO: (RZ) Support multiple components in an application
+	// Every component is rolled out by the rollout plan with its own status
 	// +optional
 	ComponentList []string `json:"componentList,omitempty"`
 
+	// ComponentRolloutStrategy is how to roll out the components in the component list, Lockstep rolls out the
+	// components batch by batch together and Ordered rolls out the components one by one in the order of the list.
+	// Default is Lockstep
+	// +optional
+	ComponentRolloutStrategy ComponentRolloutStrategyType `json:"componentRolloutStrategy,omitempty"`
+
 	// RolloutPlan is the details on how to rollout the resources
 	RolloutPlan v1alpha1.RolloutPlan `json:"rolloutPlan"`
 
@@ -47,6 +64,12 @@ type AppRolloutSpec struct {
 	// Default is false
 	// +optional
 	RevertOnDelete bool `json:"revertOnDelete,omitempty"`
+
+	// AutoRollback rolls the upgraded batches back to the source app revision when the rollout fails,
+	// the rollout ends in the rolloutRolledBack state once the rollback finishes.
+	// It only works when the source app revision is set, default is false
+	// +optional
+	AutoRollback bool `json:"autoRollback,omitempty"`
 }
 
 // AppRollout is the Schema for the AppRollout API
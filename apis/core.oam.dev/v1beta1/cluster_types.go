@@ -17,6 +17,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -38,9 +40,33 @@ type LocalSecretReference struct {
 
 // ClusterStatus defines the observed state of Cluster
 type ClusterStatus struct {
+	// ConditionedStatus reflects the observed status of a resource,
+	// the cluster is Ready if it's reachable with the kubeconfig.
+	runtimev1alpha1.ConditionedStatus `json:",inline"`
+
+	// KubernetesVersion is the version of the Kubernetes API server of the cluster.
+	// +optional
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+
+	// NodeCount is the number of nodes in the cluster.
+	// +optional
+	NodeCount int `json:"nodeCount,omitempty"`
+
+	// Capacity is the total resources of the nodes in the cluster.
+	// +optional
+	Capacity corev1.ResourceList `json:"capacity,omitempty"`
+
+	// LastProbeTime is the last time the cluster was probed.
+	// +optional
+	LastProbeTime *metav1.Time `json:"lastProbeTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="READY",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="VERSION",type=string,JSONPath=`.status.kubernetesVersion`
+// +kubebuilder:printcolumn:name="NODES",type=integer,JSONPath=`.status.nodeCount`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=".metadata.creationTimestamp"
 
 // Cluster is the Schema for the clusters API
 type Cluster struct {
@@ -0,0 +1,77 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MetricProviderType is the type of the metric provider
+type MetricProviderType string
+
+const (
+	// PrometheusMetricProvider queries the metric by the Prometheus HTTP API
+	PrometheusMetricProvider MetricProviderType = "prometheus"
+)
+
+// MetricProvider describes where to query the metric
+type MetricProvider struct {
+	// Type of the metric provider, only prometheus is supported now
+	// +kubebuilder:validation:Enum=prometheus
+	Type MetricProviderType `json:"type"`
+
+	// Address of the metric provider, e.g. http://prometheus.monitoring:9090
+	Address string `json:"address"`
+}
+
+// MetricTemplateSpec defines the desired state of MetricTemplate
+type MetricTemplateSpec struct {
+	// Provider of the metric
+	Provider MetricProvider `json:"provider"`
+
+	// Query to get the metric value from the provider. It's a go template rendered with
+	// {{ .Namespace }} and {{ .Name }} of the target workload, {{ .SourceName }} of the source workload,
+	// {{ .Interval }} of the canary metric (1m by default) and {{ .Batch }} of the current batch.
+	// The query should result in a single value.
+	Query string `json:"query"`
+}
+
+// MetricTemplate is the Schema for the MetricTemplate API, it's referenced by the canary metrics of a rollout plan
+// +kubebuilder:object:root=true
+// +genclient
+// +kubebuilder:resource:categories={oam}
+// +kubebuilder:printcolumn:name="PROVIDER",type=string,JSONPath=`.spec.provider.type`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=".metadata.creationTimestamp"
+type MetricTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MetricTemplateSpec `json:"spec,omitempty"`
+}
+
+// MetricTemplateList contains a list of MetricTemplate
+// +kubebuilder:object:root=true
+// +genclient
+type MetricTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MetricTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MetricTemplate{}, &MetricTemplateList{})
+}
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -46,6 +47,18 @@ const (
 	FinalizeRolloutHook HookType = "finalize-rollout"
 )
 
+// TrafficProviderType is the type of the provider to shift the traffic
+type TrafficProviderType string
+
+const (
+	// IstioTrafficProvider shifts the traffic by an istio VirtualService
+	IstioTrafficProvider TrafficProviderType = "istio"
+	// SMITrafficProvider shifts the traffic by a service mesh interface TrafficSplit
+	SMITrafficProvider TrafficProviderType = "smi"
+	// GatewayAPITrafficProvider shifts the traffic by a Gateway API HTTPRoute
+	GatewayAPITrafficProvider TrafficProviderType = "gateway-api"
+)
+
 // RollingState is the overall rollout state
 type RollingState string
 
@@ -76,6 +89,9 @@ const (
 	// RolloutFailedState indicates that rollout is failed, the target replica is not reached
 	// we can not move forward anymore, we will let the client to decide when or whether to revert.
 	RolloutFailedState RollingState = "rolloutFailed"
+
+	// RolloutRolledBackState indicates that the failed rollout is rolled back to the source, this is a terminal state
+	RolloutRolledBackState RollingState = "rolloutRolledBack"
 )
 
 // BatchRollingState is the sub state when the rollout is on the fly
@@ -140,6 +156,34 @@ type RolloutPlan struct {
 	// before complete the process
 	// +optional
 	CanaryMetric []CanaryMetric `json:"canaryMetric,omitempty"`
+
+	// TrafficRouting shifts the traffic between the source and the target by the traffic weight of each batch
+	// It only works when the source and the target are different workloads
+	// +optional
+	TrafficRouting *TrafficRouting `json:"trafficRouting,omitempty"`
+}
+
+// TrafficRouting describes how to shift the traffic between the source and the target
+type TrafficRouting struct {
+	// Provider is the provider to shift the traffic, istio, smi or gateway-api
+	Provider TrafficProviderType `json:"provider"`
+
+	// Service is the name of the service accessed by the clients, its traffic is split between the services
+	// of the source and the target workloads created by the rollout
+	Service string `json:"service"`
+
+	// Port is the port of the service and the pods to route the traffic to
+	Port int32 `json:"port"`
+
+	// Hosts are the hosts routed by the istio VirtualService or the Gateway API HTTPRoute,
+	// default is the service
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
+
+	// Gateways are the istio gateways or the Gateway API gateways to attach the route to,
+	// a gateway in other namespaces is referred to by `<namespace>/<name>`
+	// +optional
+	Gateways []string `json:"gateways,omitempty"`
 }
 
 // RolloutBatch is used to describe how the each batch rollout should be
@@ -175,6 +219,17 @@ type RolloutBatch struct {
 	// before moving to the next batch
 	// +optional
 	CanaryMetric []CanaryMetric `json:"canaryMetric,omitempty"`
+
+	// TrafficWeight is the percentage (0-100) of the traffic routed to the target once the pods of the batch
+	// are ready, the traffic is kept as the previous batch if it's not set
+	// It only works with the traffic routing of the rollout plan
+	// +optional
+	TrafficWeight *int32 `json:"trafficWeight,omitempty"`
+
+	// RequireApproval makes the rollout wait in the batchReady state until the batch is approved manually
+	// before moving to the next batch, default is false
+	// +optional
+	RequireApproval bool `json:"requireApproval,omitempty"`
 }
 
 // RolloutWebhook holds the reference to external checks used for canary analysis
@@ -226,7 +281,8 @@ type CanaryMetric struct {
 	// +optional
 	MetricsRange *MetricsExpectedRange `json:"metricsRange,omitempty"`
 
-	// TemplateRef references a metric template object
+	// TemplateRef references a metric template object, the MetricTemplate with the same name as the metric
+	// in the namespace of the rollout is used if it's not set
 	// +optional
 	TemplateRef *runtimev1alpha1.TypedReference `json:"templateRef,omitempty"`
 }
@@ -280,4 +336,25 @@ type RolloutStatus struct {
 
 	// UpgradedReadyReplicas is the number of Pods upgraded by the rollout controller that have a Ready Condition.
 	UpgradedReadyReplicas int32 `json:"upgradedReadyReplicas"`
+
+	// TrafficWeight is the percentage of the traffic routed to the target by the traffic routing
+	// +optional
+	TrafficWeight *int32 `json:"trafficWeight,omitempty"`
+
+	// BatchApprovals records the manual approvals of the batches that require approval
+	// +optional
+	BatchApprovals []BatchApproval `json:"batchApprovals,omitempty"`
+}
+
+// BatchApproval records the manual approval of a rollout batch
+type BatchApproval struct {
+	// Batch is the approved batch, it starts from 0
+	Batch int32 `json:"batch"`
+
+	// Approver is who approved the batch, it's empty if not given
+	// +optional
+	Approver string `json:"approver,omitempty"`
+
+	// ApprovedAt is the time when the approval was observed by the rollout controller
+	ApprovedAt metav1.Time `json:"approvedAt"`
 }
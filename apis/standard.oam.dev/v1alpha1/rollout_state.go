@@ -45,6 +45,12 @@ const (
 	// RollingDeletedEvent indicates that the rolling is being deleted
 	RollingDeletedEvent RolloutEvent = "RollingDeletedEvent"
 
+	// RollingBackEvent indicates that the failed rollout starts to roll back to the source
+	RollingBackEvent RolloutEvent = "RollingBackEvent"
+
+	// RolledBackEvent indicates that the rollout back to the source is finished
+	RolledBackEvent RolloutEvent = "RolledBackEvent"
+
 	// RollingSpecVerifiedEvent indicates that we have successfully verified that the rollout spec
 	RollingSpecVerifiedEvent RolloutEvent = "RollingSpecVerifiedEvent"
 
@@ -98,6 +104,8 @@ const (
 	RolloutFailed runtimev1alpha1.ConditionType = "RolloutFailed"
 	// RolloutSucceed means that the rollout is done.
 	RolloutSucceed runtimev1alpha1.ConditionType = "RolloutSucceed"
+	// RolloutRolledBack means that the failed rollout is rolled back.
+	RolloutRolledBack runtimev1alpha1.ConditionType = "RolloutRolledBack"
 	// BatchInitializing
 	BatchInitializing runtimev1alpha1.ConditionType = "BatchInitializing"
 	// BatchPaused
@@ -180,6 +188,9 @@ func (r *RolloutStatus) getRolloutConditionType() runtimev1alpha1.ConditionType
 	case RolloutSucceedState:
 		return RolloutSucceed
 
+	case RolloutRolledBackState:
+		return RolloutRolledBack
+
 	default:
 		return RolloutFailed
 	}
@@ -216,6 +227,24 @@ func (r *RolloutStatus) ResetStatus() {
 	r.CurrentBatch = 0
 	r.UpgradedReplicas = 0
 	r.UpgradedReadyReplicas = 0
+	r.BatchApprovals = nil
+	r.TrafficWeight = nil
+}
+
+// IsTerminated checks if the rollout is in a terminal state
+func (r *RolloutStatus) IsTerminated() bool {
+	return r.RollingState == RolloutSucceedState || r.RollingState == RolloutFailedState ||
+		r.RollingState == RolloutRolledBackState
+}
+
+// GetBatchApproval returns the recorded approval of the batch, it returns nil if the batch is not approved
+func (r *RolloutStatus) GetBatchApproval(batch int32) *BatchApproval {
+	for i := range r.BatchApprovals {
+		if r.BatchApprovals[i].Batch == batch {
+			return &r.BatchApprovals[i]
+		}
+	}
+	return nil
 }
 
 // SetRolloutCondition sets the supplied condition, replacing any existing condition
@@ -274,7 +303,7 @@ func (r *RolloutStatus) StateTransition(event RolloutEvent) {
 			r.illegalStateTransition(fmt.Errorf(invalidRollingStateTransition, rollingState, event))
 			return
 		}
-		if r.RollingState == RolloutFailedState || r.RollingState == RolloutSucceedState {
+		if r.IsTerminated() {
 			r.ResetStatus()
 		} else {
 			r.SetRolloutCondition(NewNegativeCondition(r.getRolloutConditionType(), "Rollout Spec is modified"))
@@ -286,7 +315,7 @@ func (r *RolloutStatus) StateTransition(event RolloutEvent) {
 
 	// special handle deleted event here, it can happen at many states
 	if event == RollingDeletedEvent {
-		if r.RollingState == RolloutFailedState || r.RollingState == RolloutSucceedState {
+		if r.IsTerminated() {
 			r.illegalStateTransition(fmt.Errorf(invalidRollingStateTransition, rollingState, event))
 			return
 		}
@@ -358,7 +387,23 @@ func (r *RolloutStatus) StateTransition(event RolloutEvent) {
 		}
 		r.illegalStateTransition(fmt.Errorf(invalidRollingStateTransition, rollingState, event))
 
-	case RolloutSucceedState, RolloutFailedState:
+	case RolloutFailedState:
+		if event == RollingBackEvent {
+			// roll back from the beginning with the source and the target swapped
+			r.ResetStatus()
+			return
+		}
+		r.illegalStateTransition(fmt.Errorf(invalidRollingStateTransition, rollingState, event))
+
+	case RolloutSucceedState:
+		if event == RolledBackEvent {
+			r.SetRolloutCondition(NewPositiveCondition(RolloutRolledBack))
+			r.RollingState = RolloutRolledBackState
+			return
+		}
+		r.illegalStateTransition(fmt.Errorf(invalidRollingStateTransition, rollingState, event))
+
+	case RolloutRolledBackState:
 		r.illegalStateTransition(fmt.Errorf(invalidRollingStateTransition, rollingState, event))
 
 	default:
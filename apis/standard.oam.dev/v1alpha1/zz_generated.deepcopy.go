@@ -26,6 +26,22 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BatchApproval) DeepCopyInto(out *BatchApproval) {
+	*out = *in
+	in.ApprovedAt.DeepCopyInto(&out.ApprovedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BatchApproval.
+func (in *BatchApproval) DeepCopy() *BatchApproval {
+	if in == nil {
+		return nil
+	}
+	out := new(BatchApproval)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanaryMetric) DeepCopyInto(out *CanaryMetric) {
 	*out = *in
@@ -51,6 +67,95 @@ func (in *CanaryMetric) DeepCopy() *CanaryMetric {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricProvider) DeepCopyInto(out *MetricProvider) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricProvider.
+func (in *MetricProvider) DeepCopy() *MetricProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricTemplate) DeepCopyInto(out *MetricTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricTemplate.
+func (in *MetricTemplate) DeepCopy() *MetricTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MetricTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricTemplateList) DeepCopyInto(out *MetricTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MetricTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricTemplateList.
+func (in *MetricTemplateList) DeepCopy() *MetricTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MetricTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricTemplateSpec) DeepCopyInto(out *MetricTemplateSpec) {
+	*out = *in
+	out.Provider = in.Provider
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricTemplateSpec.
+func (in *MetricTemplateSpec) DeepCopy() *MetricTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetricsExpectedRange) DeepCopyInto(out *MetricsExpectedRange) {
 	*out = *in
@@ -210,6 +315,11 @@ func (in *RolloutBatch) DeepCopyInto(out *RolloutBatch) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TrafficWeight != nil {
+		in, out := &in.TrafficWeight, &out.TrafficWeight
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutBatch.
@@ -261,6 +371,11 @@ func (in *RolloutPlan) DeepCopyInto(out *RolloutPlan) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TrafficRouting != nil {
+		in, out := &in.TrafficRouting, &out.TrafficRouting
+		*out = new(TrafficRouting)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutPlan.
@@ -277,6 +392,18 @@ func (in *RolloutPlan) DeepCopy() *RolloutPlan {
 func (in *RolloutStatus) DeepCopyInto(out *RolloutStatus) {
 	*out = *in
 	in.ConditionedStatus.DeepCopyInto(&out.ConditionedStatus)
+	if in.TrafficWeight != nil {
+		in, out := &in.TrafficWeight, &out.TrafficWeight
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BatchApprovals != nil {
+		in, out := &in.BatchApprovals, &out.BatchApprovals
+		*out = make([]BatchApproval, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStatus.
@@ -422,3 +549,28 @@ func (in *RolloutWebhookPayload) DeepCopy() *RolloutWebhookPayload {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficRouting) DeepCopyInto(out *TrafficRouting) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficRouting.
+func (in *TrafficRouting) DeepCopy() *TrafficRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficRouting)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -25,6 +25,7 @@ const (
 	ReasonHealthCheck = "HealthChecked"
 	ReasonDeployed    = "Deployed"
 	ReasonRollout     = "Rollout"
+	ReasonDriftHealed = "DriftHealed"
 
 	ReasonFailedParse       = "FailedParse"
 	ReasonFailedRender      = "FailedRender"
@@ -34,6 +35,9 @@ const (
 	ReasonFailedHealthCheck = "FailedHealthCheck"
 	ReasonFailedGC          = "FailedGC"
 	ReasonFailedRollout     = "FailedRollout"
+	ReasonFailedPolicy      = "FailedPolicy"
+	ReasonFailedPlacement   = "FailedPlacement"
+	ReasonDriftDetected     = "DriftDetected"
 )
 
 // event message for Application
@@ -0,0 +1,170 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import "k8s.io/apimachinery/pkg/runtime"
+
+const (
+	// PolicyTypeTopology is the built-in policy type which selects the clusters an application is deployed to
+	PolicyTypeTopology = "topology"
+	// PolicyTypeOverride is the built-in policy type which patches the components deployed to some clusters
+	PolicyTypeOverride = "override"
+	// PolicyTypeGarbageCollect is the built-in policy type which configures how the resources of an application are
+	// garbage collected
+	PolicyTypeGarbageCollect = "garbage-collect"
+	// PolicyTypeUpdateCheck is the built-in policy type which configures how the breaking changes in an update of
+	// an application are handled
+	PolicyTypeUpdateCheck = "update-check"
+)
+
+// TopologyPolicySpec is the properties of the topology policy.
+// The application is deployed to the union of the clusters selected by names and by labels.
+type TopologyPolicySpec struct {
+	// Clusters is the names of the clusters to deploy to.
+	Clusters []string `json:"clusters,omitempty"`
+	// ClusterSelector selects the clusters to deploy to by labels.
+	ClusterSelector map[string]string `json:"clusterSelector,omitempty"`
+}
+
+// OverridePolicySpec is the properties of the override policy.
+// The component overrides are applied in order to the application deployed to the selected clusters.
+type OverridePolicySpec struct {
+	// Clusters is the names of the clusters to override, all clusters are overridden if it's empty.
+	Clusters []string `json:"clusters,omitempty"`
+	// Components is the overrides of the components.
+	Components []ComponentOverride `json:"components"`
+}
+
+// ComponentOverride patches the components selected by name or type.
+// All components are selected if neither name nor type is given.
+type ComponentOverride struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type,omitempty"`
+	// Properties is merged into the properties of the component as a JSON merge patch.
+	Properties *runtime.RawExtension `json:"properties,omitempty"`
+	// Traits is the overrides of the traits of the component.
+	Traits []TraitOverride `json:"traits,omitempty"`
+}
+
+// TraitOverride patches or disables the trait of the type.
+type TraitOverride struct {
+	Type string `json:"type"`
+	// Properties is merged into the properties of the trait as a JSON merge patch.
+	Properties *runtime.RawExtension `json:"properties,omitempty"`
+	// Disable removes the trait from the component.
+	Disable bool `json:"disable,omitempty"`
+}
+
+// GarbageCollectStrategy is the strategy to garbage collect a resource.
+type GarbageCollectStrategy string
+
+const (
+	// GarbageCollectStrategyOnAppUpdate deletes the resource once it's removed from the application, it's the default strategy.
+	GarbageCollectStrategyOnAppUpdate GarbageCollectStrategy = "onAppUpdate"
+	// GarbageCollectStrategyOnAppDelete keeps the resource removed from the application, it's deleted with the application.
+	GarbageCollectStrategyOnAppDelete GarbageCollectStrategy = "onAppDelete"
+	// GarbageCollectStrategyNever never deletes the resource, it's orphaned when it's removed from the application
+	// or the application is deleted.
+	GarbageCollectStrategyNever GarbageCollectStrategy = "never"
+)
+
+// IsGarbageCollectStrategy checks whether the strategy is supported.
+func IsGarbageCollectStrategy(strategy GarbageCollectStrategy) bool {
+	switch strategy {
+	case GarbageCollectStrategyOnAppUpdate, GarbageCollectStrategyOnAppDelete, GarbageCollectStrategyNever:
+		return true
+	default:
+		return false
+	}
+}
+
+// GarbageCollectOrder is the order to delete the resources.
+type GarbageCollectOrder string
+
+const (
+	// GarbageCollectOrderDependency deletes the workloads first, then the traits, then the other resources.
+	// The resources of the next kind are deleted only after the ones before are gone.
+	GarbageCollectOrderDependency GarbageCollectOrder = "dependency"
+)
+
+// GarbageCollectPolicySpec is the properties of the garbage-collect policy.
+type GarbageCollectPolicySpec struct {
+	// KeepLegacyResource keeps all the resources removed from the application until the application is deleted,
+	// it's the same as the onAppDelete strategy for the resources not selected by any rule.
+	KeepLegacyResource bool `json:"keepLegacyResource,omitempty"`
+	// Order is the order to delete the resources, they are deleted at the same time if it's empty.
+	Order GarbageCollectOrder `json:"order,omitempty"`
+	// Rules set the strategies of the resources they select, the first matched rule is used.
+	Rules []GarbageCollectPolicyRule `json:"rules,omitempty"`
+}
+
+// GarbageCollectPolicyRule sets the strategy of the selected resources.
+type GarbageCollectPolicyRule struct {
+	Selector ResourceSelector       `json:"selector"`
+	Strategy GarbageCollectStrategy `json:"strategy"`
+}
+
+// ResourceSelector selects the resources of an application, a resource is selected if it matches any of the conditions.
+type ResourceSelector struct {
+	// ComponentNames selects the resources of the components.
+	ComponentNames []string `json:"componentNames,omitempty"`
+	// TraitTypes selects the resources rendered by the traits of the types.
+	TraitTypes []string `json:"traitTypes,omitempty"`
+	// ResourceTypes selects the resources of the kinds.
+	ResourceTypes []string `json:"resourceTypes,omitempty"`
+}
+
+// UpdateCheckAction is the action taken when an update of an application fails a check.
+type UpdateCheckAction string
+
+const (
+	// UpdateCheckActionWarn admits the update and reports the failed check as a warning.
+	UpdateCheckActionWarn UpdateCheckAction = "warn"
+	// UpdateCheckActionDeny rejects the update.
+	UpdateCheckActionDeny UpdateCheckAction = "deny"
+)
+
+// IsUpdateCheckAction checks whether the action is supported.
+func IsUpdateCheckAction(action UpdateCheckAction) bool {
+	return action == UpdateCheckActionWarn || action == UpdateCheckActionDeny
+}
+
+// UpdateCheckPolicySpec is the properties of the update-check policy, it sets the action taken when an update
+// fails each check, the default action is used if it's empty. The policy of the application before the update applies,
+// so a change of the policy takes effect from the next update.
+type UpdateCheckPolicySpec struct {
+	// ComponentTypeChange is the action when the type of a component is changed while the component is rolling out,
+	// it's deny by default.
+	ComponentTypeChange UpdateCheckAction `json:"componentTypeChange,omitempty"`
+	// ComponentRename is the action when a component owning PersistentVolumeClaims is renamed, it's warn by default.
+	// A removed component is regarded as renamed if a component of the same type is added in the same update.
+	ComponentRename UpdateCheckAction `json:"componentRename,omitempty"`
+	// RolloutComponentRemoval is the action when a component is removed while an AppRollout rolls it out,
+	// it's deny by default.
+	RolloutComponentRemoval UpdateCheckAction `json:"rolloutComponentRemoval,omitempty"`
+}
+
+// IsBuiltinPolicyType checks whether the policy type is handled by the controller
+// without a PolicyDefinition.
+func IsBuiltinPolicyType(policyType string) bool {
+	switch policyType {
+	case PolicyTypeTopology, PolicyTypeOverride, PolicyTypeGarbageCollect, PolicyTypeUpdateCheck:
+		return true
+	default:
+		return false
+	}
+}
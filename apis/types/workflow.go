@@ -26,4 +26,26 @@ type WorkflowContext struct {
 	AppRevision       string                      `json:"appRevision,omitempty"`
 	WorkflowIndex     int                         `json:"workflowIndex"`
 	ResourceConfigMap corev1.LocalObjectReference `json:"resourceConfigMap,omitempty"`
+	// ContextConfigMap is the ConfigMap storing the values exported by the outputs of workflow steps.
+	ContextConfigMap corev1.LocalObjectReference `json:"contextConfigMap,omitempty"`
+}
+
+const (
+	// WorkflowStepTypeApplyComponent is the built-in workflow step type which applies the resources of one component
+	WorkflowStepTypeApplyComponent = "apply-component"
+	// WorkflowStepTypeApplyApplication is the built-in workflow step type which applies the resources of all components
+	WorkflowStepTypeApplyApplication = "apply-application"
+	// WorkflowStepTypeSuspend is the built-in workflow step type which suspends the workflow until it's resumed
+	WorkflowStepTypeSuspend = "suspend"
+)
+
+// IsBuiltinWorkflowStepType checks whether the workflow step type is executed by the controller
+// without a WorkflowStepDefinition.
+func IsBuiltinWorkflowStepType(stepType string) bool {
+	switch stepType {
+	case WorkflowStepTypeApplyComponent, WorkflowStepTypeApplyApplication, WorkflowStepTypeSuspend:
+		return true
+	default:
+		return false
+	}
 }
@@ -39,6 +39,7 @@ import (
 	"github.com/oam-dev/kubevela/pkg/cue/packages"
 	"github.com/oam-dev/kubevela/pkg/oam"
 	"github.com/oam-dev/kubevela/pkg/oam/discoverymapper"
+	"github.com/oam-dev/kubevela/pkg/utils/apply"
 	"github.com/oam-dev/kubevela/pkg/utils/common"
 	"github.com/oam-dev/kubevela/pkg/utils/system"
 	oamwebhook "github.com/oam-dev/kubevela/pkg/webhook/core.oam.dev"
@@ -101,6 +102,11 @@ func main() {
 	flag.IntVar(&controllerArgs.ConcurrentReconciles, "concurrent-reconciles", 4, "concurrent-reconciles is the concurrent reconcile number of the controller. The default value is 4")
 	flag.DurationVar(&controllerArgs.DependCheckWait, "depend-check-wait", 30*time.Second, "depend-check-wait is the time to wait for ApplicationConfiguration's dependent-resource ready."+
 		"The default value is 30s, which means if dependent resources were not prepared, the ApplicationConfiguration would be reconciled after 30s.")
+	flag.DurationVar(&controllerArgs.ClusterProbeInterval, "cluster-probe-interval", time.Minute, "cluster-probe-interval is the interval to probe the health of the managed clusters. The default value is 1m")
+	flag.DurationVar(&controllerArgs.DriftDetectionInterval, "drift-detection-interval", 5*time.Minute, "drift-detection-interval is the interval to detect the resources of running applications changed outside of them, 0 disables drift detection. The default value is 5m")
+	flag.StringVar((*string)(&apply.DefaultMode), "apply-mode", string(apply.ModeClientSide), "apply-mode is the mode to apply the resources of applications, available options: client-side, server-side. "+
+		"It's overridden by the app.oam.dev/apply-mode annotation of a resource. The default value is client-side")
+	flag.BoolVar(&apply.ForceConflicts, "apply-force-conflicts", false, "apply-force-conflicts takes over the fields managed by others when applying resources in server side, otherwise applying fails on conflicts. The default value is false")
 	flag.StringVar(&controllerArgs.OAMSpecVer, "oam-spec-ver", "v0.3", "oam-spec-ver is the oam spec version controller want to setup, available options: v0.2, v0.3, all")
 
 	flag.Parse()
@@ -119,6 +125,10 @@ func main() {
 	klog.InfoS("KubeVela information", "version", version.VelaVersion, "revision", version.GitRevision)
 	klog.InfoS("Disable capabilities", "name", disableCaps)
 	klog.InfoS("Vela-Core init", "definition namespace", oam.SystemDefinitonNamespace)
+	if !apply.IsValidMode(apply.DefaultMode) {
+		klog.ErrorS(nil, "Unknown apply mode", "apply-mode", apply.DefaultMode)
+		os.Exit(1)
+	}
 
 	restConfig := ctrl.GetConfigOrDie()
 	restConfig.UserAgent = kubevelaName + "/" + version.GitRevision
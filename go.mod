@@ -45,6 +45,7 @@ require (
 	github.com/onsi/gomega v1.10.3
 	github.com/openkruise/kruise-api v0.7.0
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.6.0
 	github.com/satori/go.uuid v1.2.1-0.20181028125025-b2ce2384e17b
 	github.com/spf13/cobra v1.1.1
 	github.com/spf13/pflag v1.0.5
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/oam-dev/kubevela/pkg/appfile/config"
 
@@ -43,6 +44,7 @@ import (
 	"github.com/oam-dev/kubevela/pkg/appfile/helm"
 	"github.com/oam-dev/kubevela/pkg/cue/definition"
 	"github.com/oam-dev/kubevela/pkg/cue/process"
+	"github.com/oam-dev/kubevela/pkg/monitor/metrics"
 	"github.com/oam-dev/kubevela/pkg/oam"
 	"github.com/oam-dev/kubevela/pkg/oam/util"
 )
@@ -95,6 +97,7 @@ func (wl *Workload) GetUserConfigName() string {
 
 // EvalContext eval workload template and set result to context
 func (wl *Workload) EvalContext(ctx process.Context) error {
+	defer metrics.ObserveSince(metrics.TemplateRenderDuration.WithLabelValues("workload", wl.Type), time.Now())
 	return wl.engine.Complete(ctx, wl.FullTemplate.TemplateStr, wl.Params)
 }
 
@@ -104,7 +107,7 @@ func (wl *Workload) EvalStatus(ctx process.Context, cli client.Client, ns string
 }
 
 // EvalHealth eval workload health check
-func (wl *Workload) EvalHealth(ctx process.Context, client client.Client, namespace string) (bool, error) {
+func (wl *Workload) EvalHealth(ctx process.Context, client client.Client, namespace string) (definition.HealthResult, error) {
 	return wl.engine.HealthCheck(ctx, client, namespace, wl.FullTemplate.Health)
 }
 
@@ -151,6 +154,7 @@ type Trait struct {
 
 // EvalContext eval trait template and set result to context
 func (trait *Trait) EvalContext(ctx process.Context) error {
+	defer metrics.ObserveSince(metrics.TemplateRenderDuration.WithLabelValues("trait", trait.Name), time.Now())
 	return trait.engine.Complete(ctx, trait.Template, trait.Params)
 }
 
@@ -160,7 +164,7 @@ func (trait *Trait) EvalStatus(ctx process.Context, cli client.Client, ns string
 }
 
 // EvalHealth eval trait health check
-func (trait *Trait) EvalHealth(ctx process.Context, client client.Client, namespace string) (bool, error) {
+func (trait *Trait) EvalHealth(ctx process.Context, client client.Client, namespace string) (definition.HealthResult, error) {
 	return trait.engine.HealthCheck(ctx, client, namespace, trait.HealthCheckPolicy)
 }
 
@@ -181,21 +185,27 @@ type Appfile struct {
 	RevisionName string
 	Workloads    []*Workload
 
-	Policies      []*Workload
+	Policies []*Workload
+	// PatchPolicies are policies which patch or validate the rendered resources of every component
+	// instead of rendering standalone resources.
+	PatchPolicies []*Workload
 	WorkflowSteps []*Workload
+
+	// Topology is the properties of the topology policy which selects the clusters to deploy to,
+	// the application is deployed to the host cluster if it's nil.
+	Topology *types.TopologyPolicySpec
+	// Overrides is the properties of the override policies which patch the components deployed to some clusters.
+	Overrides []types.OverridePolicySpec
+	// GarbageCollect is the properties of the garbage-collect policy, it's nil if there is no garbage-collect policy.
+	GarbageCollect *types.GarbageCollectPolicySpec
+	// UpdateCheck is the properties of the update-check policy, it's nil if there is no update-check policy.
+	UpdateCheck *types.UpdateCheckPolicySpec
 }
 
-// GenerateWorkflowAndPolicy generates workflow steps and policies from an appFile
-func (af *Appfile) GenerateWorkflowAndPolicy() (policies, steps []*unstructured.Unstructured, err error) {
-	policies, err = af.generateUnstructureds(af.Policies)
-	if err != nil {
-		return
-	}
-	steps, err = af.generateUnstructureds(af.WorkflowSteps)
-	if err != nil {
-		return
-	}
-	return
+// GeneratePolicyManifests generates policy manifests from an appFile.
+// Workflow steps are not rendered here because they are evaluated by the workflow when executed.
+func (af *Appfile) GeneratePolicyManifests() ([]*unstructured.Unstructured, error) {
+	return af.generateUnstructureds(af.Policies)
 }
 
 func (af *Appfile) generateUnstructureds(workloads []*Workload) ([]*unstructured.Unstructured, error) {
@@ -241,13 +251,13 @@ func (af *Appfile) GenerateComponentManifest(wl *Workload) (*types.ComponentMani
 	}
 	switch wl.CapabilityCategory {
 	case types.HelmCategory:
-		return generateComponentFromHelmModule(wl, af.Name, af.RevisionName, af.Namespace)
+		return generateComponentFromHelmModule(wl, af.Name, af.RevisionName, af.Namespace, af.PatchPolicies)
 	case types.KubeCategory:
-		return generateComponentFromKubeModule(wl, af.Name, af.RevisionName, af.Namespace)
+		return generateComponentFromKubeModule(wl, af.Name, af.RevisionName, af.Namespace, af.PatchPolicies)
 	case types.TerraformCategory:
-		return generateComponentFromTerraformModule(wl, af.Name, af.RevisionName, af.Namespace)
+		return generateComponentFromTerraformModule(wl, af.Name, af.RevisionName, af.Namespace, af.PatchPolicies)
 	default:
-		return generateComponentFromCUEModule(wl, af.Name, af.RevisionName, af.Namespace)
+		return generateComponentFromCUEModule(wl, af.Name, af.RevisionName, af.Namespace, af.PatchPolicies)
 	}
 }
 
@@ -304,20 +314,20 @@ func GetSecretAndConfigs(cli client.Client, workload *Workload, appName, ns stri
 	return nil
 }
 
-func generateComponentFromCUEModule(wl *Workload, appName, revision, ns string) (*types.ComponentManifest, error) {
+func generateComponentFromCUEModule(wl *Workload, appName, revision, ns string, policies []*Workload) (*types.ComponentManifest, error) {
 	pCtx, err := PrepareProcessContext(wl, appName, revision, ns)
 	if err != nil {
 		return nil, err
 	}
-	return baseGenerateComponent(pCtx, wl, appName, ns)
+	return baseGenerateComponent(pCtx, wl, appName, ns, policies)
 }
 
-func generateComponentFromTerraformModule(wl *Workload, appName, revision, ns string) (*types.ComponentManifest, error) {
+func generateComponentFromTerraformModule(wl *Workload, appName, revision, ns string, policies []*Workload) (*types.ComponentManifest, error) {
 	pCtx := NewBasicContext(wl, appName, revision, ns)
-	return baseGenerateComponent(pCtx, wl, appName, ns)
+	return baseGenerateComponent(pCtx, wl, appName, ns, policies)
 }
 
-func baseGenerateComponent(pCtx process.Context, wl *Workload, appName, ns string) (*types.ComponentManifest, error) {
+func baseGenerateComponent(pCtx process.Context, wl *Workload, appName, ns string, policies []*Workload) (*types.ComponentManifest, error) {
 	var (
 		outputSecretName string
 		err              error
@@ -335,6 +345,14 @@ func baseGenerateComponent(pCtx process.Context, wl *Workload, appName, ns strin
 			return nil, errors.Wrapf(err, "evaluate template trait=%s app=%s", tr.Name, wl.Name)
 		}
 	}
+	// policies are evaluated after traits, so that they can patch or validate the resources rendered by traits
+	if base, _ := pCtx.Output(); base != nil {
+		for _, policy := range policies {
+			if err := policy.EvalContext(pCtx); err != nil {
+				return nil, errors.Wrapf(err, "evaluate template policy=%s app=%s", policy.Name, wl.Name)
+			}
+		}
+	}
 	compManifest, err := evalWorkloadWithContext(pCtx, wl, ns, appName, wl.Name)
 	if err != nil {
 		return nil, err
@@ -460,7 +478,7 @@ output: {
 	return templateStr, nil
 }
 
-func generateComponentFromKubeModule(wl *Workload, appName, revision, ns string) (*types.ComponentManifest, error) {
+func generateComponentFromKubeModule(wl *Workload, appName, revision, ns string, policies []*Workload) (*types.ComponentManifest, error) {
 	templateStr, err := GenerateCUETemplate(wl)
 	if err != nil {
 		return nil, err
@@ -468,7 +486,7 @@ func generateComponentFromKubeModule(wl *Workload, appName, revision, ns string)
 	wl.FullTemplate.TemplateStr = templateStr
 
 	// re-use the way CUE module generates comp & acComp
-	compManifest, err := generateComponentFromCUEModule(wl, appName, revision, ns)
+	compManifest, err := generateComponentFromCUEModule(wl, appName, revision, ns, policies)
 	if err != nil {
 		return nil, err
 	}
@@ -607,7 +625,7 @@ func setParameterValuesToKubeObj(obj *unstructured.Unstructured, values paramVal
 	return nil
 }
 
-func generateComponentFromHelmModule(wl *Workload, appName, revision, ns string) (*types.ComponentManifest, error) {
+func generateComponentFromHelmModule(wl *Workload, appName, revision, ns string, policies []*Workload) (*types.ComponentManifest, error) {
 	templateStr, err := GenerateCUETemplate(wl)
 	if err != nil {
 		return nil, err
@@ -619,7 +637,7 @@ func generateComponentFromHelmModule(wl *Workload, appName, revision, ns string)
 		Name: wl.Name,
 	}
 	if wl.FullTemplate.Reference.Type != types.AutoDetectWorkloadDefinition {
-		compManifest, err = generateComponentFromCUEModule(wl, appName, revision, ns)
+		compManifest, err = generateComponentFromCUEModule(wl, appName, revision, ns, policies)
 		if err != nil {
 			return nil, err
 		}
@@ -39,6 +39,7 @@ import (
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
 	oamtypes "github.com/oam-dev/kubevela/apis/types"
 	"github.com/oam-dev/kubevela/pkg/cue/definition"
+	"github.com/oam-dev/kubevela/pkg/cue/packages"
 	"github.com/oam-dev/kubevela/pkg/cue/process"
 	"github.com/oam-dev/kubevela/pkg/oam/util"
 )
@@ -886,6 +887,45 @@ func TestGetUserConfigName(t *testing.T) {
 	assert.Equal(t, wl3.GetUserConfigName(), config)
 }
 
+func TestGenerateComponentManifestsWithPolicies(t *testing.T) {
+	pd := &packages.PackageDiscover{}
+	newAppfile := func(policyTemplate string) *Appfile {
+		return &Appfile{
+			Name:         "myapp",
+			Namespace:    "default",
+			RevisionName: "myapp-v1",
+			Workloads: []*Workload{{
+				Name: "frontend",
+				Type: "worker",
+				FullTemplate: &Template{TemplateStr: `
+output: {
+	apiVersion: "apps/v1"
+	kind:       "Deployment"
+	spec: template: spec: containers: [{image: parameter.image}]
+}
+parameter: image: string
+`},
+				Params: map[string]interface{}{"image": "nginx"},
+				engine: definition.NewWorkloadAbstractEngine("frontend", pd),
+			}},
+			PatchPolicies: []*Workload{{
+				Name:         "policy",
+				Type:         "test-policy",
+				FullTemplate: &Template{TemplateStr: policyTemplate},
+				engine:       definition.NewPolicyAbstractEngine("policy", pd),
+			}},
+		}
+	}
+
+	comps, err := newAppfile(`patch: metadata: labels: team: "frontend"`).GenerateComponentManifests()
+	assert.NilError(t, err)
+	assert.Equal(t, "frontend", comps[0].StandardWorkload.GetLabels()["team"])
+
+	_, err = newAppfile(`violations: ["image is not allowed"]`).GenerateComponentManifests()
+	assert.Equal(t, true, definition.IsPolicyViolation(err))
+	assert.ErrorContains(t, err, "component frontend violates policy policy: image is not allowed")
+}
+
 func TestGenerateCUETemplate(t *testing.T) {
 
 	var testCorrectTemplate = func() runtime.RawExtension {
@@ -0,0 +1,137 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appfile
+
+import (
+	"context"
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/types"
+)
+
+// GenerateAppFileForCluster converts an application to an Appfile with the override policies
+// of the cluster applied to its components.
+func (p *Parser) GenerateAppFileForCluster(ctx context.Context, app *v1beta1.Application, cluster string) (*Appfile, error) {
+	overrides, err := parseOverrides(app.Spec.Policies)
+	if err != nil {
+		return nil, err
+	}
+	overridden, err := OverrideApplication(app, cluster, overrides)
+	if err != nil {
+		return nil, err
+	}
+	return p.GenerateAppFile(ctx, overridden)
+}
+
+// parseOverrides returns the properties of the override policies in order.
+func parseOverrides(policies []v1beta1.AppPolicy) ([]types.OverridePolicySpec, error) {
+	var overrides []types.OverridePolicySpec
+	for _, policy := range policies {
+		if policy.Type != types.PolicyTypeOverride {
+			continue
+		}
+		override := types.OverridePolicySpec{}
+		if policy.Properties.Raw != nil {
+			if err := json.Unmarshal(policy.Properties.Raw, &override); err != nil {
+				return nil, errors.WithMessagef(err, "invalid properties of override policy %s", policy.Name)
+			}
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides, nil
+}
+
+// OverrideApplication returns a copy of the application with the overrides of the cluster applied to its components.
+func OverrideApplication(app *v1beta1.Application, cluster string, overrides []types.OverridePolicySpec) (*v1beta1.Application, error) {
+	overridden := app.DeepCopy()
+	for _, override := range overrides {
+		if !overrideCluster(override, cluster) {
+			continue
+		}
+		for _, compOverride := range override.Components {
+			for i := range overridden.Spec.Components {
+				comp := &overridden.Spec.Components[i]
+				if (compOverride.Name != "" && compOverride.Name != comp.Name) ||
+					(compOverride.Type != "" && compOverride.Type != comp.Type) {
+					continue
+				}
+				if err := overrideComponent(comp, compOverride); err != nil {
+					return nil, errors.WithMessagef(err, "cannot override component %s", comp.Name)
+				}
+			}
+		}
+	}
+	return overridden, nil
+}
+
+func overrideCluster(override types.OverridePolicySpec, cluster string) bool {
+	if len(override.Clusters) == 0 {
+		return true
+	}
+	for _, c := range override.Clusters {
+		if c == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+func overrideComponent(comp *v1beta1.ApplicationComponent, override types.ComponentOverride) error {
+	if err := mergeProperties(&comp.Properties, override.Properties); err != nil {
+		return err
+	}
+	for _, traitOverride := range override.Traits {
+		traits := make([]v1beta1.ApplicationTrait, 0, len(comp.Traits))
+		for _, trait := range comp.Traits {
+			if trait.Type != traitOverride.Type {
+				traits = append(traits, trait)
+				continue
+			}
+			if traitOverride.Disable {
+				continue
+			}
+			if err := mergeProperties(&trait.Properties, traitOverride.Properties); err != nil {
+				return errors.WithMessagef(err, "trait %s", trait.Type)
+			}
+			traits = append(traits, trait)
+		}
+		comp.Traits = traits
+	}
+	return nil
+}
+
+// mergeProperties merges the patch into the properties as a JSON merge patch.
+func mergeProperties(properties *runtime.RawExtension, patch *runtime.RawExtension) error {
+	if patch == nil || len(patch.Raw) == 0 {
+		return nil
+	}
+	original := properties.Raw
+	if len(original) == 0 {
+		original = []byte("{}")
+	}
+	merged, err := jsonpatch.MergePatch(original, patch.Raw)
+	if err != nil {
+		return errors.Wrap(err, "cannot merge properties")
+	}
+	*properties = runtime.RawExtension{Raw: merged}
+	return nil
+}
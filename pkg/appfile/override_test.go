@@ -0,0 +1,85 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appfile
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+)
+
+func TestOverrideApplication(t *testing.T) {
+	raw := func(s string) runtime.RawExtension {
+		return runtime.RawExtension{Raw: []byte(s)}
+	}
+	app := &v1beta1.Application{
+		Spec: v1beta1.ApplicationSpec{
+			Components: []v1beta1.ApplicationComponent{
+				{
+					Name:       "frontend",
+					Type:       "webservice",
+					Properties: raw(`{"image":"nginx","cpu":"0.5"}`),
+					Traits: []v1beta1.ApplicationTrait{
+						{Type: "scaler", Properties: raw(`{"replicas":1}`)},
+						{Type: "ingress", Properties: raw(`{"domain":"example.com"}`)},
+					},
+				},
+				{
+					Name:       "backend",
+					Type:       "worker",
+					Properties: raw(`{"image":"busybox"}`),
+				},
+			},
+			Policies: []v1beta1.AppPolicy{
+				{
+					Name:       "mirror",
+					Type:       "override",
+					Properties: raw(`{"components":[{"properties":{"image":"mirror.io/app"}}]}`),
+				},
+				{
+					Name: "edge",
+					Type: "override",
+					Properties: raw(`{"clusters":["edge"],"components":[{"type":"webservice","properties":{"cpu":null},` +
+						`"traits":[{"type":"scaler","properties":{"replicas":3}},{"type":"ingress","disable":true}]}]}`),
+				},
+			},
+		},
+	}
+	overrides, err := parseOverrides(app.Spec.Policies)
+	assert.NilError(t, err)
+	assert.Equal(t, len(overrides), 2)
+
+	got, err := OverrideApplication(app, "cloud", overrides)
+	assert.NilError(t, err)
+	assert.Equal(t, string(got.Spec.Components[0].Properties.Raw), `{"cpu":"0.5","image":"mirror.io/app"}`)
+	assert.Equal(t, string(got.Spec.Components[1].Properties.Raw), `{"image":"mirror.io/app"}`)
+	assert.Equal(t, len(got.Spec.Components[0].Traits), 2)
+
+	got, err = OverrideApplication(app, "edge", overrides)
+	assert.NilError(t, err)
+	assert.Equal(t, string(got.Spec.Components[0].Properties.Raw), `{"image":"mirror.io/app"}`)
+	assert.Equal(t, string(got.Spec.Components[1].Properties.Raw), `{"image":"mirror.io/app"}`)
+	assert.Equal(t, len(got.Spec.Components[0].Traits), 1)
+	assert.Equal(t, string(got.Spec.Components[0].Traits[0].Properties.Raw), `{"replicas":3}`)
+
+	// the original application is not changed
+	assert.Equal(t, string(app.Spec.Components[0].Properties.Raw), `{"image":"nginx","cpu":"0.5"}`)
+	assert.Equal(t, len(app.Spec.Components[0].Traits), 2)
+}
@@ -18,6 +18,7 @@ package appfile
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -109,11 +110,35 @@ func (p *Parser) GenerateAppFile(ctx context.Context, app *v1beta1.Application)
 	appfile.Workloads = wds
 
 	var err error
-
-	appfile.Policies, err = p.parsePolicies(ctx, app.Spec.Policies)
+	appfile.Topology, err = parseTopology(app.Spec.Policies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parseTopology: %w", err)
+	}
+	appfile.Overrides, err = parseOverrides(app.Spec.Policies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parseOverrides: %w", err)
+	}
+	appfile.GarbageCollect, err = ParseGarbageCollectPolicy(app.Spec.Policies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parseGarbageCollectPolicy: %w", err)
+	}
+	appfile.UpdateCheck, err = ParseUpdateCheckPolicy(app.Spec.Policies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parseUpdateCheckPolicy: %w", err)
+	}
+	policies, err := p.parsePolicies(ctx, app.Spec.Policies)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parsePolicies: %w", err)
 	}
+	appfile.Policies = []*Workload{}
+	for _, policy := range policies {
+		if definition.IsPatchPolicy(policy.FullTemplate.TemplateStr) {
+			policy.engine = definition.NewPolicyAbstractEngine(policy.Name, p.pd)
+			appfile.PatchPolicies = append(appfile.PatchPolicies, policy)
+			continue
+		}
+		appfile.Policies = append(appfile.Policies, policy)
+	}
 
 	appfile.WorkflowSteps, err = p.parseWorkflow(ctx, app.Spec.Workflow)
 	if err != nil {
@@ -125,6 +150,10 @@ func (p *Parser) GenerateAppFile(ctx context.Context, app *v1beta1.Application)
 func (p *Parser) parsePolicies(ctx context.Context, policies []v1beta1.AppPolicy) ([]*Workload, error) {
 	ws := []*Workload{}
 	for _, policy := range policies {
+		if types.IsBuiltinPolicyType(policy.Type) {
+			// built-in policies are handled by the controller and have no PolicyDefinition
+			continue
+		}
 		w, err := p.makeWorkload(ctx, policy.Name, policy.Type, types.TypePolicy, policy.Properties)
 		if err != nil {
 			return nil, err
@@ -134,6 +163,84 @@ func (p *Parser) parsePolicies(ctx context.Context, policies []v1beta1.AppPolicy
 	return ws, nil
 }
 
+// parseTopology returns the properties of the topology policy, it's nil if there is no topology policy.
+func parseTopology(policies []v1beta1.AppPolicy) (*types.TopologyPolicySpec, error) {
+	var topology *types.TopologyPolicySpec
+	for _, policy := range policies {
+		if policy.Type != types.PolicyTypeTopology {
+			continue
+		}
+		if topology != nil {
+			return nil, errors.Errorf("policy %s: only one topology policy is allowed", policy.Name)
+		}
+		topology = &types.TopologyPolicySpec{}
+		if policy.Properties.Raw != nil {
+			if err := json.Unmarshal(policy.Properties.Raw, topology); err != nil {
+				return nil, errors.WithMessagef(err, "invalid properties of topology policy %s", policy.Name)
+			}
+		}
+		if len(topology.Clusters) == 0 && len(topology.ClusterSelector) == 0 {
+			return nil, errors.Errorf("topology policy %s selects no cluster", policy.Name)
+		}
+	}
+	return topology, nil
+}
+
+// ParseGarbageCollectPolicy returns the properties of the garbage-collect policy,
+// it's nil if there is no garbage-collect policy.
+func ParseGarbageCollectPolicy(policies []v1beta1.AppPolicy) (*types.GarbageCollectPolicySpec, error) {
+	var gc *types.GarbageCollectPolicySpec
+	for _, policy := range policies {
+		if policy.Type != types.PolicyTypeGarbageCollect {
+			continue
+		}
+		if gc != nil {
+			return nil, errors.Errorf("policy %s: only one garbage-collect policy is allowed", policy.Name)
+		}
+		gc = &types.GarbageCollectPolicySpec{}
+		if policy.Properties.Raw != nil {
+			if err := json.Unmarshal(policy.Properties.Raw, gc); err != nil {
+				return nil, errors.WithMessagef(err, "invalid properties of garbage-collect policy %s", policy.Name)
+			}
+		}
+		if gc.Order != "" && gc.Order != types.GarbageCollectOrderDependency {
+			return nil, errors.Errorf("garbage-collect policy %s: unknown order %q", policy.Name, gc.Order)
+		}
+		for _, rule := range gc.Rules {
+			if !types.IsGarbageCollectStrategy(rule.Strategy) {
+				return nil, errors.Errorf("garbage-collect policy %s: unknown strategy %q", policy.Name, rule.Strategy)
+			}
+		}
+	}
+	return gc, nil
+}
+
+// ParseUpdateCheckPolicy returns the properties of the update-check policy,
+// it's nil if there is no update-check policy.
+func ParseUpdateCheckPolicy(policies []v1beta1.AppPolicy) (*types.UpdateCheckPolicySpec, error) {
+	var check *types.UpdateCheckPolicySpec
+	for _, policy := range policies {
+		if policy.Type != types.PolicyTypeUpdateCheck {
+			continue
+		}
+		if check != nil {
+			return nil, errors.Errorf("policy %s: only one update-check policy is allowed", policy.Name)
+		}
+		check = &types.UpdateCheckPolicySpec{}
+		if policy.Properties.Raw != nil {
+			if err := json.Unmarshal(policy.Properties.Raw, check); err != nil {
+				return nil, errors.WithMessagef(err, "invalid properties of update-check policy %s", policy.Name)
+			}
+		}
+		for _, action := range []types.UpdateCheckAction{check.ComponentTypeChange, check.ComponentRename, check.RolloutComponentRemoval} {
+			if action != "" && !types.IsUpdateCheckAction(action) {
+				return nil, errors.Errorf("update-check policy %s: unknown action %q", policy.Name, action)
+			}
+		}
+	}
+	return check, nil
+}
+
 func (p *Parser) parseWorkflow(ctx context.Context, workflow *v1beta1.Workflow) ([]*Workload, error) {
 	if workflow == nil {
 		return []*Workload{}, nil
@@ -141,6 +248,20 @@ func (p *Parser) parseWorkflow(ctx context.Context, workflow *v1beta1.Workflow)
 	steps := workflow.Steps
 	ws := []*Workload{}
 	for _, step := range steps {
+		if types.IsBuiltinWorkflowStepType(step.Type) {
+			// built-in steps are executed by the controller and have no WorkflowStepDefinition
+			settings, err := util.RawExtension2Map(&step.Properties)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "fail to parse settings for %s", step.Name)
+			}
+			ws = append(ws, &Workload{
+				Name:         step.Name,
+				Type:         step.Type,
+				Params:       settings,
+				FullTemplate: &Template{},
+			})
+			continue
+		}
 		w, err := p.makeWorkload(ctx, step.Name, step.Type, types.TypeWorkflowStep, step.Properties)
 		if err != nil {
 			return nil, err
@@ -171,7 +292,7 @@ func (p *Parser) makeWorkload(ctx context.Context, name, typ string, capType typ
 		CapabilityCategory: templ.CapabilityCategory,
 		FullTemplate:       templ,
 		Params:             settings,
-		engine:             definition.NewWorkloadAbstractEngine(name, p.pd),
+		engine:             definition.NewWorkloadAbstractEngine(name, p.pd, engineOptions(templ)...),
 	}
 	return workload, nil
 }
@@ -230,10 +351,18 @@ func (p *Parser) parseTrait(ctx context.Context, name string, properties map[str
 		HealthCheckPolicy:  templ.Health,
 		CustomStatusFormat: templ.CustomStatus,
 		FullTemplate:       templ,
-		engine:             definition.NewTraitAbstractEngine(traitName, p.pd),
+		engine:             definition.NewTraitAbstractEngine(traitName, p.pd, engineOptions(templ)...),
 	}, nil
 }
 
+// engineOptions caches the parsed template of the definition by its revision
+func engineOptions(templ *Template) []definition.EngineOption {
+	if templ == nil || templ.DefinitionRevision == nil {
+		return nil
+	}
+	return []definition.EngineOption{definition.WithRevisionHash(templ.DefinitionRevision.RevisionHash)}
+}
+
 // ValidateComponentNames validate all component name whether repeat in cluster and template
 func (p *Parser) ValidateComponentNames(ctx context.Context, af *Appfile) (int, error) {
 	existCompNames := make(map[string]string)
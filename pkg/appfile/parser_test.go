@@ -20,11 +20,13 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"testing"
 
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 	"github.com/ghodss/yaml"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"gotest.tools/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -656,3 +658,74 @@ var _ = Describe("Test IsSecretConsumer", func() {
 		})
 	})
 })
+
+func TestParseTopology(t *testing.T) {
+	topology, err := parseTopology([]v1beta1.AppPolicy{{Name: "security", Type: "security-policy"}})
+	assert.NilError(t, err)
+	assert.Assert(t, topology == nil)
+
+	topology, err = parseTopology([]v1beta1.AppPolicy{{
+		Name:       "regions",
+		Type:       types.PolicyTypeTopology,
+		Properties: runtime.RawExtension{Raw: []byte(`{"clusters":["cluster-a"],"clusterSelector":{"region":"hangzhou"}}`)},
+	}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, topology, &types.TopologyPolicySpec{
+		Clusters:        []string{"cluster-a"},
+		ClusterSelector: map[string]string{"region": "hangzhou"},
+	})
+
+	_, err = parseTopology([]v1beta1.AppPolicy{{Name: "empty", Type: types.PolicyTypeTopology}})
+	assert.ErrorContains(t, err, "selects no cluster")
+
+	_, err = parseTopology([]v1beta1.AppPolicy{
+		{Name: "a", Type: types.PolicyTypeTopology, Properties: runtime.RawExtension{Raw: []byte(`{"clusters":["cluster-a"]}`)}},
+		{Name: "b", Type: types.PolicyTypeTopology, Properties: runtime.RawExtension{Raw: []byte(`{"clusters":["cluster-b"]}`)}},
+	})
+	assert.ErrorContains(t, err, "only one topology policy")
+}
+
+func TestParseGarbageCollectPolicy(t *testing.T) {
+	gc, err := ParseGarbageCollectPolicy([]v1beta1.AppPolicy{{
+		Name: "gc",
+		Type: types.PolicyTypeGarbageCollect,
+		Properties: runtime.RawExtension{Raw: []byte(`{"keepLegacyResource":true,"order":"dependency",` +
+			`"rules":[{"selector":{"componentNames":["db"]},"strategy":"never"}]}`)},
+	}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, gc, &types.GarbageCollectPolicySpec{
+		KeepLegacyResource: true,
+		Order:              types.GarbageCollectOrderDependency,
+		Rules: []types.GarbageCollectPolicyRule{{
+			Selector: types.ResourceSelector{ComponentNames: []string{"db"}},
+			Strategy: types.GarbageCollectStrategyNever,
+		}},
+	})
+
+	_, err = ParseGarbageCollectPolicy([]v1beta1.AppPolicy{{
+		Name:       "gc",
+		Type:       types.PolicyTypeGarbageCollect,
+		Properties: runtime.RawExtension{Raw: []byte(`{"rules":[{"selector":{"resourceTypes":["Secret"]},"strategy":"sometimes"}]}`)},
+	}})
+	assert.ErrorContains(t, err, "unknown strategy")
+}
+
+func TestParseUpdateCheckPolicy(t *testing.T) {
+	check, err := ParseUpdateCheckPolicy([]v1beta1.AppPolicy{{
+		Name:       "check",
+		Type:       types.PolicyTypeUpdateCheck,
+		Properties: runtime.RawExtension{Raw: []byte(`{"componentTypeChange":"warn","componentRename":"deny"}`)},
+	}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, check, &types.UpdateCheckPolicySpec{
+		ComponentTypeChange: types.UpdateCheckActionWarn,
+		ComponentRename:     types.UpdateCheckActionDeny,
+	})
+
+	_, err = ParseUpdateCheckPolicy([]v1beta1.AppPolicy{{
+		Name:       "check",
+		Type:       types.PolicyTypeUpdateCheck,
+		Properties: runtime.RawExtension{Raw: []byte(`{"rolloutComponentRemoval":"ignore"}`)},
+	}})
+	assert.ErrorContains(t, err, "unknown action")
+}
@@ -62,6 +62,10 @@ type Template struct {
 	TraitDefinition        *v1beta1.TraitDefinition
 	PolicyDefinition       *v1beta1.PolicyDefinition
 	WorkflowStepDefinition *v1beta1.WorkflowStepDefinition
+
+	// DefinitionRevision is the revision of the ComponentDefinition or TraitDefinition the template is loaded from,
+	// it's nil if the definition has no revision
+	DefinitionRevision *common.Revision
 }
 
 // LoadTemplate gets the capability definition from cluster and resolve it.
@@ -72,9 +76,10 @@ func LoadTemplate(ctx context.Context, dm discoverymapper.DiscoveryMapper, cli c
 	switch capType {
 	case types.TypeComponentDefinition:
 		cd := new(v1beta1.ComponentDefinition)
-		err := oamutil.GetCapabilityDefinition(ctx, cli, cd, capName)
+		defRev, err := oamutil.GetCapabilityDefinitionWithRevision(ctx, cli, cd, capName)
 		if err != nil {
-			if kerrors.IsNotFound(err) {
+			// a definition pinned to a revision must be a ComponentDefinition, WorkloadDefinition has no revision
+			if kerrors.IsNotFound(err) && !oamutil.IsDefinitionRevisionName(capName) {
 				wd := new(v1beta1.WorkloadDefinition)
 				if err := oamutil.GetDefinition(ctx, cli, wd, capName); err != nil {
 					return nil, errors.WithMessagef(err, "LoadTemplate from workloadDefinition [%s] ", capName)
@@ -101,11 +106,12 @@ func LoadTemplate(ctx context.Context, dm discoverymapper.DiscoveryMapper, cli c
 		if err != nil {
 			return nil, err
 		}
+		tmpl.DefinitionRevision = defRev
 		return tmpl, nil
 
 	case types.TypeTrait:
 		td := new(v1beta1.TraitDefinition)
-		err := oamutil.GetCapabilityDefinition(ctx, cli, td, capName)
+		defRev, err := oamutil.GetCapabilityDefinitionWithRevision(ctx, cli, td, capName)
 		if err != nil {
 			return nil, errors.WithMessagef(err, "LoadTemplate [%s] ", capName)
 		}
@@ -113,6 +119,7 @@ func LoadTemplate(ctx context.Context, dm discoverymapper.DiscoveryMapper, cli c
 		if err != nil {
 			return nil, err
 		}
+		tmpl.DefinitionRevision = defRev
 		return tmpl, nil
 	case types.TypePolicy:
 		d := new(v1beta1.PolicyDefinition)
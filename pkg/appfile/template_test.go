@@ -24,7 +24,10 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	ktypes "k8s.io/apimachinery/pkg/types"
 
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
@@ -524,3 +527,101 @@ spec:
 		t.Fatal("failed load template of trait definition ", diff)
 	}
 }
+
+func TestLoadTemplateWithDefinitionRevision(t *testing.T) {
+	cueTemplate := func(image string) *common.Schematic {
+		return &common.Schematic{CUE: &common.CUE{Template: `
+output: {
+	apiVersion: "apps/v1"
+	kind:       "Deployment"
+	spec: template: spec: containers: [{image: "` + image + `"}]
+}`}}
+	}
+	compDef := v1beta1.ComponentDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "webservice"},
+		Spec:       v1beta1.ComponentDefinitionSpec{Schematic: cueTemplate("nginx:v3")},
+		Status: v1beta1.ComponentDefinitionStatus{
+			LatestRevision: &common.Revision{Name: "webservice-v3", Revision: 3, RevisionHash: "hash-v3"},
+		},
+	}
+	oldCompDef := compDef.DeepCopy()
+	oldCompDef.Spec.Schematic = cueTemplate("nginx:v2")
+	oldCompDef.Status = v1beta1.ComponentDefinitionStatus{}
+	traitDef := v1beta1.TraitDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "scaler"},
+		Spec:       v1beta1.TraitDefinitionSpec{Schematic: &common.Schematic{CUE: &common.CUE{Template: `patch: spec: replicas: 1`}}},
+	}
+	defRevs := map[string]v1beta1.DefinitionRevision{
+		"webservice-v2": {
+			ObjectMeta: metav1.ObjectMeta{Name: "webservice-v2"},
+			Spec: v1beta1.DefinitionRevisionSpec{Revision: 2, RevisionHash: "hash-v2",
+				DefinitionType: common.ComponentType, ComponentDefinition: *oldCompDef},
+		},
+		"scaler-v1": {
+			ObjectMeta: metav1.ObjectMeta{Name: "scaler-v1"},
+			Spec: v1beta1.DefinitionRevisionSpec{Revision: 1, RevisionHash: "hash-v1",
+				DefinitionType: common.TraitType, TraitDefinition: traitDef},
+		},
+	}
+	tclient := test.MockClient{
+		MockGet: func(ctx context.Context, key ktypes.NamespacedName, obj runtime.Object) error {
+			switch o := obj.(type) {
+			case *v1beta1.ComponentDefinition:
+				if key.Name == compDef.Name {
+					*o = compDef
+					return nil
+				}
+			case *v1beta1.DefinitionRevision:
+				if defRev, ok := defRevs[key.Name]; ok {
+					*o = defRev
+					return nil
+				}
+			}
+			return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+		},
+	}
+
+	testCases := map[string]struct {
+		capName     string
+		capType     types.CapType
+		wantRev     *common.Revision
+		wantPartial string
+		wantErr     bool
+	}{
+		"latest component definition": {
+			capName:     "webservice",
+			capType:     types.TypeComponentDefinition,
+			wantRev:     &common.Revision{Name: "webservice-v3", Revision: 3, RevisionHash: "hash-v3"},
+			wantPartial: "nginx:v3",
+		},
+		"component definition pinned to a revision": {
+			capName:     "webservice@v2",
+			capType:     types.TypeComponentDefinition,
+			wantRev:     &common.Revision{Name: "webservice-v2", Revision: 2, RevisionHash: "hash-v2"},
+			wantPartial: "nginx:v2",
+		},
+		"pinned revision not found": {
+			capName: "webservice@v9",
+			capType: types.TypeComponentDefinition,
+			wantErr: true,
+		},
+		"trait definition pinned to a revision": {
+			capName:     "scaler@v1",
+			capType:     types.TypeTrait,
+			wantRev:     &common.Revision{Name: "scaler-v1", Revision: 1, RevisionHash: "hash-v1"},
+			wantPartial: "replicas",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := LoadTemplate(context.Background(), mock.NewMockDiscoveryMapper(), &tclient, tc.capName, tc.capType)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantRev, tmpl.DefinitionRevision)
+			assert.Contains(t, tmpl.TemplateStr, tc.wantPartial)
+		})
+	}
+}
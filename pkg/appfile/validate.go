@@ -18,11 +18,25 @@ package appfile
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	"github.com/oam-dev/kubevela/apis/types"
 	"github.com/oam-dev/kubevela/pkg/cue/process"
+	oamutil "github.com/oam-dev/kubevela/pkg/oam/util"
+)
+
+const (
+	errFmtUnappliableTrait     = "trait %q cannot apply to component %q of type %q (appliable: %q)"
+	errFmtTraitConflict        = "conflict(rule: %q) between traits %q and %q of component %q is detected"
+	errFmtTraitConflictWithAll = "trait %q of component %q conflicts with all other traits"
+	errFmtInvalidLabelSelector = "labelSelector in conflict rule %q of trait %q is invalid"
+	errFmtTooManyTraits        = "trait %q can apply to component %q at most %d times, but it's applied %d times"
 )
 
 // ValidateCUESchematicAppfile validates CUE schematic workloads in an Appfile
@@ -91,3 +105,181 @@ func validateAuxiliaryNameUnique() process.AuxiliaryHook {
 		return nil
 	})
 }
+
+// ValidateTraits validates the traits of every workload in an Appfile against their TraitDefinitions.
+// A trait must be appliable to the workload (appliesToWorkloads), must not conflict with the other traits
+// of the workload (conflictsWith), and must not be applied more times than allowed (maxInstances).
+func (p *Parser) ValidateTraits(a *Appfile) error {
+	var errs []error
+	for _, wl := range a.Workloads {
+		if len(wl.Traits) == 0 {
+			continue
+		}
+		errs = append(errs, p.validateTraitsAppliable(wl)...)
+		errs = append(errs, validateTraitConflicts(wl)...)
+		errs = append(errs, validateTraitMaxInstances(wl)...)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// validateTraitsAppliable validates the appliesToWorkloads rules of the traits. A rule matches the workload by
+// the name of its definition, the name of its CRD, the API group of its CRD ("*.apps"), or "*" for any workload.
+func (p *Parser) validateTraitsAppliable(wl *Workload) []error {
+	var errs []error
+	var workloadResource string
+	resolved := false
+	for _, tr := range wl.Traits {
+		td := traitDefinitionOf(tr)
+		if td == nil || len(td.Spec.AppliesToWorkloads) == 0 {
+			// AppliesToWorkloads is empty, the trait can be applied to ANY workload
+			continue
+		}
+		if !resolved {
+			var err error
+			if workloadResource, err = p.getWorkloadResource(wl); err != nil {
+				return []error{err}
+			}
+			resolved = true
+		}
+		if !isAppliableTo(td.Spec.AppliesToWorkloads, workloadDefinitionName(wl), workloadResource) {
+			errs = append(errs, fmt.Errorf(errFmtUnappliableTrait, td.Name, wl.Name, wl.Type, td.Spec.AppliesToWorkloads))
+		}
+	}
+	return errs
+}
+
+// getWorkloadResource returns the CRD name of the workload, e.g., deployments.apps, or empty if the workload
+// has no CRD, e.g., a helm or terraform component
+func (p *Parser) getWorkloadResource(wl *Workload) (string, error) {
+	tmpl := wl.FullTemplate
+	switch {
+	case tmpl == nil:
+		return "", nil
+	case tmpl.WorkloadDefinition != nil:
+		return tmpl.WorkloadDefinition.Spec.Reference.Name, nil
+	case tmpl.Reference.Type != "":
+		return tmpl.Reference.Type, nil
+	case tmpl.Reference.Definition.Kind == "":
+		return "", nil
+	}
+	ref, err := oamutil.ConvertWorkloadGVK2Definition(p.dm, tmpl.Reference.Definition)
+	if err != nil {
+		return "", errors.WithMessagef(err, "cannot get the workload resource of component %q", wl.Name)
+	}
+	return ref.Name, nil
+}
+
+func workloadDefinitionName(wl *Workload) string {
+	if wl.FullTemplate != nil {
+		if wl.FullTemplate.ComponentDefinition != nil {
+			return wl.FullTemplate.ComponentDefinition.Name
+		}
+		if wl.FullTemplate.WorkloadDefinition != nil {
+			return wl.FullTemplate.WorkloadDefinition.Name
+		}
+	}
+	return wl.Type
+}
+
+func isAppliableTo(appliesTo []string, definitionName, workloadResource string) bool {
+	workloadGroup := schema.ParseGroupResource(workloadResource).Group
+	for _, applyTo := range appliesTo {
+		switch {
+		case applyTo == "*", applyTo == definitionName:
+			// "*" means the trait can be applied to ANY workload
+			return true
+		case workloadResource == "":
+			continue
+		case applyTo == workloadResource:
+			return true
+		case strings.HasPrefix(applyTo, "*.") && workloadGroup == applyTo[2:]:
+			return true
+		}
+	}
+	return false
+}
+
+// validateTraitConflicts validates the conflictsWith rules of the traits, the rules are the same as the ones
+// checked for ApplicationConfiguration: a trait definition name, a CRD name, an API group ("*.networking.k8s.io"),
+// a label selector ("labelSelector:foo=bar"), or "*" for all other traits.
+func validateTraitConflicts(wl *Workload) []error {
+	var errs []error
+	for _, tr := range wl.Traits {
+		td := traitDefinitionOf(tr)
+		if td == nil {
+			continue
+		}
+		for _, rule := range td.Spec.ConflictsWith {
+			if rule == "*" {
+				if len(wl.Traits) > 1 {
+					errs = append(errs, fmt.Errorf(errFmtTraitConflictWithAll, td.Name, wl.Name))
+				}
+				continue
+			}
+			var selector labels.Selector
+			if strings.HasPrefix(rule, "labelSelector:") {
+				var err error
+				if selector, err = labels.Parse(strings.TrimPrefix(rule, "labelSelector:")); err != nil {
+					errs = append(errs, errors.Wrapf(err, errFmtInvalidLabelSelector, rule, td.Name))
+					continue
+				}
+			}
+			for _, other := range wl.Traits {
+				otherDef := traitDefinitionOf(other)
+				// skip self-check
+				if otherDef == nil || otherDef.Name == td.Name {
+					continue
+				}
+				if isTraitConflict(rule, selector, otherDef) {
+					errs = append(errs, fmt.Errorf(errFmtTraitConflict, rule, td.Name, otherDef.Name, wl.Name))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func isTraitConflict(rule string, selector labels.Selector, td *v1beta1.TraitDefinition) bool {
+	if td.Name == rule || (selector != nil && selector.Matches(labels.Set(td.Labels))) {
+		return true
+	}
+	// according to OAM convention, Spec.Reference.Name in traitDefinition is CRD name
+	crdName := td.Spec.Reference.Name
+	if crdName == "" {
+		return false
+	}
+	return crdName == rule || (strings.HasPrefix(rule, "*.") && schema.ParseGroupResource(crdName).Group == rule[2:])
+}
+
+// validateTraitMaxInstances validates the number of each trait applied to the workload doesn't exceed its maxInstances,
+// the revisions of one trait definition are counted together.
+func validateTraitMaxInstances(wl *Workload) []error {
+	var errs []error
+	var names []string
+	counts := make(map[string]int32)
+	limits := make(map[string]int32)
+	for _, tr := range wl.Traits {
+		td := traitDefinitionOf(tr)
+		if td == nil || td.Spec.MaxInstances == nil {
+			continue
+		}
+		if _, ok := counts[td.Name]; !ok {
+			names = append(names, td.Name)
+			limits[td.Name] = *td.Spec.MaxInstances
+		}
+		counts[td.Name]++
+	}
+	for _, name := range names {
+		if counts[name] > limits[name] {
+			errs = append(errs, fmt.Errorf(errFmtTooManyTraits, name, wl.Name, limits[name], counts[name]))
+		}
+	}
+	return errs
+}
+
+func traitDefinitionOf(tr *Trait) *v1beta1.TraitDefinition {
+	if tr == nil || tr.FullTemplate == nil {
+		return nil
+	}
+	return tr.FullTemplate.TraitDefinition
+}
@@ -17,12 +17,20 @@ limitations under the License.
 package appfile
 
 import (
+	"testing"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	"github.com/oam-dev/kubevela/apis/types"
 	"github.com/oam-dev/kubevela/pkg/cue/definition"
+	"github.com/oam-dev/kubevela/pkg/oam/mock"
 )
 
 var _ = Describe("Test validate CUE schematic Appfile", func() {
@@ -146,3 +154,107 @@ var _ = Describe("Test validate CUE schematic Appfile", func() {
 		}),
 	)
 })
+
+func TestValidateTraits(t *testing.T) {
+	traitDef := func(name string, modify func(td *v1beta1.TraitDefinition)) *Trait {
+		td := &v1beta1.TraitDefinition{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if modify != nil {
+			modify(td)
+		}
+		return &Trait{Name: name, FullTemplate: &Template{TraitDefinition: td}}
+	}
+	workload := func(traits ...*Trait) *Workload {
+		return &Workload{
+			Name: "myweb",
+			Type: "webservice",
+			FullTemplate: &Template{
+				ComponentDefinition: &v1beta1.ComponentDefinition{ObjectMeta: metav1.ObjectMeta{Name: "webservice"}},
+				Reference:           common.WorkloadTypeDescriptor{Definition: common.WorkloadGVK{APIVersion: "apps/v1", Kind: "Deployment"}},
+			},
+			Traits: traits,
+		}
+	}
+	appliesTo := func(workloads ...string) func(td *v1beta1.TraitDefinition) {
+		return func(td *v1beta1.TraitDefinition) { td.Spec.AppliesToWorkloads = workloads }
+	}
+	conflictsWith := func(rules ...string) func(td *v1beta1.TraitDefinition) {
+		return func(td *v1beta1.TraitDefinition) { td.Spec.ConflictsWith = rules }
+	}
+	maxInstances := func(max int32) func(td *v1beta1.TraitDefinition) {
+		return func(td *v1beta1.TraitDefinition) { td.Spec.MaxInstances = pointer.Int32Ptr(max) }
+	}
+
+	dm := mock.NewMockDiscoveryMapper()
+	dm.MockRESTMapping = mock.NewMockRESTMapping("deployments")
+	p := &Parser{dm: dm}
+
+	testCases := map[string]struct {
+		wl      *Workload
+		wantErr string
+	}{
+		"no rules": {
+			wl: workload(traitDef("scaler", nil), traitDef("ingress", nil)),
+		},
+		"applies to any workload": {
+			wl: workload(traitDef("scaler", appliesTo("*"))),
+		},
+		"applies to the component definition": {
+			wl: workload(traitDef("ingress", appliesTo("worker", "webservice"))),
+		},
+		"applies to the workload resource": {
+			wl: workload(traitDef("ingress", appliesTo("deployments.apps"))),
+		},
+		"applies to the workload group": {
+			wl: workload(traitDef("ingress", appliesTo("*.apps"))),
+		},
+		"not appliable": {
+			wl:      workload(traitDef("ingress", appliesTo("worker", "statefulsets.apps"))),
+			wantErr: `trait "ingress" cannot apply to component "myweb" of type "webservice"`,
+		},
+		"conflicts with trait definition": {
+			wl:      workload(traitDef("ingress", conflictsWith("gateway")), traitDef("gateway", nil)),
+			wantErr: `conflict(rule: "gateway") between traits "ingress" and "gateway" of component "myweb" is detected`,
+		},
+		"conflicts with trait group": {
+			wl: workload(traitDef("ingress", conflictsWith("*.networking.k8s.io")), traitDef("gateway", func(td *v1beta1.TraitDefinition) {
+				td.Spec.Reference.Name = "gateways.networking.k8s.io"
+			})),
+			wantErr: `conflict(rule: "*.networking.k8s.io") between traits "ingress" and "gateway"`,
+		},
+		"conflicts with trait labels": {
+			wl: workload(traitDef("ingress", conflictsWith("labelSelector:type=route")), traitDef("gateway", func(td *v1beta1.TraitDefinition) {
+				td.Labels = map[string]string{"type": "route"}
+			})),
+			wantErr: `conflict(rule: "labelSelector:type=route") between traits "ingress" and "gateway"`,
+		},
+		"conflicts with all": {
+			wl:      workload(traitDef("ingress", conflictsWith("*")), traitDef("scaler", nil)),
+			wantErr: `trait "ingress" of component "myweb" conflicts with all other traits`,
+		},
+		"conflicts with all but alone": {
+			wl: workload(traitDef("ingress", conflictsWith("*"))),
+		},
+		"invalid label selector": {
+			wl:      workload(traitDef("ingress", conflictsWith("labelSelector:a=b=c")), traitDef("scaler", nil)),
+			wantErr: `labelSelector in conflict rule "labelSelector:a=b=c" of trait "ingress" is invalid`,
+		},
+		"within max instances": {
+			wl: workload(traitDef("sidecar", maxInstances(2)), traitDef("sidecar", maxInstances(2))),
+		},
+		"exceed max instances": {
+			wl:      workload(traitDef("ingress", maxInstances(1)), traitDef("ingress", maxInstances(1))),
+			wantErr: `trait "ingress" can apply to component "myweb" at most 1 times, but it's applied 2 times`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := p.ValidateTraits(&Appfile{Workloads: []*Workload{tc.wl}})
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
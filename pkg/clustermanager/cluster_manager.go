@@ -17,21 +17,198 @@ limitations under the License.
 package clustermanager
 
 import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	"github.com/oam-dev/kubevela/pkg/utils/common"
 )
 
+// SecretKeyConfig is the key of the kubeconfig in the secret referenced by a cluster
+const SecretKeyConfig = "config"
+
+// probeTimeout is the timeout of the requests to probe a cluster
+const probeTimeout = 10 * time.Second
+
 // GetClient returns a kube client for given kubeConfigData
 func GetClient(kubeConfigData []byte) (client.Client, error) {
+	restConfig, err := getRestConfig(kubeConfigData)
+	if err != nil {
+		return nil, err
+	}
+	return client.New(restConfig, client.Options{Scheme: common.Scheme})
+}
+
+func getRestConfig(kubeConfigData []byte) (*rest.Config, error) {
 	clientConfig, err := clientcmd.NewClientConfigFromBytes(kubeConfigData)
 	if err != nil {
 		return nil, err
 	}
-	restConfig, err := clientConfig.ClientConfig()
+	return clientConfig.ClientConfig()
+}
+
+// clusterClient is the cached client of a cluster, it's rebuilt when the kubeconfig secret changes.
+type clusterClient struct {
+	secretVersion string
+	config        *rest.Config
+	client        client.Client
+}
+
+var (
+	clientsMu sync.Mutex
+	clients   = map[types.NamespacedName]*clusterClient{}
+)
+
+// GetClusterClient returns a kube client for the cluster with the kubeconfig in the secret it references.
+// One client is cached per cluster until the secret changes.
+func GetClusterClient(ctx context.Context, cli client.Client, cluster *v1beta1.Cluster) (client.Client, error) {
+	cc, err := getClusterClient(ctx, cli, cluster)
 	if err != nil {
 		return nil, err
 	}
-	return client.New(restConfig, client.Options{Scheme: common.Scheme})
+	return cc.client, nil
+}
+
+func getClusterClient(ctx context.Context, cli client.Client, cluster *v1beta1.Cluster) (*clusterClient, error) {
+	key := client.ObjectKey{
+		Name:      cluster.Spec.KubeconfigSecretRef.Name,
+		Namespace: cluster.Namespace,
+	}
+	secret := &corev1.Secret{}
+	if err := cli.Get(ctx, key, secret); err != nil {
+		return nil, errors.WithMessagef(err, "get kubeconfig secret of cluster %s", cluster.Name)
+	}
+	clusterKey := types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name}
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if cc, ok := clients[clusterKey]; ok && cc.secretVersion == secret.ResourceVersion {
+		return cc, nil
+	}
+	config, err := getRestConfig(secret.Data[SecretKeyConfig])
+	if err != nil {
+		return nil, errors.WithMessagef(err, "invalid kubeconfig of cluster %s", cluster.Name)
+	}
+	c, err := client.New(config, client.Options{Scheme: common.Scheme})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "cannot create client of cluster %s", cluster.Name)
+	}
+	cc := &clusterClient{secretVersion: secret.ResourceVersion, config: config, client: c}
+	clients[clusterKey] = cc
+	return cc, nil
+}
+
+// ForgetClusterClient removes the cached client of the cluster.
+func ForgetClusterClient(namespace, name string) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	delete(clients, types.NamespacedName{Namespace: namespace, Name: name})
+}
+
+// IsClusterReady checks whether the cluster is reachable in the last probe.
+func IsClusterReady(cluster *v1beta1.Cluster) bool {
+	return cluster.Status.GetCondition(runtimev1alpha1.TypeReady).Status == corev1.ConditionTrue
+}
+
+// CheckClusterReady checks whether the cluster is ready to dispatch resources to. The readiness of a cluster which
+// is not probed yet, e.g., a newly registered one, is unknown, so it's probed synchronously on first use instead of
+// being treated as not ready. The error explains why the cluster is not ready.
+func CheckClusterReady(ctx context.Context, cli client.Client, cluster *v1beta1.Cluster) (bool, error) {
+	if cluster.Status.LastProbeTime != nil {
+		if IsClusterReady(cluster) {
+			return true, nil
+		}
+		return false, errors.Errorf("cluster %s is unavailable in the last probe: %s", cluster.Name,
+			cluster.Status.GetCondition(runtimev1alpha1.TypeReady).Message)
+	}
+	if _, err := ProbeCluster(ctx, cli, cluster); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ClusterInfo is the information of a cluster found by probing it.
+type ClusterInfo struct {
+	KubernetesVersion string
+	NodeCount         int
+	Capacity          corev1.ResourceList
+}
+
+// ProbeCluster checks the cluster is reachable with its kubeconfig, and collects the version and capacity of it.
+func ProbeCluster(ctx context.Context, cli client.Client, cluster *v1beta1.Cluster) (*ClusterInfo, error) {
+	cc, err := getClusterClient(ctx, cli, cluster)
+	if err != nil {
+		return nil, err
+	}
+	config := rest.CopyConfig(cc.config)
+	config.Timeout = probeTimeout
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "cannot create discovery client of cluster %s", cluster.Name)
+	}
+	version, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "cannot get version of cluster %s", cluster.Name)
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	nodes := &corev1.NodeList{}
+	if err := cc.client.List(probeCtx, nodes); err != nil {
+		return nil, errors.WithMessagef(err, "cannot list nodes of cluster %s", cluster.Name)
+	}
+	return &ClusterInfo{
+		KubernetesVersion: version.GitVersion,
+		NodeCount:         len(nodes.Items),
+		Capacity:          sumCapacity(nodes.Items),
+	}, nil
+}
+
+func sumCapacity(nodes []corev1.Node) corev1.ResourceList {
+	capacity := corev1.ResourceList{}
+	for _, node := range nodes {
+		for name, quantity := range node.Status.Capacity {
+			total := capacity[name]
+			total.Add(quantity)
+			capacity[name] = total
+		}
+	}
+	return capacity
+}
+
+// SelectClusters returns the clusters in the namespace with the given names, and the clusters matching the selector.
+// It returns an error if a named cluster doesn't exist. The clusters are sorted by name.
+func SelectClusters(ctx context.Context, cli client.Client, ns string, names []string, selector map[string]string) ([]v1beta1.Cluster, error) {
+	selected := map[string]v1beta1.Cluster{}
+	for _, name := range names {
+		cluster := v1beta1.Cluster{}
+		if err := cli.Get(ctx, client.ObjectKey{Namespace: ns, Name: name}, &cluster); err != nil {
+			return nil, errors.WithMessagef(err, "get cluster %s", name)
+		}
+		selected[name] = cluster
+	}
+	if len(selector) != 0 {
+		clusterList := v1beta1.ClusterList{}
+		if err := cli.List(ctx, &clusterList, client.InNamespace(ns), client.MatchingLabels(selector)); err != nil {
+			return nil, errors.WithMessage(err, "list clusters")
+		}
+		for _, cluster := range clusterList.Items {
+			selected[cluster.Name] = cluster
+		}
+	}
+	clusters := make([]v1beta1.Cluster, 0, len(selected))
+	for _, cluster := range selected {
+		clusters = append(clusters, cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters, nil
 }
@@ -0,0 +1,157 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clustermanager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/utils/common"
+)
+
+func TestSelectClusters(t *testing.T) {
+	newCluster := func(name, region string) *v1beta1.Cluster {
+		return &v1beta1.Cluster{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{"region": region},
+		}}
+	}
+	cli := fake.NewFakeClientWithScheme(common.Scheme,
+		newCluster("cluster-c", "hangzhou"), newCluster("cluster-b", "beijing"), newCluster("cluster-a", "hangzhou"))
+	ctx := context.Background()
+
+	testCases := map[string]struct {
+		names    []string
+		selector map[string]string
+		want     []string
+		wantErr  bool
+	}{
+		"by names": {
+			names: []string{"cluster-b"},
+			want:  []string{"cluster-b"},
+		},
+		"by labels": {
+			selector: map[string]string{"region": "hangzhou"},
+			want:     []string{"cluster-a", "cluster-c"},
+		},
+		"union of names and labels": {
+			names:    []string{"cluster-b", "cluster-a"},
+			selector: map[string]string{"region": "hangzhou"},
+			want:     []string{"cluster-a", "cluster-b", "cluster-c"},
+		},
+		"no cluster matches labels": {
+			selector: map[string]string{"region": "shanghai"},
+			want:     []string{},
+		},
+		"named cluster not found": {
+			names:   []string{"cluster-d"},
+			wantErr: true,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			clusters, err := SelectClusters(ctx, cli, "default", tc.names, tc.selector)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			got := []string{}
+			for _, c := range clusters {
+				got = append(got, c.Name)
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestCheckClusterReady(t *testing.T) {
+	// a fake kube-apiserver which serves the requests to probe a cluster
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/version":
+			fmt.Fprint(w, `{"gitVersion":"v1.20.2"}`)
+		case "/api":
+			fmt.Fprint(w, `{"kind":"APIVersions","versions":["v1"]}`)
+		case "/apis":
+			fmt.Fprint(w, `{"kind":"APIGroupList","groups":[]}`)
+		case "/api/v1":
+			fmt.Fprint(w, `{"kind":"APIResourceList","groupVersion":"v1","resources":[{"name":"nodes","kind":"Node","namespaced":false,"verbs":["list"]}]}`)
+		case "/api/v1/nodes":
+			fmt.Fprint(w, `{"kind":"NodeList","apiVersion":"v1","items":[]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+current-context: test
+`, server.URL)
+
+	newCluster := func(name, secret string) *v1beta1.Cluster {
+		cluster := &v1beta1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+		cluster.Spec.KubeconfigSecretRef.Name = secret
+		return cluster
+	}
+	cli := fake.NewFakeClientWithScheme(common.Scheme, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubeconfig", Namespace: "default"},
+		Data:       map[string][]byte{SecretKeyConfig: []byte(kubeconfig)},
+	})
+	ctx := context.Background()
+	now := metav1.Now()
+
+	// the cluster which is not probed yet is probed on first use
+	ready, err := CheckClusterReady(ctx, cli, newCluster("new", "kubeconfig"))
+	assert.NoError(t, err)
+	assert.True(t, ready)
+	ready, err = CheckClusterReady(ctx, cli, newCluster("unreachable", "not-found"))
+	assert.Error(t, err)
+	assert.False(t, ready)
+
+	// the cluster which is probed is not probed again
+	probed := newCluster("probed", "not-found")
+	probed.Status.LastProbeTime = &now
+	probed.Status.SetConditions(runtimev1alpha1.Available())
+	ready, err = CheckClusterReady(ctx, cli, probed)
+	assert.NoError(t, err)
+	assert.True(t, ready)
+	probed.Status.SetConditions(runtimev1alpha1.Unavailable().WithMessage("connection refused"))
+	ready, err = CheckClusterReady(ctx, cli, probed)
+	assert.Error(t, err)
+	assert.False(t, ready)
+}
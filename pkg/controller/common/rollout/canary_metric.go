@@ -0,0 +1,116 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/pkg/controller/common/rollout/metrics"
+)
+
+// defaultMetricInterval is the window size of a canary metric without interval
+const defaultMetricInterval = "1m"
+
+// metricQueryVars are the variables to render the query of a metric template
+type metricQueryVars struct {
+	Namespace  string
+	Name       string
+	SourceName string
+	Interval   string
+	Batch      int32
+}
+
+// checkCanaryMetrics evaluates the canary metrics of the current batch, and those of the rollout plan as well in
+// the last batch before the rollout completes. It returns an error if any metric is out of its expected range,
+// and returns false to check again later if any metric can't be queried for now.
+func (r *Controller) checkCanaryMetrics(ctx context.Context) (bool, error) {
+	currentBatch := int(r.rolloutStatus.CurrentBatch)
+	canaryMetrics := r.rolloutSpec.RolloutBatches[currentBatch].CanaryMetric
+	if currentBatch == len(r.rolloutSpec.RolloutBatches)-1 {
+		canaryMetrics = append(canaryMetrics[:len(canaryMetrics):len(canaryMetrics)], r.rolloutSpec.CanaryMetric...)
+	}
+	for _, metric := range canaryMetrics {
+		value, err := r.queryCanaryMetric(ctx, metric)
+		if err != nil {
+			klog.ErrorS(err, "failed to query the canary metric", "metric", metric.Name,
+				"current batch", currentBatch)
+			r.rolloutStatus.RolloutRetry(fmt.Sprintf("failed to query the canary metric %s: %s", metric.Name, err))
+			return false, nil
+		}
+		if err := metrics.CheckRange(metric.MetricsRange, value); err != nil {
+			return false, errors.WithMessagef(err, "the canary metric %s is out of the expected range in batch %d",
+				metric.Name, currentBatch)
+		}
+		klog.InfoS("the canary metric is in the expected range", "metric", metric.Name, "value", value,
+			"current batch", currentBatch)
+	}
+	return true, nil
+}
+
+// queryCanaryMetric queries the value of the canary metric by its metric template
+func (r *Controller) queryCanaryMetric(ctx context.Context, metric v1alpha1.CanaryMetric) (float64, error) {
+	templateName := metric.Name
+	if metric.TemplateRef != nil {
+		templateName = metric.TemplateRef.Name
+	}
+	metricTemplate := &v1alpha1.MetricTemplate{}
+	if err := r.client.Get(ctx, types.NamespacedName{Namespace: r.parentController.GetNamespace(), Name: templateName},
+		metricTemplate); err != nil {
+		return 0, errors.Wrapf(err, "cannot get the metric template %s", templateName)
+	}
+	query, err := r.renderMetricQuery(metricTemplate.Spec.Query, metric)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "cannot render the query of metric template %s", templateName)
+	}
+	provider, err := r.newMetricProvider(metricTemplate.Spec.Provider)
+	if err != nil {
+		return 0, err
+	}
+	return provider.Query(ctx, query)
+}
+
+func (r *Controller) renderMetricQuery(query string, metric v1alpha1.CanaryMetric) (string, error) {
+	tmpl, err := template.New(metric.Name).Option("missingkey=error").Parse(query)
+	if err != nil {
+		return "", err
+	}
+	vars := metricQueryVars{
+		Namespace: r.targetWorkload.GetNamespace(),
+		Name:      r.targetWorkload.GetName(),
+		Interval:  metric.Interval,
+		Batch:     r.rolloutStatus.CurrentBatch,
+	}
+	if len(vars.Interval) == 0 {
+		vars.Interval = defaultMetricInterval
+	}
+	if r.sourceWorkload != nil {
+		vars.SourceName = r.sourceWorkload.GetName()
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
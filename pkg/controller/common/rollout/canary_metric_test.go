@@ -0,0 +1,139 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/pkg/controller/common/rollout/metrics"
+)
+
+func TestCheckCanaryMetrics(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	successRate := &v1alpha1.MetricTemplate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "success-rate"},
+		Spec: v1alpha1.MetricTemplateSpec{
+			Provider: v1alpha1.MetricProvider{Type: v1alpha1.PrometheusMetricProvider, Address: "http://prometheus:9090"},
+			Query:    `success_rate{namespace="{{ .Namespace }}",workload="{{ .Name }}"}[{{ .Interval }}]`,
+		},
+	}
+	latency := &v1alpha1.MetricTemplate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "latency"},
+		Spec: v1alpha1.MetricTemplateSpec{
+			Provider: v1alpha1.MetricProvider{Type: v1alpha1.PrometheusMetricProvider, Address: "http://prometheus:9090"},
+			Query:    `latency{workload="{{ .Name }}",batch="{{ .Batch }}"}`,
+		},
+	}
+	min, max := intstr.FromString("0.99"), intstr.FromInt(500)
+	batchMetric := v1alpha1.CanaryMetric{
+		Name:         "success-rate",
+		Interval:     "5m",
+		MetricsRange: &v1alpha1.MetricsExpectedRange{Min: &min},
+	}
+	planMetric := v1alpha1.CanaryMetric{
+		Name:         "p99-latency",
+		MetricsRange: &v1alpha1.MetricsExpectedRange{Max: &max},
+		TemplateRef:  &runtimev1alpha1.TypedReference{Kind: "MetricTemplate", Name: "latency"},
+	}
+	rolloutSpec := &v1alpha1.RolloutPlan{
+		RolloutBatches: []v1alpha1.RolloutBatch{
+			{Replicas: intstr.FromInt(1), CanaryMetric: []v1alpha1.CanaryMetric{batchMetric}},
+			{Replicas: intstr.FromInt(1)},
+		},
+		CanaryMetric: []v1alpha1.CanaryMetric{planMetric},
+	}
+	target := &unstructured.Unstructured{}
+	target.SetNamespace("default")
+	target.SetName("web-v2")
+
+	cases := map[string]struct {
+		batch       int32
+		values      map[string]float64
+		providerErr error
+		want        bool
+		wantErr     string
+	}{
+		"BatchMetricInRange": {
+			batch:  0,
+			values: map[string]float64{`success_rate{namespace="default",workload="web-v2"}[5m]`: 0.995},
+			want:   true,
+		},
+		"BatchMetricOutOfRange": {
+			batch:   0,
+			values:  map[string]float64{`success_rate{namespace="default",workload="web-v2"}[5m]`: 0.9},
+			wantErr: "the canary metric success-rate is out of the expected range in batch 0",
+		},
+		"NoValueYet": {
+			batch: 0,
+			want:  false,
+		},
+		"ProviderUnavailable": {
+			batch:       0,
+			providerErr: errors.New("connection refused"),
+			want:        false,
+		},
+		"PlanMetricInLastBatch": {
+			batch:   1,
+			values:  map[string]float64{`latency{workload="web-v2",batch="1"}`: 800},
+			wantErr: "the canary metric p99-latency is out of the expected range in batch 1",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &Controller{
+				client:           fake.NewFakeClientWithScheme(scheme, successRate, latency),
+				recorder:         event.NewNopRecorder(),
+				parentController: &v1beta1.AppRollout{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "rollout"}},
+				rolloutSpec:      rolloutSpec,
+				rolloutStatus:    &v1alpha1.RolloutStatus{CurrentBatch: tc.batch},
+				targetWorkload:   target,
+				newMetricProvider: func(v1alpha1.MetricProvider) (metrics.Provider, error) {
+					return &metrics.FakeProvider{Values: tc.values, Err: tc.providerErr}, nil
+				},
+			}
+			got, err := r.checkCanaryMetrics(context.Background())
+			if tc.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.wantErr) {
+					t.Fatalf("checkCanaryMetrics(...): want error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkCanaryMetrics(...): unexpected error %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("checkCanaryMetrics(...): want %t, got %t", tc.want, got)
+			}
+		})
+	}
+}
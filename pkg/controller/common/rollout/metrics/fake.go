@@ -0,0 +1,41 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+)
+
+// FakeProvider is a Provider returning the preset values without querying any metric server, it's used in test
+type FakeProvider struct {
+	// Values are the results of the queries
+	Values map[string]float64
+	// Err is returned by every query if it's set
+	Err error
+}
+
+// Query returns the preset value of the query
+func (p *FakeProvider) Query(_ context.Context, query string) (float64, error) {
+	if p.Err != nil {
+		return 0, p.Err
+	}
+	value, ok := p.Values[query]
+	if !ok {
+		return 0, ErrNoValue
+	}
+	return value, nil
+}
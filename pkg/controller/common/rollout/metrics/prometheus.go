@@ -0,0 +1,133 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const prometheusQueryTimeout = 10 * time.Second
+
+// PrometheusProvider queries the metric by the Prometheus HTTP API
+type PrometheusProvider struct {
+	address string
+	client  *http.Client
+}
+
+// NewPrometheusProvider creates a PrometheusProvider querying the Prometheus server at the address
+func NewPrometheusProvider(address string) (*PrometheusProvider, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid prometheus address %q", address)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.Errorf("invalid prometheus address %q, expect a http or https url", address)
+	}
+	return &PrometheusProvider{
+		address: strings.TrimSuffix(address, "/"),
+		client:  &http.Client{Timeout: prometheusQueryTimeout},
+	}, nil
+}
+
+// prometheusResponse is the response of the Prometheus instant query API
+type prometheusResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// Query runs the instant query, it results in a scalar or a vector with a single sample
+func (p *PrometheusProvider) Query(ctx context.Context, query string) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/api/v1/query?%s", p.address, url.Values{"query": []string{query}}.Encode()), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot create prometheus query request")
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot query prometheus")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot read prometheus response")
+	}
+	var result prometheusResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, errors.Wrapf(err, "cannot unmarshal prometheus response with status %d", resp.StatusCode)
+	}
+	if result.Status != "success" {
+		return 0, errors.Errorf("prometheus query failed, %s: %s", result.ErrorType, result.Error)
+	}
+
+	var sample []interface{}
+	switch result.Data.ResultType {
+	case "scalar":
+		if err := json.Unmarshal(result.Data.Result, &sample); err != nil {
+			return 0, errors.Wrap(err, "cannot unmarshal prometheus scalar result")
+		}
+	case "vector":
+		var vector []struct {
+			Value []interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(result.Data.Result, &vector); err != nil {
+			return 0, errors.Wrap(err, "cannot unmarshal prometheus vector result")
+		}
+		if len(vector) == 0 {
+			return 0, ErrNoValue
+		}
+		if len(vector) > 1 {
+			return 0, errors.Errorf("the query results in %d values, expect a single value", len(vector))
+		}
+		sample = vector[0].Value
+	default:
+		return 0, errors.Errorf("unsupported prometheus result type %q", result.Data.ResultType)
+	}
+	// a sample is a pair of the timestamp and the value in string
+	if len(sample) != 2 {
+		return 0, errors.Errorf("invalid prometheus sample %v", sample)
+	}
+	str, ok := sample[1].(string)
+	if !ok {
+		return 0, errors.Errorf("invalid prometheus sample value %v", sample[1])
+	}
+	value, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot parse prometheus sample value %q", str)
+	}
+	if math.IsNaN(value) {
+		return 0, ErrNoValue
+	}
+	return value, nil
+}
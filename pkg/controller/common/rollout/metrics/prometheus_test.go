@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+)
+
+func TestPrometheusProvider(t *testing.T) {
+	responses := map[string]string{
+		"vector":  `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1625000000.1,"0.99"]}]}}`,
+		"scalar":  `{"status":"success","data":{"resultType":"scalar","result":[1625000000.1,"12"]}}`,
+		"empty":   `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+		"nan":     `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1625000000.1,"NaN"]}]}}`,
+		"series":  `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"a":"1"},"value":[1,"1"]},{"metric":{"a":"2"},"value":[1,"2"]}]}}`,
+		"invalid": `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, responses[r.URL.Query().Get("query")])
+	}))
+	defer server.Close()
+
+	p, err := NewPrometheusProvider(server.URL + "/")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	value, err := p.Query(ctx, "vector")
+	require.NoError(t, err)
+	assert.Equal(t, 0.99, value)
+	value, err = p.Query(ctx, "scalar")
+	require.NoError(t, err)
+	assert.Equal(t, float64(12), value)
+
+	_, err = p.Query(ctx, "empty")
+	assert.Equal(t, ErrNoValue, err)
+	_, err = p.Query(ctx, "nan")
+	assert.Equal(t, ErrNoValue, err)
+	_, err = p.Query(ctx, "series")
+	assert.EqualError(t, err, "the query results in 2 values, expect a single value")
+	_, err = p.Query(ctx, "invalid")
+	assert.EqualError(t, err, "prometheus query failed, bad_data: parse error")
+
+	_, err = NewPrometheusProvider("prometheus:9090")
+	assert.Error(t, err)
+}
+
+func TestCheckRange(t *testing.T) {
+	min, max := intstr.FromString("0.95"), intstr.FromInt(1)
+	cases := map[string]struct {
+		metricsRange *v1alpha1.MetricsExpectedRange
+		value        float64
+		wantErr      string
+	}{
+		"NoRange": {
+			value: 100,
+		},
+		"InRange": {
+			metricsRange: &v1alpha1.MetricsExpectedRange{Min: &min, Max: &max},
+			value:        0.99,
+		},
+		"LessThanMin": {
+			metricsRange: &v1alpha1.MetricsExpectedRange{Min: &min},
+			value:        0.9,
+			wantErr:      "the value 0.9 is less than the min value 0.95",
+		},
+		"GreaterThanMax": {
+			metricsRange: &v1alpha1.MetricsExpectedRange{Max: &max},
+			value:        2,
+			wantErr:      "the value 2 is greater than the max value 1",
+		},
+		"InvalidRange": {
+			metricsRange: &v1alpha1.MetricsExpectedRange{Min: &max, Max: &min},
+			wantErr:      "the min value 1 is greater than the max value 0.95",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := CheckRange(tc.metricsRange, tc.value)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
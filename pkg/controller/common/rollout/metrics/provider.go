@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+)
+
+// ErrNoValue is returned by a Provider if the query results in no value
+var ErrNoValue = errors.New("the query results in no value")
+
+// Provider queries the value of a metric for canary analysis
+type Provider interface {
+	// Query runs the query and returns the single value it results in
+	Query(ctx context.Context, query string) (float64, error)
+}
+
+// NewProvider creates the Provider of the metric provider
+func NewProvider(provider v1alpha1.MetricProvider) (Provider, error) {
+	switch provider.Type {
+	case v1alpha1.PrometheusMetricProvider:
+		return NewPrometheusProvider(provider.Address)
+	default:
+		return nil, errors.Errorf("unsupported metric provider type %q", provider.Type)
+	}
+}
+
+// ParseRange parses the min and max value of the expected range, a nil bound is not limited
+func ParseRange(r *v1alpha1.MetricsExpectedRange) (min, max *float64, err error) {
+	if r == nil {
+		return nil, nil, nil
+	}
+	if min, err = parseBound(r.Min); err != nil {
+		return nil, nil, errors.WithMessage(err, "invalid min value")
+	}
+	if max, err = parseBound(r.Max); err != nil {
+		return nil, nil, errors.WithMessage(err, "invalid max value")
+	}
+	if min != nil && max != nil && *min > *max {
+		return nil, nil, errors.Errorf("the min value %v is greater than the max value %v", *min, *max)
+	}
+	return min, max, nil
+}
+
+// CheckRange checks if the value is in the expected range
+func CheckRange(r *v1alpha1.MetricsExpectedRange, value float64) error {
+	min, max, err := ParseRange(r)
+	if err != nil {
+		return err
+	}
+	if min != nil && value < *min {
+		return fmt.Errorf("the value %v is less than the min value %v", value, *min)
+	}
+	if max != nil && value > *max {
+		return fmt.Errorf("the value %v is greater than the max value %v", value, *max)
+	}
+	return nil
+}
+
+func parseBound(bound *intstr.IntOrString) (*float64, error) {
+	if bound == nil {
+		return nil, nil
+	}
+	if bound.Type == intstr.Int {
+		value := float64(bound.IntVal)
+		return &value, nil
+	}
+	value, err := strconv.ParseFloat(bound.StrVal, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %q as a number", bound.StrVal)
+	}
+	return &value, nil
+}
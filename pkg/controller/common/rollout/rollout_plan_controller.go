@@ -20,11 +20,14 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/crossplane/crossplane-runtime/pkg/event"
 	kruisev1 "github.com/openkruise/kruise-api/apps/v1alpha1"
 	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
@@ -33,6 +36,8 @@ import (
 
 	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
 	"github.com/oam-dev/kubevela/pkg/controller/common"
+	"github.com/oam-dev/kubevela/pkg/controller/common/rollout/metrics"
+	"github.com/oam-dev/kubevela/pkg/controller/common/rollout/traffic"
 	"github.com/oam-dev/kubevela/pkg/controller/common/rollout/workloads"
 	"github.com/oam-dev/kubevela/pkg/oam"
 )
@@ -51,6 +56,13 @@ type Controller struct {
 
 	targetWorkload *unstructured.Unstructured
 	sourceWorkload *unstructured.Unstructured
+
+	// newMetricProvider creates the provider to query the canary metrics
+	newMetricProvider func(provider v1alpha1.MetricProvider) (metrics.Provider, error)
+
+	// newTrafficProvider creates the provider to shift the traffic between the source and the target
+	newTrafficProvider func(c client.Client, routing v1alpha1.TrafficRouting, namespace string,
+		owner metav1.OwnerReference) (traffic.Provider, error)
 }
 
 // NewRolloutPlanController creates a RolloutPlanController
@@ -62,13 +74,15 @@ func NewRolloutPlanController(client client.Client, parentController oam.Object,
 		initializedRolloutStatus.BatchRollingState = v1alpha1.BatchInitializingState
 	}
 	return &Controller{
-		client:           client,
-		parentController: parentController,
-		recorder:         recorder,
-		rolloutSpec:      rolloutSpec.DeepCopy(),
-		rolloutStatus:    initializedRolloutStatus,
-		targetWorkload:   targetWorkload,
-		sourceWorkload:   sourceWorkload,
+		client:             client,
+		parentController:   parentController,
+		recorder:           recorder,
+		rolloutSpec:        rolloutSpec.DeepCopy(),
+		rolloutStatus:      initializedRolloutStatus,
+		targetWorkload:     targetWorkload,
+		sourceWorkload:     sourceWorkload,
+		newMetricProvider:  metrics.NewProvider,
+		newTrafficProvider: traffic.NewProvider,
 	}
 }
 
@@ -92,8 +106,7 @@ func (r *Controller) Reconcile(ctx context.Context) (res reconcile.Result, statu
 	status = r.rolloutStatus
 
 	defer func() {
-		if status.RollingState == v1alpha1.RolloutFailedState ||
-			status.RollingState == v1alpha1.RolloutSucceedState {
+		if status.IsTerminated() {
 			// no need to requeue if we reach the terminal states
 			res = reconcile.Result{}
 		} else {
@@ -113,6 +126,9 @@ func (r *Controller) Reconcile(ctx context.Context) (res reconcile.Result, statu
 	switch r.rolloutStatus.RollingState {
 	case v1alpha1.VerifyingSpecState:
 		verified, err := workloadController.VerifySpec(ctx)
+		if err == nil {
+			err = r.verifyTrafficRouting()
+		}
 		if err != nil {
 			// we can fail it right away, everything after initialized need to be finalized
 			r.rolloutStatus.RolloutFailed(err.Error())
@@ -134,11 +150,23 @@ func (r *Controller) Reconcile(ctx context.Context) (res reconcile.Result, statu
 		r.reconcileBatchInRolling(ctx, workloadController)
 
 	case v1alpha1.RolloutFailingState, v1alpha1.RolloutAbandoningState, v1alpha1.RolloutDeletingState:
+		// route all the traffic back to the source before finalizing the workloads
+		if err := r.shiftTraffic(ctx, 0); err != nil {
+			klog.ErrorS(err, "failed to route the traffic back to the source")
+			r.rolloutStatus.RolloutRetry(err.Error())
+			break
+		}
 		if succeed := workloadController.Finalize(ctx, false); succeed {
 			r.finalizeRollout(ctx)
 		}
 
 	case v1alpha1.FinalisingState:
+		// route all the traffic to the target before finalizing the workloads
+		if err := r.shiftTraffic(ctx, 100); err != nil {
+			klog.ErrorS(err, "failed to route the traffic to the target")
+			r.rolloutStatus.RolloutRetry(err.Error())
+			break
+		}
 		if succeed := workloadController.Finalize(ctx, true); succeed {
 			r.finalizeRollout(ctx)
 		}
@@ -149,6 +177,9 @@ func (r *Controller) Reconcile(ctx context.Context) (res reconcile.Result, statu
 	case v1alpha1.RolloutFailedState:
 		// Nothing to do
 
+	case v1alpha1.RolloutRolledBackState:
+		// Nothing to do
+
 	default:
 		panic(fmt.Sprintf("illegal rollout status %+v", r.rolloutStatus))
 	}
@@ -158,11 +189,18 @@ func (r *Controller) Reconcile(ctx context.Context) (res reconcile.Result, statu
 
 // reconcile logic when we are in the middle of rollout, we have to go through finalizing state before succeed or fail
 func (r *Controller) reconcileBatchInRolling(ctx context.Context, workloadController workloads.WorkloadController) {
+	paused := r.rolloutStatus.GetCondition(v1alpha1.BatchPaused).Status == corev1.ConditionTrue
 	if r.rolloutSpec.Paused {
-		r.recorder.Event(r.parentController, event.Normal("Rollout paused", "Rollout paused"))
-		r.rolloutStatus.SetConditions(v1alpha1.NewPositiveCondition(v1alpha1.BatchPaused))
+		if !paused {
+			r.recorder.Event(r.parentController, event.Normal("Rollout paused", "Rollout paused"))
+			r.rolloutStatus.SetConditions(v1alpha1.NewPositiveCondition(v1alpha1.BatchPaused))
+		}
 		return
 	}
+	if paused {
+		r.recorder.Event(r.parentController, event.Normal("Rollout resumed", "Rollout resumed"))
+		r.rolloutStatus.SetConditions(v1alpha1.NewNegativeCondition(v1alpha1.BatchPaused, "Rollout resumed"))
+	}
 
 	switch r.rolloutStatus.BatchRollingState {
 	case v1alpha1.BatchInitializingState:
@@ -180,12 +218,27 @@ func (r *Controller) reconcileBatchInRolling(ctx context.Context, workloadContro
 	case v1alpha1.BatchVerifyingState:
 		// verifying if the application is ready to roll
 		// need to check if they meet the availability requirements in the rollout spec.
-		// TODO: evaluate any metrics/analysis
 		// TODO: We may need to go back to rollout again if the size of the resource can change behind our back
 		verified, err := workloadController.CheckOneBatchPods(ctx)
 		if err != nil {
 			r.rolloutStatus.RolloutFailing(err.Error())
-		} else if verified {
+			break
+		}
+		if !verified {
+			break
+		}
+		// shift the traffic of the batch once its pods are available so that the canary metrics cover it
+		if err := r.shiftBatchTraffic(ctx); err != nil {
+			klog.ErrorS(err, "failed to shift the traffic", "current batch", r.rolloutStatus.CurrentBatch)
+			r.rolloutStatus.RolloutRetry(err.Error())
+			break
+		}
+		// evaluate the canary metrics once the pods in the batch are available
+		analyzed, err := r.checkCanaryMetrics(ctx)
+		if err != nil {
+			r.recorder.Event(r.parentController, event.Warning("Canary Metric Failed", err))
+			r.rolloutStatus.RolloutFailing(err.Error())
+		} else if analyzed {
 			r.rolloutStatus.StateTransition(v1alpha1.OneBatchAvailableEvent)
 		}
 
@@ -261,13 +314,55 @@ func (r *Controller) gatherAllWebhooks() []v1alpha1.RolloutWebhook {
 
 // check if we can move to the next batch
 func (r *Controller) tryMovingToNextBatch() {
-	if r.rolloutSpec.BatchPartition == nil || *r.rolloutSpec.BatchPartition > r.rolloutStatus.CurrentBatch {
-		klog.InfoS("ready to rollout the next batch", "current batch", r.rolloutStatus.CurrentBatch)
-		r.rolloutStatus.StateTransition(v1alpha1.BatchRolloutApprovedEvent)
-	} else {
+	if r.rolloutSpec.BatchPartition != nil && *r.rolloutSpec.BatchPartition <= r.rolloutStatus.CurrentBatch {
 		klog.V(common.LogDebug).InfoS("the current batch is waiting to move on", "current batch",
 			r.rolloutStatus.CurrentBatch)
+		return
+	}
+	currentBatch := int(r.rolloutStatus.CurrentBatch)
+	if currentBatch < len(r.rolloutSpec.RolloutBatches) && r.rolloutSpec.RolloutBatches[currentBatch].RequireApproval &&
+		!r.checkBatchApproval() {
+		klog.V(common.LogDebug).InfoS("the current batch is waiting for approval", "current batch",
+			r.rolloutStatus.CurrentBatch)
+		return
 	}
+	klog.InfoS("ready to rollout the next batch", "current batch", r.rolloutStatus.CurrentBatch)
+	r.rolloutStatus.StateTransition(v1alpha1.BatchRolloutApprovedEvent)
+}
+
+// checkBatchApproval checks if the current batch is approved by the approval annotation of the parent controller,
+// the approval is recorded in the rollout status once it's observed
+func (r *Controller) checkBatchApproval() bool {
+	batch := r.rolloutStatus.CurrentBatch
+	if r.rolloutStatus.GetBatchApproval(batch) != nil {
+		return true
+	}
+	annotations := r.parentController.GetAnnotations()
+	approvedBatch, ok := annotations[oam.AnnotationRolloutApprovedBatch]
+	if !ok {
+		r.rolloutStatus.RolloutRetry(fmt.Sprintf("batch %d is waiting for approval", batch))
+		return false
+	}
+	approved, err := strconv.ParseInt(approvedBatch, 10, 32)
+	if err != nil {
+		klog.ErrorS(err, "invalid rollout approval", "annotation", oam.AnnotationRolloutApprovedBatch,
+			"value", approvedBatch)
+		r.rolloutStatus.RolloutRetry(fmt.Sprintf("invalid approval %q of batch %d", approvedBatch, batch))
+		return false
+	}
+	if int32(approved) < batch {
+		r.rolloutStatus.RolloutRetry(fmt.Sprintf("batch %d is waiting for approval", batch))
+		return false
+	}
+	approver := annotations[oam.AnnotationRolloutApprover]
+	r.rolloutStatus.BatchApprovals = append(r.rolloutStatus.BatchApprovals, v1alpha1.BatchApproval{
+		Batch:      batch,
+		Approver:   approver,
+		ApprovedAt: metav1.Now(),
+	})
+	klog.InfoS("the current batch is approved", "current batch", batch, "approver", approver)
+	r.recorder.Event(r.parentController, event.Normal("Batch Approved", fmt.Sprintf("Batch %d is approved", batch)))
+	return true
 }
 
 func (r *Controller) finalizeOneBatch(ctx context.Context) {
@@ -346,6 +441,14 @@ func (r *Controller) GetWorkloadController() (workloads.WorkloadController, erro
 			return workloads.NewCloneSetScaleController(r.client, r.recorder, r.parentController,
 				r.rolloutSpec, r.rolloutStatus, target), nil
 		}
+		// check if the target workload is Advanced StatefulSet, it's upgraded in place as CloneSet
+		if r.targetWorkload.GetKind() == reflect.TypeOf(kruisev1.StatefulSet{}).Name() {
+			if r.sourceWorkload != nil {
+				return workloads.NewAdvancedStatefulSetRolloutController(r.client, r.recorder, r.parentController,
+					r.rolloutSpec, r.rolloutStatus, target), nil
+			}
+			return nil, fmt.Errorf("scaling the workload kind `%s` is not supported", kind)
+		}
 	}
 
 	if r.targetWorkload.GroupVersionKind().Group == apps.GroupName {
@@ -359,6 +462,23 @@ func (r *Controller) GetWorkloadController() (workloads.WorkloadController, erro
 			return workloads.NewDeploymentScaleController(r.client, r.recorder, r.parentController,
 				r.rolloutSpec, r.rolloutStatus, target), nil
 		}
+		// check if the target workload is StatefulSet, it's upgraded in place
+		if r.targetWorkload.GetKind() == reflect.TypeOf(apps.StatefulSet{}).Name() {
+			if r.sourceWorkload != nil {
+				return workloads.NewStatefulSetRolloutController(r.client, r.recorder, r.parentController,
+					r.rolloutSpec, r.rolloutStatus, target), nil
+			}
+			return workloads.NewStatefulSetScaleController(r.client, r.recorder, r.parentController,
+				r.rolloutSpec, r.rolloutStatus, target), nil
+		}
+		// check if the target workload is DaemonSet, it's upgraded in place and can't be scaled
+		if r.targetWorkload.GetKind() == reflect.TypeOf(apps.DaemonSet{}).Name() {
+			if r.sourceWorkload != nil {
+				return workloads.NewDaemonSetRolloutController(r.client, r.recorder, r.parentController,
+					r.rolloutSpec, r.rolloutStatus, target), nil
+			}
+			return nil, fmt.Errorf("scaling the workload kind `%s` is not supported", kind)
+		}
 	}
 
 	return nil, fmt.Errorf("the workload kind `%s` is not supported", kind)
@@ -17,11 +17,19 @@ limitations under the License.
 package rollout
 
 import (
+	"context"
 	"testing"
 
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
 
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/pkg/oam"
 )
 
 func Test_TryMovingToNextBatch(t *testing.T) {
@@ -63,3 +71,110 @@ func Test_TryMovingToNextBatch(t *testing.T) {
 		})
 	}
 }
+
+func TestTryMovingToNextBatchWithApproval(t *testing.T) {
+	rolloutSpec := &v1alpha1.RolloutPlan{
+		RolloutBatches: []v1alpha1.RolloutBatch{
+			{Replicas: intstr.FromInt(1)},
+			{Replicas: intstr.FromInt(1), RequireApproval: true},
+			{Replicas: intstr.FromInt(1)},
+		},
+	}
+	tests := map[string]struct {
+		annotations   map[string]string
+		approvals     []v1alpha1.BatchApproval
+		wantNextBatch int32
+		wantApprovals []v1alpha1.BatchApproval
+	}{
+		"wait for approval": {
+			wantNextBatch: 1,
+		},
+		"only the previous batch is approved": {
+			annotations:   map[string]string{oam.AnnotationRolloutApprovedBatch: "0"},
+			wantNextBatch: 1,
+		},
+		"invalid approval": {
+			annotations:   map[string]string{oam.AnnotationRolloutApprovedBatch: "first"},
+			wantNextBatch: 1,
+		},
+		"approved by annotation": {
+			annotations: map[string]string{
+				oam.AnnotationRolloutApprovedBatch: "1",
+				oam.AnnotationRolloutApprover:      "alice",
+			},
+			wantNextBatch: 2,
+			wantApprovals: []v1alpha1.BatchApproval{{Batch: 1, Approver: "alice"}},
+		},
+		"a later batch is approved": {
+			annotations:   map[string]string{oam.AnnotationRolloutApprovedBatch: "2"},
+			wantNextBatch: 2,
+			wantApprovals: []v1alpha1.BatchApproval{{Batch: 1}},
+		},
+		"approval already recorded": {
+			approvals:     []v1alpha1.BatchApproval{{Batch: 1, Approver: "bob"}},
+			wantNextBatch: 2,
+			wantApprovals: []v1alpha1.BatchApproval{{Batch: 1, Approver: "bob"}},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			parent := &v1beta1.AppRollout{}
+			parent.SetAnnotations(tt.annotations)
+			r := &Controller{
+				recorder:         event.NewNopRecorder(),
+				parentController: parent,
+				rolloutSpec:      rolloutSpec,
+				rolloutStatus: &v1alpha1.RolloutStatus{
+					CurrentBatch:      1,
+					RollingState:      v1alpha1.RollingInBatchesState,
+					BatchRollingState: v1alpha1.BatchReadyState,
+					BatchApprovals:    tt.approvals,
+				},
+			}
+			r.tryMovingToNextBatch()
+			if r.rolloutStatus.CurrentBatch != tt.wantNextBatch {
+				t.Errorf("want batch `%d`, got batch `%d`", tt.wantNextBatch, r.rolloutStatus.CurrentBatch)
+			}
+			if diff := cmp.Diff(tt.wantApprovals, r.rolloutStatus.BatchApprovals,
+				cmpopts.IgnoreFields(v1alpha1.BatchApproval{}, "ApprovedAt")); diff != "" {
+				t.Errorf("approvals mismatch (-want +got):\n%s", diff)
+			}
+			if tt.wantNextBatch == 1 && r.rolloutStatus.GetCondition(v1alpha1.BatchReady).Status != corev1.ConditionFalse {
+				t.Errorf("want the waiting batch with a negative condition, got %+v", r.rolloutStatus.Conditions)
+			}
+		})
+	}
+}
+
+func TestPauseAndResumeRollout(t *testing.T) {
+	rolloutSpec := &v1alpha1.RolloutPlan{
+		RolloutBatches: []v1alpha1.RolloutBatch{{Replicas: intstr.FromInt(1)}, {Replicas: intstr.FromInt(1)}},
+		Paused:         true,
+	}
+	r := &Controller{
+		recorder:         event.NewNopRecorder(),
+		parentController: &v1beta1.AppRollout{},
+		rolloutSpec:      rolloutSpec,
+		rolloutStatus: &v1alpha1.RolloutStatus{
+			RollingState:      v1alpha1.RollingInBatchesState,
+			BatchRollingState: v1alpha1.BatchReadyState,
+		},
+	}
+	// the workload controller is not used in the ready state
+	r.reconcileBatchInRolling(context.Background(), nil)
+	if r.rolloutStatus.CurrentBatch != 0 {
+		t.Errorf("want the paused rollout at batch 0, got batch %d", r.rolloutStatus.CurrentBatch)
+	}
+	if r.rolloutStatus.GetCondition(v1alpha1.BatchPaused).Status != corev1.ConditionTrue {
+		t.Errorf("want the rollout paused, got %+v", r.rolloutStatus.Conditions)
+	}
+
+	rolloutSpec.Paused = false
+	r.reconcileBatchInRolling(context.Background(), nil)
+	if r.rolloutStatus.CurrentBatch != 1 {
+		t.Errorf("want the resumed rollout at batch 1, got batch %d", r.rolloutStatus.CurrentBatch)
+	}
+	if r.rolloutStatus.GetCondition(v1alpha1.BatchPaused).Status != corev1.ConditionFalse {
+		t.Errorf("want the rollout resumed, got %+v", r.rolloutStatus.Conditions)
+	}
+}
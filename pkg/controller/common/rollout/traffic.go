@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/controller/common/rollout/traffic"
+	"github.com/oam-dev/kubevela/pkg/utils/apply"
+)
+
+// verifyTrafficRouting makes sure that the traffic can be shifted between the source and the target
+func (r *Controller) verifyTrafficRouting() error {
+	if r.rolloutSpec.TrafficRouting == nil {
+		return nil
+	}
+	if r.sourceWorkload == nil || (r.sourceWorkload.GetName() == r.targetWorkload.GetName() &&
+		r.sourceWorkload.GetKind() == r.targetWorkload.GetKind()) {
+		return errors.New("the traffic routing needs different source and target workloads")
+	}
+	sourceSvc, err := traffic.WorkloadService(r.sourceWorkload, r.rolloutSpec.TrafficRouting.Port)
+	if err != nil {
+		return err
+	}
+	targetSvc, err := traffic.WorkloadService(r.targetWorkload, r.rolloutSpec.TrafficRouting.Port)
+	if err != nil {
+		return err
+	}
+	// the service of a workload selects the pods of the other one if its selector is a subset of the other selector,
+	// then the traffic weights have no effect
+	if labels.SelectorFromSet(sourceSvc.Spec.Selector).Matches(labels.Set(targetSvc.Spec.Selector)) ||
+		labels.SelectorFromSet(targetSvc.Spec.Selector).Matches(labels.Set(sourceSvc.Spec.Selector)) {
+		return errors.Errorf("the traffic routing needs the source workload %s and the target workload %s to select "+
+			"different pods, add the app revision to their selectors, e.g., by addRevisionLabel of webservice",
+			r.sourceWorkload.GetName(), r.targetWorkload.GetName())
+	}
+	return nil
+}
+
+// shiftBatchTraffic routes the traffic of the current batch to the target if the batch has a traffic weight
+func (r *Controller) shiftBatchTraffic(ctx context.Context) error {
+	weight := r.rolloutSpec.RolloutBatches[r.rolloutStatus.CurrentBatch].TrafficWeight
+	if weight == nil {
+		return nil
+	}
+	return r.shiftTraffic(ctx, *weight)
+}
+
+// shiftTraffic routes the weight percent of the traffic to the target workload and the rest to the source workload
+// by the traffic provider of the rollout plan, the services of the workloads are applied along with the route
+func (r *Controller) shiftTraffic(ctx context.Context, weight int32) error {
+	routing := r.rolloutSpec.TrafficRouting
+	if routing == nil || r.sourceWorkload == nil {
+		return nil
+	}
+	if r.rolloutStatus.TrafficWeight != nil && *r.rolloutStatus.TrafficWeight == weight {
+		return nil
+	}
+	owner := r.trafficOwner()
+	provider, err := r.newTrafficProvider(r.client, *routing, r.targetWorkload.GetNamespace(), *owner)
+	if err != nil {
+		return err
+	}
+	sourceSvc, err := traffic.WorkloadService(r.sourceWorkload, routing.Port)
+	if err != nil {
+		return err
+	}
+	targetSvc, err := traffic.WorkloadService(r.targetWorkload, routing.Port)
+	if err != nil {
+		return err
+	}
+	applicator := apply.NewApplicator(r.client)
+	for _, svc := range []metav1.Object{sourceSvc, targetSvc} {
+		svc.SetOwnerReferences([]metav1.OwnerReference{*owner})
+	}
+	if err := applicator.Apply(ctx, sourceSvc); err != nil {
+		return errors.Wrapf(err, "cannot apply the service of the source workload %s", r.sourceWorkload.GetName())
+	}
+	if err := applicator.Apply(ctx, targetSvc); err != nil {
+		return errors.Wrapf(err, "cannot apply the service of the target workload %s", r.targetWorkload.GetName())
+	}
+	if err := provider.Route(ctx, sourceSvc.Name, targetSvc.Name, weight); err != nil {
+		return err
+	}
+	klog.InfoS("shifted the traffic", "provider", routing.Provider, "service", routing.Service,
+		"target service", targetSvc.Name, "weight", weight)
+	r.recorder.Event(r.parentController, event.Normal("Traffic Shifted",
+		fmt.Sprintf("%d%% of the traffic of service %s is routed to %s", weight, routing.Service, targetSvc.Name)))
+	r.rolloutStatus.TrafficWeight = pointer.Int32Ptr(weight)
+	return nil
+}
+
+// trafficOwner returns the owner of the services and the route of the traffic. The AppRollout of the rollout plan of
+// an application is constructed in memory only and controlled by the application with the same UID, the application
+// owns them then, otherwise they'd be garbage collected for the missing owner.
+func (r *Controller) trafficOwner() *metav1.OwnerReference {
+	if owner := metav1.GetControllerOf(r.parentController); owner != nil && owner.UID == r.parentController.GetUID() {
+		return owner
+	}
+	return metav1.NewControllerRef(r.parentController, v1beta1.AppRolloutKindVersionKind)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/oam-dev/kubevela/pkg/utils/apply"
+)
+
+// gatewayAPIProvider splits the traffic by a Gateway API HTTPRoute
+type gatewayAPIProvider struct {
+	router
+}
+
+// Route applies the HTTPRoute routing the traffic of the gateways to the source and the target by weight
+func (p *gatewayAPIProvider) Route(ctx context.Context, source, target string, weight int32) error {
+	spec := map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{
+				"backendRefs": []interface{}{
+					p.backendRef(source, 100-weight),
+					p.backendRef(target, weight),
+				},
+			},
+		},
+	}
+	if len(p.routing.Gateways) != 0 {
+		parentRefs := make([]interface{}, 0, len(p.routing.Gateways))
+		for _, gateway := range p.routing.Gateways {
+			ref := map[string]interface{}{"name": gateway}
+			if i := strings.Index(gateway, "/"); i >= 0 {
+				ref = map[string]interface{}{"namespace": gateway[:i], "name": gateway[i+1:]}
+			}
+			parentRefs = append(parentRefs, ref)
+		}
+		spec["parentRefs"] = parentRefs
+	}
+	if len(p.routing.Hosts) != 0 {
+		hostnames := make([]interface{}, 0, len(p.routing.Hosts))
+		for _, host := range p.routing.Hosts {
+			hostnames = append(hostnames, host)
+		}
+		spec["hostnames"] = hostnames
+	}
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1alpha2",
+		"kind":       "HTTPRoute",
+		"spec":       spec,
+	}}
+	meta := p.objectMeta()
+	route.SetName(meta.Name)
+	route.SetNamespace(meta.Namespace)
+	route.SetOwnerReferences(meta.OwnerReferences)
+	return errors.Wrap(apply.NewApplicator(p.client).Apply(ctx, route), "cannot apply the Gateway API HTTPRoute")
+}
+
+func (p *gatewayAPIProvider) backendRef(service string, weight int32) map[string]interface{} {
+	return map[string]interface{}{
+		"name":   service,
+		"port":   int64(p.routing.Port),
+		"weight": int64(weight),
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	istioapiv1beta1 "istio.io/api/networking/v1beta1"
+	istioclientv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/oam-dev/kubevela/pkg/utils/apply"
+)
+
+// istioProvider splits the traffic by an istio VirtualService
+type istioProvider struct {
+	router
+}
+
+// Route applies the VirtualService routing the traffic of the hosts to the source and the target by weight
+func (p *istioProvider) Route(ctx context.Context, source, target string, weight int32) error {
+	vs := &istioclientv1beta1.VirtualService{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: istioclientv1beta1.SchemeGroupVersion.String(),
+			Kind:       "VirtualService",
+		},
+		ObjectMeta: p.objectMeta(),
+		Spec: istioapiv1beta1.VirtualService{
+			Hosts:    p.hosts(),
+			Gateways: p.routing.Gateways,
+			Http: []*istioapiv1beta1.HTTPRoute{{
+				Route: []*istioapiv1beta1.HTTPRouteDestination{
+					p.destination(source, 100-weight),
+					p.destination(target, weight),
+				},
+			}},
+		},
+	}
+	return errors.Wrap(apply.NewApplicator(p.client).Apply(ctx, vs), "cannot apply the istio VirtualService")
+}
+
+func (p *istioProvider) destination(host string, weight int32) *istioapiv1beta1.HTTPRouteDestination {
+	return &istioapiv1beta1.HTTPRouteDestination{
+		Destination: &istioapiv1beta1.Destination{
+			Host: host,
+			Port: &istioapiv1beta1.PortSelector{Number: uint32(p.routing.Port)},
+		},
+		Weight: weight,
+	}
+}
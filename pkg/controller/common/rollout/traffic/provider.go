@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+// Provider splits the traffic of a service between the services of the source and the target
+type Provider interface {
+	// Route routes the weight percent of the traffic to the target service and the rest to the source service
+	Route(ctx context.Context, source, target string, weight int32) error
+}
+
+// NewProvider creates the Provider of the traffic routing, the routing object is named after the service
+// in the namespace and owned by the owner
+func NewProvider(c client.Client, routing v1alpha1.TrafficRouting, namespace string, owner metav1.OwnerReference) (Provider, error) {
+	r := router{client: c, routing: routing, namespace: namespace, owner: owner}
+	switch routing.Provider {
+	case v1alpha1.IstioTrafficProvider:
+		return &istioProvider{router: r}, nil
+	case v1alpha1.SMITrafficProvider:
+		return &smiProvider{router: r}, nil
+	case v1alpha1.GatewayAPITrafficProvider:
+		return &gatewayAPIProvider{router: r}, nil
+	default:
+		return nil, errors.Errorf("unsupported traffic provider %q", routing.Provider)
+	}
+}
+
+// router holds the common fields of the providers
+type router struct {
+	client    client.Client
+	routing   v1alpha1.TrafficRouting
+	namespace string
+	owner     metav1.OwnerReference
+}
+
+func (r *router) objectMeta() metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:            r.routing.Service,
+		Namespace:       r.namespace,
+		OwnerReferences: []metav1.OwnerReference{r.owner},
+	}
+}
+
+func (r *router) hosts() []string {
+	if len(r.routing.Hosts) != 0 {
+		return r.routing.Hosts
+	}
+	return []string{r.routing.Service}
+}
+
+// WorkloadService creates the service to route the traffic to the pods of the workload on the port,
+// the pods are selected by the selector of the workload.
+func WorkloadService(workload *unstructured.Unstructured, port int32) (*corev1.Service, error) {
+	selector, _, err := unstructured.NestedStringMap(workload.Object, "spec", "selector", "matchLabels")
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get the selector of workload %s", workload.GetName())
+	}
+	if len(selector) == 0 {
+		return nil, errors.Errorf("workload %s has no selector to route the traffic to", workload.GetName())
+	}
+	svc := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%d", workload.GetName(), port),
+			Namespace: workload.GetNamespace(),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports:    []corev1.ServicePort{{Protocol: corev1.ProtocolTCP, Port: port}},
+		},
+	}
+	if comp, ok := workload.GetLabels()[oam.LabelAppComponent]; ok {
+		svc.SetLabels(map[string]string{oam.LabelAppComponent: comp})
+	}
+	return svc, nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	istioclientv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+func TestProviders(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, istioclientv1beta1.AddToScheme(scheme))
+	owner := metav1.OwnerReference{APIVersion: "core.oam.dev/v1beta1", Kind: "AppRollout", Name: "rollout", UID: "uid"}
+	routing := v1alpha1.TrafficRouting{Service: "web", Port: 80, Gateways: []string{"gateway-ns/gateway"}}
+	key := client.ObjectKey{Namespace: "default", Name: "web"}
+
+	t.Run("istio", func(t *testing.T) {
+		cli := fake.NewFakeClientWithScheme(scheme)
+		routing.Provider = v1alpha1.IstioTrafficProvider
+		p, err := NewProvider(cli, routing, "default", owner)
+		require.NoError(t, err)
+		require.NoError(t, p.Route(ctx, "web-v1-80", "web-v2-80", 20))
+		vs := &istioclientv1beta1.VirtualService{}
+		require.NoError(t, cli.Get(ctx, key, vs))
+		assert.Equal(t, []metav1.OwnerReference{owner}, vs.OwnerReferences)
+		assert.Equal(t, []string{"web"}, vs.Spec.Hosts)
+		assert.Equal(t, []string{"gateway-ns/gateway"}, vs.Spec.Gateways)
+		destinations := vs.Spec.Http[0].Route
+		require.Len(t, destinations, 2)
+		assert.Equal(t, "web-v1-80", destinations[0].Destination.Host)
+		assert.Equal(t, int32(80), destinations[0].Weight)
+		assert.Equal(t, "web-v2-80", destinations[1].Destination.Host)
+		assert.Equal(t, int32(20), destinations[1].Weight)
+		assert.Equal(t, uint32(80), destinations[1].Destination.Port.Number)
+	})
+
+	t.Run("smi", func(t *testing.T) {
+		cli := fake.NewFakeClientWithScheme(scheme)
+		routing.Provider = v1alpha1.SMITrafficProvider
+		p, err := NewProvider(cli, routing, "default", owner)
+		require.NoError(t, err)
+		require.NoError(t, p.Route(ctx, "web-v1-80", "web-v2-80", 50))
+		ts := &unstructured.Unstructured{}
+		ts.SetAPIVersion("split.smi-spec.io/v1alpha2")
+		ts.SetKind("TrafficSplit")
+		require.NoError(t, cli.Get(ctx, key, ts))
+		service, _, _ := unstructured.NestedString(ts.Object, "spec", "service")
+		assert.Equal(t, "web", service)
+		backends, _, _ := unstructured.NestedSlice(ts.Object, "spec", "backends")
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"service": "web-v1-80", "weight": int64(50)},
+			map[string]interface{}{"service": "web-v2-80", "weight": int64(50)},
+		}, backends)
+	})
+
+	t.Run("gateway api", func(t *testing.T) {
+		cli := fake.NewFakeClientWithScheme(scheme)
+		routing.Provider = v1alpha1.GatewayAPITrafficProvider
+		routing.Hosts = []string{"web.example.com"}
+		p, err := NewProvider(cli, routing, "default", owner)
+		require.NoError(t, err)
+		require.NoError(t, p.Route(ctx, "web-v1-80", "web-v2-80", 100))
+		route := &unstructured.Unstructured{}
+		route.SetAPIVersion("gateway.networking.k8s.io/v1alpha2")
+		route.SetKind("HTTPRoute")
+		require.NoError(t, cli.Get(ctx, key, route))
+		parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+		assert.Equal(t, []interface{}{map[string]interface{}{"namespace": "gateway-ns", "name": "gateway"}}, parentRefs)
+		hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+		assert.Equal(t, []string{"web.example.com"}, hostnames)
+		rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+		require.Len(t, rules, 1)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"name": "web-v1-80", "port": int64(80), "weight": int64(0)},
+			map[string]interface{}{"name": "web-v2-80", "port": int64(80), "weight": int64(100)},
+		}, rules[0].(map[string]interface{})["backendRefs"])
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		routing.Provider = "linkerd"
+		_, err := NewProvider(fake.NewFakeClientWithScheme(scheme), routing, "default", owner)
+		assert.Error(t, err)
+	})
+}
+
+func TestWorkloadService(t *testing.T) {
+	workload := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "web-v2",
+			"namespace": "default",
+			"labels":    map[string]interface{}{oam.LabelAppComponent: "web"},
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "web", "version": "v2"},
+			},
+		},
+	}}
+	svc, err := WorkloadService(workload, 8080)
+	require.NoError(t, err)
+	assert.Equal(t, "web-v2-8080", svc.Name)
+	assert.Equal(t, "default", svc.Namespace)
+	assert.Equal(t, map[string]string{"app": "web", "version": "v2"}, svc.Spec.Selector)
+	assert.Equal(t, []corev1.ServicePort{{Protocol: corev1.ProtocolTCP, Port: 8080}}, svc.Spec.Ports)
+	assert.Equal(t, "web", svc.Labels[oam.LabelAppComponent])
+
+	unstructured.RemoveNestedField(workload.Object, "spec", "selector")
+	_, err = WorkloadService(workload, 8080)
+	assert.Error(t, err)
+}
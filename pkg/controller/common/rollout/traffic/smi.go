@@ -0,0 +1,51 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/oam-dev/kubevela/pkg/utils/apply"
+)
+
+// smiProvider splits the traffic by a service mesh interface TrafficSplit
+type smiProvider struct {
+	router
+}
+
+// Route applies the TrafficSplit splitting the traffic of the service to the source and the target by weight
+func (p *smiProvider) Route(ctx context.Context, source, target string, weight int32) error {
+	ts := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "split.smi-spec.io/v1alpha2",
+		"kind":       "TrafficSplit",
+		"spec": map[string]interface{}{
+			"service": p.routing.Service,
+			"backends": []interface{}{
+				map[string]interface{}{"service": source, "weight": int64(100 - weight)},
+				map[string]interface{}{"service": target, "weight": int64(weight)},
+			},
+		},
+	}}
+	meta := p.objectMeta()
+	ts.SetName(meta.Name)
+	ts.SetNamespace(meta.Namespace)
+	ts.SetOwnerReferences(meta.OwnerReferences)
+	return errors.Wrap(apply.NewApplicator(p.client).Apply(ctx, ts), "cannot apply the smi TrafficSplit")
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/pkg/controller/common/rollout/traffic"
+)
+
+type fakeTrafficProvider struct {
+	routes []string
+	weight int32
+}
+
+func (p *fakeTrafficProvider) Route(_ context.Context, source, target string, weight int32) error {
+	p.routes = []string{source, target}
+	p.weight = weight
+	return nil
+}
+
+func TestShiftTraffic(t *testing.T) {
+	workload := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": name}},
+			},
+		}}
+	}
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cli := fake.NewFakeClientWithScheme(scheme)
+	provider := &fakeTrafficProvider{}
+	r := &Controller{
+		client:           cli,
+		recorder:         event.NewNopRecorder(),
+		parentController: &v1beta1.AppRollout{ObjectMeta: metav1.ObjectMeta{Name: "rollout", UID: "uid"}},
+		rolloutSpec: &v1alpha1.RolloutPlan{
+			TrafficRouting: &v1alpha1.TrafficRouting{Provider: v1alpha1.IstioTrafficProvider, Service: "web", Port: 80},
+			RolloutBatches: []v1alpha1.RolloutBatch{{TrafficWeight: pointer.Int32Ptr(30)}, {}},
+		},
+		rolloutStatus:  &v1alpha1.RolloutStatus{},
+		sourceWorkload: workload("web-v1"),
+		targetWorkload: workload("web-v2"),
+		newTrafficProvider: func(client.Client, v1alpha1.TrafficRouting, string, metav1.OwnerReference) (traffic.Provider, error) {
+			return provider, nil
+		},
+	}
+	if err := r.verifyTrafficRouting(); err != nil {
+		t.Errorf("want the traffic routing verified, got %v", err)
+	}
+
+	if err := r.shiftBatchTraffic(context.Background()); err != nil {
+		t.Fatalf("shiftBatchTraffic() error = %v", err)
+	}
+	if diff := cmp.Diff([]string{"web-v1-80", "web-v2-80"}, provider.routes); diff != "" {
+		t.Errorf("routes mismatch (-want +got):\n%s", diff)
+	}
+	if provider.weight != 30 || r.rolloutStatus.TrafficWeight == nil || *r.rolloutStatus.TrafficWeight != 30 {
+		t.Errorf("want 30%% of the traffic shifted, got provider weight %d status %v", provider.weight, r.rolloutStatus.TrafficWeight)
+	}
+	svc := &corev1.Service{}
+	if err := cli.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "web-v2-80"}, svc); err != nil {
+		t.Fatalf("want the service of the target created, got %v", err)
+	}
+	if len(svc.OwnerReferences) != 1 || svc.OwnerReferences[0].UID != "uid" {
+		t.Errorf("want the service owned by the rollout, got %v", svc.OwnerReferences)
+	}
+
+	// the batch without traffic weight keeps the traffic
+	r.rolloutStatus.CurrentBatch = 1
+	provider.weight = -1
+	if err := r.shiftBatchTraffic(context.Background()); err != nil || provider.weight != -1 {
+		t.Errorf("want the traffic kept, got weight %d err %v", provider.weight, err)
+	}
+
+	r.targetWorkload = workload("web-v1")
+	if err := r.verifyTrafficRouting(); err == nil {
+		t.Error("want the traffic routing of the same workload rejected")
+	}
+
+	// the workloads without the revision in their selectors select the pods of each other
+	sameSelector := func(name string) *unstructured.Unstructured {
+		w := workload(name)
+		_ = unstructured.SetNestedStringMap(w.Object, map[string]string{"app.oam.dev/component": "web"},
+			"spec", "selector", "matchLabels")
+		return w
+	}
+	r.sourceWorkload, r.targetWorkload = sameSelector("web-v1"), sameSelector("web-v2")
+	if err := r.verifyTrafficRouting(); err == nil {
+		t.Error("want the traffic routing of the workloads with identical selectors rejected")
+	}
+	_ = unstructured.SetNestedStringMap(r.targetWorkload.Object, map[string]string{
+		"app.oam.dev/component": "web", "app.oam.dev/appRevision": "web-v2"}, "spec", "selector", "matchLabels")
+	if err := r.verifyTrafficRouting(); err == nil {
+		t.Error("want the traffic routing rejected if the source selects the pods of the target")
+	}
+	_ = unstructured.SetNestedStringMap(r.sourceWorkload.Object, map[string]string{
+		"app.oam.dev/component": "web", "app.oam.dev/appRevision": "web-v1"}, "spec", "selector", "matchLabels")
+	if err := r.verifyTrafficRouting(); err != nil {
+		t.Errorf("want the traffic routing of the workloads with revision selectors verified, got %v", err)
+	}
+}
+
+func TestShiftTrafficOfApplication(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cli := fake.NewFakeClientWithScheme(scheme)
+	workload := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": name}},
+			},
+		}}
+	}
+	// the AppRollout of the rollout plan of an application is constructed in memory and controlled by the application
+	app := &v1beta1.Application{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "app-uid"}}
+	appOwner := metav1.NewControllerRef(app, v1beta1.ApplicationKindVersionKind)
+	var providerOwner metav1.OwnerReference
+	r := &Controller{
+		client:   cli,
+		recorder: event.NewNopRecorder(),
+		parentController: &v1beta1.AppRollout{ObjectMeta: metav1.ObjectMeta{Name: app.Name, Namespace: app.Namespace,
+			UID: app.UID, OwnerReferences: []metav1.OwnerReference{*appOwner}}},
+		rolloutSpec: &v1alpha1.RolloutPlan{
+			TrafficRouting: &v1alpha1.TrafficRouting{Provider: v1alpha1.IstioTrafficProvider, Service: "web", Port: 80},
+		},
+		rolloutStatus:  &v1alpha1.RolloutStatus{},
+		sourceWorkload: workload("web-v1"),
+		targetWorkload: workload("web-v2"),
+		newTrafficProvider: func(_ client.Client, _ v1alpha1.TrafficRouting, _ string, owner metav1.OwnerReference) (traffic.Provider, error) {
+			providerOwner = owner
+			return &fakeTrafficProvider{}, nil
+		},
+	}
+	if err := r.shiftTraffic(context.Background(), 50); err != nil {
+		t.Fatalf("shiftTraffic() error = %v", err)
+	}
+	if diff := cmp.Diff(*appOwner, providerOwner); diff != "" {
+		t.Errorf("want the route owned by the application (-want +got):\n%s", diff)
+	}
+	for _, name := range []string{"web-v1-80", "web-v2-80"} {
+		svc := &corev1.Service{}
+		if err := cli.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: name}, svc); err != nil {
+			t.Fatalf("want the service %s created, got %v", name, err)
+		}
+		if diff := cmp.Diff([]metav1.OwnerReference{*appOwner}, svc.OwnerReferences); diff != "" {
+			t.Errorf("want the service %s owned by the application (-want +got):\n%s", name, diff)
+		}
+	}
+}
@@ -150,3 +150,13 @@ func getStatefulSetReplicas(statefulSet *apps.StatefulSet) int32 {
 	}
 	return 1
 }
+
+// upgradedReadyReplicas estimates the number of upgraded pods that are ready for a StatefulSet whose status doesn't
+// count them, all the pods not ready yet are regarded as upgraded ones
+func upgradedReadyReplicas(replicas, updatedReplicas, readyReplicas int32) int32 {
+	notReady := replicas - readyReplicas
+	if updatedReplicas <= notReady {
+		return 0
+	}
+	return updatedReplicas - notReady
+}
@@ -18,13 +18,19 @@ package workloads
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/crossplane/crossplane-runtime/pkg/event"
 	kruise "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"github.com/pkg/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
 	"github.com/oam-dev/kubevela/pkg/oam"
 )
@@ -104,3 +110,105 @@ func (c *cloneSetController) fetchCloneSet(ctx context.Context) error {
 	c.cloneSet = &workload
 	return nil
 }
+
+// checkInPlaceBatch checks if enough pods are upgraded and ready in the current batch of a workload upgraded in place,
+// the pods of all the batches are counted against the size of the workload
+func (c *workloadController) checkInPlaceBatch(size, readyPodCount int32) (bool, error) {
+	if len(c.rolloutSpec.RolloutBatches) <= int(c.rolloutStatus.CurrentBatch) {
+		err := errors.New("somehow, currentBatch number exceeded the rolloutBatches spec")
+		klog.ErrorS(err, "total batch", len(c.rolloutSpec.RolloutBatches), "current batch",
+			c.rolloutStatus.CurrentBatch)
+		return false, err
+	}
+	newPodTarget := calculateNewBatchTarget(c.rolloutSpec, 0, int(size), int(c.rolloutStatus.CurrentBatch))
+	currentBatch := c.rolloutSpec.RolloutBatches[c.rolloutStatus.CurrentBatch]
+	unavail := 0
+	if currentBatch.MaxUnavailable != nil {
+		unavail, _ = intstr.GetValueFromIntOrPercent(currentBatch.MaxUnavailable, int(size), true)
+	}
+	klog.InfoS("checking the rolling out progress", "current batch", c.rolloutStatus.CurrentBatch,
+		"new pod count target", newPodTarget, "new ready pod count", readyPodCount,
+		"max unavailable pod allowed", unavail)
+	c.rolloutStatus.UpgradedReadyReplicas = readyPodCount
+	// we could overshoot in the revert case when many pods are already upgraded
+	if unavail+int(readyPodCount) >= newPodTarget {
+		// record the successful upgrade
+		klog.InfoS("all pods in current batch are ready", "current batch", c.rolloutStatus.CurrentBatch)
+		c.recorder.Event(c.parentController, event.Normal("Batch Available",
+			fmt.Sprintf("Batch %d is available", c.rolloutStatus.CurrentBatch)))
+		return true, nil
+	}
+	// continue to verify
+	klog.InfoS("the batch is not ready yet", "current batch", c.rolloutStatus.CurrentBatch)
+	c.rolloutStatus.RolloutRetry("the batch is not ready yet")
+	return false, nil
+}
+
+// finalizeInPlaceBatch makes sure that the upgradedReplicas and current batch in the status are valid according to
+// the spec for a workload upgraded in place
+func (c *workloadController) finalizeInPlaceBatch() (bool, error) {
+	status := c.rolloutStatus
+	spec := c.rolloutSpec
+	if spec.BatchPartition != nil && *spec.BatchPartition < status.CurrentBatch {
+		err := fmt.Errorf("the current batch value in the status is greater than the batch partition")
+		klog.ErrorS(err, "we have moved past the user defined partition", "user specified batch partition",
+			*spec.BatchPartition, "current batch we are working on", status.CurrentBatch)
+		return false, err
+	}
+	upgradedReplicas := int(status.UpgradedReplicas)
+	currentBatch := int(status.CurrentBatch)
+	// calculate the lower bound of the possible pod count just before the current batch
+	podCount := calculateNewBatchTarget(spec, 0, int(status.RolloutTargetSize), currentBatch-1)
+	// the recorded number should be at least as much as the all the pods before the current batch
+	if podCount > upgradedReplicas {
+		err := fmt.Errorf("the upgraded replica in the status is less than all the pods in the previous batch")
+		klog.ErrorS(err, "rollout status inconsistent", "upgraded num status", upgradedReplicas,
+			"pods in all the previous batches", podCount)
+		return false, err
+	}
+	// calculate the upper bound with the current batch
+	podCount = calculateNewBatchTarget(spec, 0, int(status.RolloutTargetSize), currentBatch)
+	// the recorded number should be not as much as the all the pods including the active batch
+	if podCount < upgradedReplicas {
+		err := fmt.Errorf("the upgraded replica in the status is greater than all the pods in the current batch")
+		klog.ErrorS(err, "rollout status inconsistent", "total target size", status.RolloutTargetSize,
+			"upgraded num status", upgradedReplicas, "pods in the batches including the current batch", podCount)
+		return false, err
+	}
+	return true, nil
+}
+
+// verifyInPlaceBatches checks if the replicas in all the rollout batches add up to the size of a workload upgraded
+// in place, the rollout plan can't scale the workload at the same time
+func (c *workloadController) verifyInPlaceBatches(kind string, currentReplicas int32) error {
+	// the target size has to be the same as the workload size
+	if c.rolloutSpec.TargetSize != nil && *c.rolloutSpec.TargetSize != currentReplicas {
+		return fmt.Errorf("the rollout plan is attempting to scale the %s, target = %d, %s size = %d",
+			kind, *c.rolloutSpec.TargetSize, kind, currentReplicas)
+	}
+	// use a common function to check if the sum of all the batches can match the workload size
+	return verifyBatchesWithRollout(c.rolloutSpec, currentReplicas)
+}
+
+// isOwnedByRollout checks if the workload is already controlled by the rollout
+func isOwnedByRollout(obj metav1.Object) bool {
+	controller := metav1.GetControllerOf(obj)
+	return controller != nil && controller.Kind == v1beta1.AppRolloutKind &&
+		controller.APIVersion == v1beta1.SchemeGroupVersion.String()
+}
+
+// removeRolloutOwner removes the rollout from the owner list of the workload, it returns false if the rollout is
+// not an owner
+func removeRolloutOwner(obj metav1.Object) bool {
+	var newOwnerList []metav1.OwnerReference
+	isOwner := false
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.Kind == v1beta1.AppRolloutKind && owner.APIVersion == v1beta1.SchemeGroupVersion.String() {
+			isOwner = true
+			continue
+		}
+		newOwnerList = append(newOwnerList, owner)
+	}
+	obj.SetOwnerReferences(newOwnerList)
+	return isOwner
+}
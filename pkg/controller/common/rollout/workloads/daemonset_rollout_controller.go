@@ -0,0 +1,346 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloads
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+// daemonSetTemplateGenerationKey is the label of the DaemonSet pods recording the template generation they are
+// created from, it's compared with the DeprecatedTemplateGeneration annotation of the DaemonSet
+const daemonSetTemplateGenerationKey = "pod-template-generation"
+
+// DaemonSetRolloutController is responsible for handle rollout DaemonSet type of workloads.
+// The DaemonSet is paused by the OnDelete update strategy before the rollout, and the pods on a batch of nodes
+// are upgraded by deleting their old pods, no more than the max unavailable of the batch at the same time.
+type DaemonSetRolloutController struct {
+	workloadController
+	targetNamespacedName types.NamespacedName
+	daemonSet            *apps.DaemonSet
+}
+
+// NewDaemonSetRolloutController creates a new DaemonSet rollout controller
+func NewDaemonSetRolloutController(client client.Client, recorder event.Recorder, parentController oam.Object,
+	rolloutSpec *v1alpha1.RolloutPlan, rolloutStatus *v1alpha1.RolloutStatus, workloadName types.NamespacedName) *DaemonSetRolloutController {
+	return &DaemonSetRolloutController{
+		workloadController: workloadController{
+			client:           client,
+			recorder:         recorder,
+			parentController: parentController,
+			rolloutSpec:      rolloutSpec,
+			rolloutStatus:    rolloutStatus,
+		},
+		targetNamespacedName: workloadName,
+	}
+}
+
+// VerifySpec verifies that the target DaemonSet is consistent with the rollout spec
+func (d *DaemonSetRolloutController) VerifySpec(ctx context.Context) (bool, error) {
+	var verifyErr error
+	defer func() {
+		if verifyErr != nil {
+			klog.Error(verifyErr)
+			d.recorder.Event(d.parentController, event.Warning("VerifyFailed", verifyErr))
+		}
+	}()
+
+	// fetch the DaemonSet and get the number of nodes it runs on
+	currentReplicas, verifyErr := d.size(ctx)
+	if verifyErr != nil {
+		// do not fail the rollout because we can't get the resource
+		d.rolloutStatus.RolloutRetry(verifyErr.Error())
+		// nolint: nilerr
+		return false, nil
+	}
+	if d.daemonSet.Status.ObservedGeneration < d.daemonSet.Generation {
+		verifyErr = fmt.Errorf("the DaemonSet %s status is not updated yet", d.daemonSet.GetName())
+		d.rolloutStatus.RolloutRetry(verifyErr.Error())
+		return false, nil
+	}
+
+	// make sure that the template generation is different from what we have already done
+	targetGeneration := d.daemonSet.GetAnnotations()[apps.DeprecatedTemplateGeneration]
+	if targetGeneration == d.rolloutStatus.LastAppliedPodTemplateIdentifier {
+		return false, fmt.Errorf("there is no difference between the source and target, template generation = %s",
+			targetGeneration)
+	}
+
+	// check if the rollout batch replicas added up to the number of nodes
+	if verifyErr = d.verifyInPlaceBatches("DaemonSet", currentReplicas); verifyErr != nil {
+		return false, verifyErr
+	}
+
+	// record the size
+	klog.InfoS("record the target size", "total replicas", currentReplicas)
+	d.rolloutStatus.RolloutTargetSize = currentReplicas
+	d.rolloutStatus.RolloutOriginalSize = currentReplicas
+
+	// check if the DaemonSet is paused by the update strategy
+	if d.daemonSet.Spec.UpdateStrategy.Type != apps.OnDeleteDaemonSetStrategyType {
+		return false, fmt.Errorf("the DaemonSet %s is in the middle of updating, need to be paused by the %s "+
+			"update strategy first", d.daemonSet.GetName(), apps.OnDeleteDaemonSetStrategyType)
+	}
+
+	// check if the DaemonSet has any controller
+	if controller := metav1.GetControllerOf(d.daemonSet); controller != nil {
+		return false, fmt.Errorf("the DaemonSet %s has a controller owner %s",
+			d.daemonSet.GetName(), controller.String())
+	}
+
+	// mark the rollout verified
+	d.recorder.Event(d.parentController, event.Normal("Rollout Verified",
+		"Rollout spec and the DaemonSet resource are verified"))
+	// record the new template generation only if it succeeds
+	d.rolloutStatus.NewPodTemplateIdentifier = targetGeneration
+	return true, nil
+}
+
+// Initialize makes sure that the DaemonSet is under our control
+func (d *DaemonSetRolloutController) Initialize(ctx context.Context) (bool, error) {
+	if err := d.fetchDaemonSet(ctx); err != nil {
+		d.rolloutStatus.RolloutRetry(err.Error())
+		// nolint: nilerr
+		return false, nil
+	}
+	if isOwnedByRollout(d.daemonSet) {
+		// it's already there
+		return true, nil
+	}
+	// add the parent controller to the owner of the DaemonSet
+	dsPatch := client.MergeFrom(d.daemonSet.DeepCopyObject())
+	ref := metav1.NewControllerRef(d.parentController, v1beta1.AppRolloutKindVersionKind)
+	d.daemonSet.SetOwnerReferences(append(d.daemonSet.GetOwnerReferences(), *ref))
+	if err := d.client.Patch(ctx, d.daemonSet, dsPatch, client.FieldOwner(d.parentController.GetUID())); err != nil {
+		d.recorder.Event(d.parentController, event.Warning("Failed to the start the DaemonSet update", err))
+		d.rolloutStatus.RolloutRetry(err.Error())
+		return false, nil
+	}
+	// mark the rollout initialized
+	d.recorder.Event(d.parentController, event.Normal("Rollout Initialized", "Rollout resource are initialized"))
+	return true, nil
+}
+
+// RolloutOneBatchPods calculates the number of nodes we can upgrade once according to the rollout spec and deletes
+// the old pods on them, so that the DaemonSet creates the new ones. The pods are deleted in several rounds if
+// deleting them at once exceeds the max unavailable of the batch.
+func (d *DaemonSetRolloutController) RolloutOneBatchPods(ctx context.Context) (bool, error) {
+	dsSize, err := d.size(ctx)
+	if err != nil {
+		d.rolloutStatus.RolloutRetry(err.Error())
+		// nolint: nilerr
+		return false, nil
+	}
+	pods, err := d.listPods(ctx)
+	if err != nil {
+		d.rolloutStatus.RolloutRetry(err.Error())
+		// nolint: nilerr
+		return false, nil
+	}
+	newPodTarget := calculateNewBatchTarget(d.rolloutSpec, 0, int(dsSize), int(d.rolloutStatus.CurrentBatch))
+
+	upgraded, unavailable := 0, 0
+	var oldPods []*corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil {
+			// the old pod is being deleted, its node will run the new pod soon
+			upgraded++
+			unavailable++
+			continue
+		}
+		if d.isUpgraded(pod) {
+			upgraded++
+		} else {
+			oldPods = append(oldPods, pod)
+		}
+		if !isPodReady(pod) {
+			unavailable++
+		}
+	}
+	maxUnavailable := newPodTarget
+	currentBatch := d.rolloutSpec.RolloutBatches[d.rolloutStatus.CurrentBatch]
+	if currentBatch.MaxUnavailable != nil {
+		maxUnavailable, _ = intstr.GetValueFromIntOrPercent(currentBatch.MaxUnavailable, int(dsSize), true)
+	}
+	// delete the pods not ready first since deleting them doesn't make more pods unavailable
+	sort.SliceStable(oldPods, func(i, j int) bool {
+		return !isPodReady(oldPods[i]) && isPodReady(oldPods[j])
+	})
+	deleted := 0
+	for _, pod := range oldPods {
+		if upgraded >= newPodTarget {
+			break
+		}
+		ready := isPodReady(pod)
+		if ready && unavailable >= maxUnavailable {
+			break
+		}
+		if err := d.client.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			d.recorder.Event(d.parentController, event.Warning("Failed to delete the DaemonSet pod to upgrade", err))
+			d.rolloutStatus.RolloutRetry(err.Error())
+			return false, nil
+		}
+		deleted++
+		upgraded++
+		if ready {
+			unavailable++
+		}
+	}
+	// the nodes without an old pod run the new pod directly
+	if upgraded < newPodTarget && deleted < len(oldPods) {
+		klog.InfoS("wait for the unavailable pods to delete more old pods", "current batch", d.rolloutStatus.CurrentBatch,
+			"new pod target", newPodTarget, "upgraded pods", upgraded, "unavailable pods", unavailable,
+			"max unavailable pod allowed", maxUnavailable)
+		d.rolloutStatus.RolloutRetry("waiting for the unavailable pods to upgrade more pods in the batch")
+		return false, nil
+	}
+	// record the upgrade
+	klog.InfoS("upgraded one batch", "current batch", d.rolloutStatus.CurrentBatch)
+	d.recorder.Event(d.parentController, event.Normal("Batch Rollout",
+		fmt.Sprintf("Submitted upgrade quest for batch %d", d.rolloutStatus.CurrentBatch)))
+	d.rolloutStatus.UpgradedReplicas = int32(newPodTarget)
+	return true, nil
+}
+
+// CheckOneBatchPods checks to see if enough pods are upgraded according to the rollout plan
+func (d *DaemonSetRolloutController) CheckOneBatchPods(ctx context.Context) (bool, error) {
+	if err := d.fetchDaemonSet(ctx); err != nil {
+		d.rolloutStatus.RolloutRetry(err.Error())
+		// nolint: nilerr
+		return false, nil
+	}
+	pods, err := d.listPods(ctx)
+	if err != nil {
+		d.rolloutStatus.RolloutRetry(err.Error())
+		// nolint: nilerr
+		return false, nil
+	}
+	var readyPodCount int32
+	for i := range pods {
+		if pods[i].DeletionTimestamp == nil && d.isUpgraded(&pods[i]) && isPodReady(&pods[i]) {
+			readyPodCount++
+		}
+	}
+	return d.checkInPlaceBatch(d.daemonSet.Status.DesiredNumberScheduled, readyPodCount)
+}
+
+// FinalizeOneBatch makes sure that the upgradedReplicas and current batch in the status are valid according to the spec
+func (d *DaemonSetRolloutController) FinalizeOneBatch(ctx context.Context) (bool, error) {
+	return d.finalizeInPlaceBatch()
+}
+
+// Finalize makes sure the DaemonSet is all upgraded, the DaemonSet is resumed to upgrade the pods by itself only if
+// the rollout succeeds
+func (d *DaemonSetRolloutController) Finalize(ctx context.Context, succeed bool) bool {
+	if err := d.fetchDaemonSet(ctx); err != nil {
+		d.rolloutStatus.RolloutRetry(err.Error())
+		return false
+	}
+	dsPatch := client.MergeFrom(d.daemonSet.DeepCopyObject())
+	// remove the parent controller from the resources' owner list
+	if !removeRolloutOwner(d.daemonSet) {
+		// nothing to do if we are already not the owner
+		klog.InfoS("the DaemonSet is already released and not controlled by rollout", "DaemonSet", d.daemonSet.Name)
+		return true
+	}
+	if succeed {
+		d.daemonSet.Spec.UpdateStrategy = apps.DaemonSetUpdateStrategy{Type: apps.RollingUpdateDaemonSetStrategyType}
+	}
+	if err := d.client.Patch(ctx, d.daemonSet, dsPatch, client.FieldOwner(d.parentController.GetUID())); err != nil {
+		d.recorder.Event(d.parentController, event.Warning("Failed to the finalize the DaemonSet", err))
+		d.rolloutStatus.RolloutRetry(err.Error())
+		return false
+	}
+	// mark the resource finalized
+	d.recorder.Event(d.parentController, event.Normal("Rollout Finalized",
+		fmt.Sprintf("Rollout resource are finalized, succeed := %t", succeed)))
+	d.rolloutStatus.LastAppliedPodTemplateIdentifier = d.rolloutStatus.NewPodTemplateIdentifier
+	return true
+}
+
+// size returns the number of nodes that should run the DaemonSet pod
+func (d *DaemonSetRolloutController) size(ctx context.Context) (int32, error) {
+	if d.daemonSet == nil {
+		if err := d.fetchDaemonSet(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return d.daemonSet.Status.DesiredNumberScheduled, nil
+}
+
+func (d *DaemonSetRolloutController) fetchDaemonSet(ctx context.Context) error {
+	workload := apps.DaemonSet{}
+	if err := d.client.Get(ctx, d.targetNamespacedName, &workload); err != nil {
+		if !apierrors.IsNotFound(err) {
+			d.recorder.Event(d.parentController, event.Warning("Failed to get the DaemonSet", err))
+		}
+		return err
+	}
+	d.daemonSet = &workload
+	return nil
+}
+
+// listPods lists the pods controlled by the DaemonSet
+func (d *DaemonSetRolloutController) listPods(ctx context.Context) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(d.daemonSet.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	podList := &corev1.PodList{}
+	if err := d.client.List(ctx, podList, client.InNamespace(d.daemonSet.Namespace),
+		client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	pods := make([]corev1.Pod, 0, len(podList.Items))
+	for i := range podList.Items {
+		if metav1.IsControlledBy(&podList.Items[i], d.daemonSet) {
+			pods = append(pods, podList.Items[i])
+		}
+	}
+	return pods, nil
+}
+
+// isUpgraded checks if the pod is created from the current template of the DaemonSet
+func (d *DaemonSetRolloutController) isUpgraded(pod *corev1.Pod) bool {
+	generation := d.daemonSet.GetAnnotations()[apps.DeprecatedTemplateGeneration]
+	return len(generation) != 0 && pod.GetLabels()[daemonSetTemplateGenerationKey] == generation
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloads
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/google/go-cmp/cmp"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+)
+
+func TestDaemonSetRollout(t *testing.T) {
+	ctx := context.Background()
+	name := types.NamespacedName{Namespace: "default", Name: "agent"}
+	labels := map[string]string{"app": "agent"}
+	ds := &apps.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: name.Namespace, Name: name.Name, UID: "ds-uid",
+			Annotations: map[string]string{apps.DeprecatedTemplateGeneration: "2"}},
+		Spec: apps.DaemonSetSpec{
+			Selector:       &metav1.LabelSelector{MatchLabels: labels},
+			UpdateStrategy: apps.DaemonSetUpdateStrategy{Type: apps.OnDeleteDaemonSetStrategyType},
+		},
+		Status: apps.DaemonSetStatus{DesiredNumberScheduled: 4},
+	}
+	newPod := func(node, generation string, ready bool) *corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: name.Namespace, Name: fmt.Sprintf("agent-%s-%s", node, generation),
+				Labels: map[string]string{"app": "agent", daemonSetTemplateGenerationKey: generation},
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "DaemonSet", Name: name.Name,
+					UID: ds.UID, Controller: pointer.BoolPtr(true)}}},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+		}
+	}
+	rollout := &v1beta1.AppRollout{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "rollout", UID: "uid"}}
+	maxUnavailable := intstr.FromInt(1)
+	rolloutSpec := &v1alpha1.RolloutPlan{
+		RolloutBatches: []v1alpha1.RolloutBatch{
+			{Replicas: intstr.FromInt(2), MaxUnavailable: &maxUnavailable},
+			{Replicas: intstr.FromInt(2)},
+		},
+	}
+	rolloutStatus := &v1alpha1.RolloutStatus{LastAppliedPodTemplateIdentifier: "1"}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, ds, newPod("n1", "1", true), newPod("n2", "1", true),
+		newPod("n3", "1", false), newPod("n4", "1", true))
+	controller := NewDaemonSetRolloutController(c, event.NewNopRecorder(), rollout, rolloutSpec, rolloutStatus, name)
+
+	podNames := func() []string {
+		pods := &corev1.PodList{}
+		if err := c.List(ctx, pods, client.InNamespace(name.Namespace)); err != nil {
+			t.Fatalf("List(...): unexpected error %v", err)
+		}
+		var names []string
+		for _, pod := range pods.Items {
+			names = append(names, pod.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	if verified, err := controller.VerifySpec(ctx); !verified || err != nil {
+		t.Fatalf("VerifySpec(...): want verified, got %t, %v", verified, err)
+	}
+	if initialized, err := controller.Initialize(ctx); !initialized || err != nil {
+		t.Fatalf("Initialize(...): want initialized, got %t, %v", initialized, err)
+	}
+
+	// the pod not ready is deleted first, then no more ready pod can be deleted since one pod is unavailable
+	if done, err := controller.RolloutOneBatchPods(ctx); done || err != nil {
+		t.Fatalf("RolloutOneBatchPods(...): want not done, got %t, %v", done, err)
+	}
+	if diff := cmp.Diff([]string{"agent-n1-1", "agent-n2-1", "agent-n4-1"}, podNames()); diff != "" {
+		t.Errorf("RolloutOneBatchPods(...): -want , +got \n%s\n", diff)
+	}
+	// the new pod is created but not ready yet
+	upgraded := newPod("n3", "2", false)
+	_ = c.Create(ctx, upgraded)
+	if done, err := controller.RolloutOneBatchPods(ctx); done || err != nil {
+		t.Fatalf("RolloutOneBatchPods(...): want not done, got %t, %v", done, err)
+	}
+	if ready, err := controller.CheckOneBatchPods(ctx); ready || err != nil {
+		t.Errorf("CheckOneBatchPods(...): want not ready, got %t, %v", ready, err)
+	}
+	// one more old pod is deleted after the new pod is ready
+	upgraded.Status.Conditions[0].Status = corev1.ConditionTrue
+	_ = c.Update(ctx, upgraded)
+	if done, err := controller.RolloutOneBatchPods(ctx); !done || err != nil {
+		t.Fatalf("RolloutOneBatchPods(...): want done, got %t, %v", done, err)
+	}
+	if diff := cmp.Diff([]string{"agent-n2-1", "agent-n3-2", "agent-n4-1"}, podNames()); diff != "" {
+		t.Errorf("RolloutOneBatchPods(...): -want , +got \n%s\n", diff)
+	}
+	_ = c.Create(ctx, newPod("n1", "2", true))
+	if ready, err := controller.CheckOneBatchPods(ctx); !ready || err != nil {
+		t.Errorf("CheckOneBatchPods(...): want ready, got %t, %v", ready, err)
+	}
+
+	if !controller.Finalize(ctx, true) {
+		t.Fatalf("Finalize(...): want finalized")
+	}
+	got := &apps.DaemonSet{}
+	_ = c.Get(ctx, name, got)
+	if diff := cmp.Diff(apps.RollingUpdateDaemonSetStrategyType, got.Spec.UpdateStrategy.Type); diff != "" {
+		t.Errorf("Finalize(...): -want , +got \n%s\n", diff)
+	}
+	if isOwnedByRollout(got) {
+		t.Errorf("Finalize(...): the DaemonSet should be released")
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloads
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	apps "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+// StatefulSetRolloutController is responsible for handle rollout StatefulSet type of workloads, the StatefulSet is
+// upgraded in place and the pods in each batch are upgraded by moving down the partition of its update strategy
+type StatefulSetRolloutController struct {
+	statefulSetController
+	statefulSet *apps.StatefulSet
+}
+
+// NewStatefulSetRolloutController creates a new StatefulSet rollout controller
+func NewStatefulSetRolloutController(client client.Client, recorder event.Recorder, parentController oam.Object,
+	rolloutSpec *v1alpha1.RolloutPlan, rolloutStatus *v1alpha1.RolloutStatus, workloadName types.NamespacedName) *StatefulSetRolloutController {
+	return &StatefulSetRolloutController{
+		statefulSetController: statefulSetController{
+			workloadController: workloadController{
+				client:           client,
+				recorder:         recorder,
+				parentController: parentController,
+				rolloutSpec:      rolloutSpec,
+				rolloutStatus:    rolloutStatus,
+			},
+			targetNamespacedName: workloadName,
+		},
+	}
+}
+
+// VerifySpec verifies that the target StatefulSet is consistent with the rollout spec
+func (s *StatefulSetRolloutController) VerifySpec(ctx context.Context) (bool, error) {
+	var verifyErr error
+	defer func() {
+		if verifyErr != nil {
+			klog.Error(verifyErr)
+			s.recorder.Event(s.parentController, event.Warning("VerifyFailed", verifyErr))
+		}
+	}()
+
+	// fetch the StatefulSet and get its current size
+	currentReplicas, verifyErr := s.size(ctx)
+	if verifyErr != nil {
+		// do not fail the rollout because we can't get the resource
+		s.rolloutStatus.RolloutRetry(verifyErr.Error())
+		// nolint: nilerr
+		return false, nil
+	}
+
+	// the StatefulSet size has to be the same as the current size
+	if currentReplicas != s.statefulSet.Status.Replicas {
+		verifyErr = fmt.Errorf("the StatefulSet is still scaling, target = %d, StatefulSet size = %d",
+			currentReplicas, s.statefulSet.Status.Replicas)
+		// we can wait for the StatefulSet scale operation to finish
+		s.rolloutStatus.RolloutRetry(verifyErr.Error())
+		return false, nil
+	}
+
+	// make sure that the updateRevision is different from what we have already done
+	targetHash := s.statefulSet.Status.UpdateRevision
+	if targetHash == s.rolloutStatus.LastAppliedPodTemplateIdentifier {
+		return false, fmt.Errorf("there is no difference between the source and target, hash = %s", targetHash)
+	}
+
+	// check if the rollout batch replicas added up to the StatefulSet replicas
+	if verifyErr = s.verifyInPlaceBatches("StatefulSet", currentReplicas); verifyErr != nil {
+		return false, verifyErr
+	}
+
+	// record the size
+	klog.InfoS("record the target size", "total replicas", currentReplicas)
+	s.rolloutStatus.RolloutTargetSize = currentReplicas
+	s.rolloutStatus.RolloutOriginalSize = currentReplicas
+
+	// check if the StatefulSet is held by the partition, no pod is upgraded before the rollout starts
+	if partition := getStatefulSetPartition(s.statefulSet); partition < currentReplicas {
+		return false, fmt.Errorf("the StatefulSet %s is in the middle of updating, partition = %d, "+
+			"need to be paused by the partition first", s.statefulSet.GetName(), partition)
+	}
+
+	// check if the StatefulSet has any controller
+	if controller := metav1.GetControllerOf(s.statefulSet); controller != nil {
+		return false, fmt.Errorf("the StatefulSet %s has a controller owner %s",
+			s.statefulSet.GetName(), controller.String())
+	}
+
+	// mark the rollout verified
+	s.recorder.Event(s.parentController, event.Normal("Rollout Verified",
+		"Rollout spec and the StatefulSet resource are verified"))
+	// record the new pod template hash only if it succeeds
+	s.rolloutStatus.NewPodTemplateIdentifier = targetHash
+	return true, nil
+}
+
+// Initialize makes sure that the StatefulSet is under our control
+func (s *StatefulSetRolloutController) Initialize(ctx context.Context) (bool, error) {
+	totalReplicas, err := s.size(ctx)
+	if err != nil {
+		s.rolloutStatus.RolloutRetry(err.Error())
+		// nolint: nilerr
+		return false, nil
+	}
+	if isOwnedByRollout(s.statefulSet) {
+		// it's already there
+		return true, nil
+	}
+	// add the parent controller to the owner of the StatefulSet
+	// before kicking start the update and start from every pod in the old version
+	stsPatch := client.MergeFrom(s.statefulSet.DeepCopyObject())
+	ref := metav1.NewControllerRef(s.parentController, v1beta1.AppRolloutKindVersionKind)
+	s.statefulSet.SetOwnerReferences(append(s.statefulSet.GetOwnerReferences(), *ref))
+	setStatefulSetPartition(s.statefulSet, totalReplicas)
+
+	// patch the StatefulSet
+	if err := s.client.Patch(ctx, s.statefulSet, stsPatch, client.FieldOwner(s.parentController.GetUID())); err != nil {
+		s.recorder.Event(s.parentController, event.Warning("Failed to the start the StatefulSet update", err))
+		s.rolloutStatus.RolloutRetry(err.Error())
+		return false, nil
+	}
+	// mark the rollout initialized
+	s.recorder.Event(s.parentController, event.Normal("Rollout Initialized", "Rollout resource are initialized"))
+	return true, nil
+}
+
+// RolloutOneBatchPods calculates the number of pods we can upgrade once according to the rollout spec
+// and then set the partition accordingly
+func (s *StatefulSetRolloutController) RolloutOneBatchPods(ctx context.Context) (bool, error) {
+	stsSize, err := s.size(ctx)
+	if err != nil {
+		s.rolloutStatus.RolloutRetry(err.Error())
+		// nolint: nilerr
+		return false, nil
+	}
+
+	newPodTarget := calculateNewBatchTarget(s.rolloutSpec, 0, int(stsSize), int(s.rolloutStatus.CurrentBatch))
+	// set the partition as the desired number of pods in old revision, the pods with ordinal not less than
+	// the partition are upgraded
+	stsPatch := client.MergeFrom(s.statefulSet.DeepCopyObject())
+	setStatefulSetPartition(s.statefulSet, stsSize-int32(newPodTarget))
+	if err = s.client.Patch(ctx, s.statefulSet, stsPatch, client.FieldOwner(s.parentController.GetUID())); err != nil {
+		s.recorder.Event(s.parentController, event.Warning("Failed to update the StatefulSet to upgrade", err))
+		s.rolloutStatus.RolloutRetry(err.Error())
+		return false, nil
+	}
+	// record the upgrade
+	klog.InfoS("upgraded one batch", "current batch", s.rolloutStatus.CurrentBatch)
+	s.recorder.Event(s.parentController, event.Normal("Batch Rollout",
+		fmt.Sprintf("Submitted upgrade quest for batch %d", s.rolloutStatus.CurrentBatch)))
+	s.rolloutStatus.UpgradedReplicas = int32(newPodTarget)
+	return true, nil
+}
+
+// CheckOneBatchPods checks to see if enough pods are upgraded according to the rollout plan
+func (s *StatefulSetRolloutController) CheckOneBatchPods(ctx context.Context) (bool, error) {
+	if err := s.fetchStatefulSet(ctx); err != nil {
+		s.rolloutStatus.RolloutRetry(err.Error())
+		// nolint: nilerr
+		return false, nil
+	}
+	if s.statefulSet.Status.ObservedGeneration < s.statefulSet.Generation {
+		s.rolloutStatus.RolloutRetry("the StatefulSet status is not updated yet")
+		return false, nil
+	}
+	return s.checkInPlaceBatch(getStatefulSetReplicas(s.statefulSet), upgradedReadyReplicas(s.statefulSet.Status.Replicas,
+		s.statefulSet.Status.UpdatedReplicas, s.statefulSet.Status.ReadyReplicas))
+}
+
+// FinalizeOneBatch makes sure that the upgradedReplicas and current batch in the status are valid according to the spec
+func (s *StatefulSetRolloutController) FinalizeOneBatch(ctx context.Context) (bool, error) {
+	return s.finalizeInPlaceBatch()
+}
+
+// Finalize makes sure the StatefulSet is all upgraded
+func (s *StatefulSetRolloutController) Finalize(ctx context.Context, succeed bool) bool {
+	if err := s.fetchStatefulSet(ctx); err != nil {
+		s.rolloutStatus.RolloutRetry(err.Error())
+		return false
+	}
+	stsPatch := client.MergeFrom(s.statefulSet.DeepCopyObject())
+	// remove the parent controller from the resources' owner list
+	if !removeRolloutOwner(s.statefulSet) {
+		// nothing to do if we are already not the owner
+		klog.InfoS("the StatefulSet is already released and not controlled by rollout", "StatefulSet", s.statefulSet.Name)
+		return true
+	}
+	// hold the pods not upgraded yet when the rollout failed so we can try again next time
+	if !succeed {
+		setStatefulSetPartition(s.statefulSet, getStatefulSetReplicas(s.statefulSet))
+	}
+	if err := s.client.Patch(ctx, s.statefulSet, stsPatch, client.FieldOwner(s.parentController.GetUID())); err != nil {
+		s.recorder.Event(s.parentController, event.Warning("Failed to the finalize the StatefulSet", err))
+		s.rolloutStatus.RolloutRetry(err.Error())
+		return false
+	}
+	// mark the resource finalized
+	s.recorder.Event(s.parentController, event.Normal("Rollout Finalized",
+		fmt.Sprintf("Rollout resource are finalized, succeed := %t", succeed)))
+	s.rolloutStatus.LastAppliedPodTemplateIdentifier = s.rolloutStatus.NewPodTemplateIdentifier
+	return true
+}
+
+func (s *StatefulSetRolloutController) size(ctx context.Context) (int32, error) {
+	if s.statefulSet == nil {
+		if err := s.fetchStatefulSet(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return getStatefulSetReplicas(s.statefulSet), nil
+}
+
+func (s *StatefulSetRolloutController) fetchStatefulSet(ctx context.Context) error {
+	workload := apps.StatefulSet{}
+	if err := s.client.Get(ctx, s.targetNamespacedName, &workload); err != nil {
+		if !apierrors.IsNotFound(err) {
+			s.recorder.Event(s.parentController, event.Warning("Failed to get the StatefulSet", err))
+		}
+		return err
+	}
+	s.statefulSet = &workload
+	return nil
+}
+
+// getStatefulSetPartition returns the partition of the StatefulSet, all the pods are upgraded if it's not set
+func getStatefulSetPartition(statefulSet *apps.StatefulSet) int32 {
+	strategy := statefulSet.Spec.UpdateStrategy
+	if strategy.Type == apps.OnDeleteStatefulSetStrategyType {
+		// no pod is upgraded by the StatefulSet controller
+		return getStatefulSetReplicas(statefulSet)
+	}
+	if strategy.RollingUpdate == nil || strategy.RollingUpdate.Partition == nil {
+		return 0
+	}
+	return *strategy.RollingUpdate.Partition
+}
+
+func setStatefulSetPartition(statefulSet *apps.StatefulSet, partition int32) {
+	statefulSet.Spec.UpdateStrategy.Type = apps.RollingUpdateStatefulSetStrategyType
+	if statefulSet.Spec.UpdateStrategy.RollingUpdate == nil {
+		statefulSet.Spec.UpdateStrategy.RollingUpdate = &apps.RollingUpdateStatefulSetStrategy{}
+	}
+	statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition = pointer.Int32Ptr(partition)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloads
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/google/go-cmp/cmp"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+)
+
+func TestStatefulSetRollout(t *testing.T) {
+	ctx := context.Background()
+	name := types.NamespacedName{Namespace: "default", Name: "db"}
+	sts := &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: name.Namespace, Name: name.Name},
+		Spec: apps.StatefulSetSpec{
+			Replicas: pointer.Int32Ptr(5),
+			UpdateStrategy: apps.StatefulSetUpdateStrategy{
+				Type:          apps.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{Partition: pointer.Int32Ptr(math.MaxInt32)},
+			},
+		},
+		Status: apps.StatefulSetStatus{Replicas: 5, ReadyReplicas: 5, UpdatedReplicas: 0, UpdateRevision: "db-v2"},
+	}
+	rollout := &v1beta1.AppRollout{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "rollout", UID: "uid"}}
+	rolloutSpec := &v1alpha1.RolloutPlan{
+		RolloutBatches: []v1alpha1.RolloutBatch{{Replicas: intstr.FromInt(2)}, {Replicas: intstr.FromInt(3)}},
+	}
+	rolloutStatus := &v1alpha1.RolloutStatus{LastAppliedPodTemplateIdentifier: "db-v1"}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, sts)
+	controller := NewStatefulSetRolloutController(c, event.NewNopRecorder(), rollout, rolloutSpec, rolloutStatus, name)
+
+	partition := func() int32 {
+		got := &apps.StatefulSet{}
+		if err := c.Get(ctx, name, got); err != nil {
+			t.Fatalf("Get(...): unexpected error %v", err)
+		}
+		return getStatefulSetPartition(got)
+	}
+
+	if verified, err := controller.VerifySpec(ctx); !verified || err != nil {
+		t.Fatalf("VerifySpec(...): want verified, got %t, %v", verified, err)
+	}
+	if diff := cmp.Diff("db-v2", rolloutStatus.NewPodTemplateIdentifier); diff != "" {
+		t.Errorf("VerifySpec(...): -want , +got \n%s\n", diff)
+	}
+	if initialized, err := controller.Initialize(ctx); !initialized || err != nil {
+		t.Fatalf("Initialize(...): want initialized, got %t, %v", initialized, err)
+	}
+	if diff := cmp.Diff(int32(5), partition()); diff != "" {
+		t.Errorf("Initialize(...): partition -want , +got \n%s\n", diff)
+	}
+	if done, err := controller.RolloutOneBatchPods(ctx); !done || err != nil {
+		t.Fatalf("RolloutOneBatchPods(...): want done, got %t, %v", done, err)
+	}
+	if diff := cmp.Diff(int32(3), partition()); diff != "" {
+		t.Errorf("RolloutOneBatchPods(...): partition -want , +got \n%s\n", diff)
+	}
+
+	// one of the two upgraded pods is not ready yet
+	got := &apps.StatefulSet{}
+	_ = c.Get(ctx, name, got)
+	got.Status.UpdatedReplicas, got.Status.ReadyReplicas = 2, 4
+	_ = c.Status().Update(ctx, got)
+	if ready, err := controller.CheckOneBatchPods(ctx); ready || err != nil {
+		t.Errorf("CheckOneBatchPods(...): want not ready, got %t, %v", ready, err)
+	}
+	got.Status.ReadyReplicas = 5
+	_ = c.Status().Update(ctx, got)
+	if ready, err := controller.CheckOneBatchPods(ctx); !ready || err != nil {
+		t.Errorf("CheckOneBatchPods(...): want ready, got %t, %v", ready, err)
+	}
+
+	if !controller.Finalize(ctx, false) {
+		t.Fatalf("Finalize(...): want finalized")
+	}
+	if diff := cmp.Diff(int32(5), partition()); diff != "" {
+		t.Errorf("Finalize(...): partition -want , +got \n%s\n", diff)
+	}
+	got = &apps.StatefulSet{}
+	_ = c.Get(ctx, name, got)
+	if isOwnedByRollout(got) {
+		t.Errorf("Finalize(...): the StatefulSet should be released")
+	}
+}
+
+func TestUpgradedReadyReplicas(t *testing.T) {
+	cases := map[string]struct {
+		replicas, updated, ready int32
+		want                     int32
+	}{
+		"AllReady":           {replicas: 5, updated: 2, ready: 5, want: 2},
+		"UpgradedNotReady":   {replicas: 5, updated: 2, ready: 4, want: 1},
+		"MoreNotReadyPods":   {replicas: 5, updated: 2, ready: 2, want: 0},
+		"NothingUpgradedYet": {replicas: 5, updated: 0, ready: 5, want: 0},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := upgradedReadyReplicas(tc.replicas, tc.updated, tc.ready); got != tc.want {
+				t.Errorf("upgradedReadyReplicas() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -80,4 +80,11 @@ type Args struct {
 
 	// OAMSpecVer is the oam spec version controller want to setup
 	OAMSpecVer string
+
+	// ClusterProbeInterval is the interval to probe the health of the managed clusters
+	ClusterProbeInterval time.Duration
+
+	// DriftDetectionInterval is the interval to detect the drift of the resources of running applications,
+	// drift detection is disabled if it's zero
+	DriftDetectionInterval time.Duration
 }
@@ -48,7 +48,6 @@ import (
 const (
 	appDeploymentFinalizer = "finalizers.appdeployment.oam.dev"
 	reconcileTimeOut       = 60 * time.Second
-	secretKeyConfig        = "config"
 )
 
 var (
@@ -180,16 +179,7 @@ func (r *Reconciler) getClientForCluster(ctx context.Context, cluster, ns string
 	if err != nil {
 		return nil, err
 	}
-
-	key := client.ObjectKey{
-		Name:      c.Spec.KubeconfigSecretRef.Name,
-		Namespace: ns,
-	}
-	secret := &corev1.Secret{}
-	if err := r.Client.Get(ctx, key, secret); err != nil {
-		return nil, err
-	}
-	return clustermanager.GetClient(secret.Data[secretKeyConfig])
+	return clustermanager.GetClusterClient(ctx, r.Client, c)
 }
 
 func (r *Reconciler) deleteRevisions(ctx context.Context, appd *oamcore.AppDeployment, revisions []*revision) (err error) {
@@ -256,7 +246,7 @@ func (r *Reconciler) applyRevisions(ctx context.Context, appd *oamcore.AppDeploy
 			return err
 		}
 
-		applicator := apply.NewAPIApplicator(kubecli)
+		applicator := apply.NewApplicator(kubecli)
 		for _, wl := range workloads {
 			if err := applicator.Apply(ctx, wl.Object); err != nil {
 				return err
@@ -517,7 +507,7 @@ func (r *Reconciler) applyTraffic(ctx context.Context, appd *oamcore.AppDeployme
 			}
 		}
 
-		applicator := apply.NewAPIApplicator(kubecli)
+		applicator := apply.NewApplicator(kubecli)
 		if err := applicator.Apply(ctx, vsvc); err != nil {
 			return err
 		}
@@ -38,9 +38,11 @@ import (
 	velatypes "github.com/oam-dev/kubevela/apis/types"
 	"github.com/oam-dev/kubevela/pkg/appfile"
 	core "github.com/oam-dev/kubevela/pkg/controller/core.oam.dev"
-	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/application/dispatch"
+	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/applicationrollout"
 	"github.com/oam-dev/kubevela/pkg/controller/utils"
+	"github.com/oam-dev/kubevela/pkg/cue/definition"
 	"github.com/oam-dev/kubevela/pkg/cue/packages"
+	"github.com/oam-dev/kubevela/pkg/monitor/metrics"
 	"github.com/oam-dev/kubevela/pkg/oam"
 	"github.com/oam-dev/kubevela/pkg/oam/discoverymapper"
 	oamutil "github.com/oam-dev/kubevela/pkg/oam/util"
@@ -55,7 +57,9 @@ const (
 
 const (
 	// WorkflowReconcileWaitTime is the time to wait before reconcile again workflow running
-	WorkflowReconcileWaitTime      = time.Second * 3
+	WorkflowReconcileWaitTime = time.Second * 3
+	// GarbageCollectWaitTime is the time to wait before garbage collecting the pending resources again
+	GarbageCollectWaitTime         = time.Second * 5
 	legacyResourceTrackerFinalizer = "resourceTracker.finalizer.core.oam.dev"
 	// resourceTrackerFinalizer is to delete the resource tracker of the latest app revision.
 	resourceTrackerFinalizer = "app.oam.dev/resource-tracker-finalizer"
@@ -74,6 +78,8 @@ type Reconciler struct {
 	applicator           apply.Applicator
 	appRevisionLimit     int
 	concurrentReconciles int
+	// driftDetectionInterval is the interval to detect the drift of running applications, it's disabled if zero
+	driftDetectionInterval time.Duration
 }
 
 // +kubebuilder:rbac:groups=core.oam.dev,resources=applications,verbs=get;list;watch;create;update;patch;delete
@@ -98,8 +104,9 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		})
 	}
 	handler := &AppHandler{
-		r:   r,
-		app: app,
+		r:          r,
+		app:        app,
+		wasRunning: app.Status.Phase == common.ApplicationRunning,
 	}
 	endReconcile, err := r.handleFinalizers(ctx, app)
 	if err != nil {
@@ -120,6 +127,20 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	}
 	app.Status.SetConditions(utils.ReadyCondition("Parsed"))
 	r.Recorder.Event(app, event.Normal(velatypes.ReasonParsed, velatypes.MessageParsed))
+	handler.gcPolicy = appFile.GarbageCollect
+
+	if err := handler.PreparePlacements(ctx, appFile.Topology); err != nil {
+		klog.ErrorS(err, "Failed to select clusters", "application", klog.KObj(app))
+		r.Recorder.Event(app, event.Warning(velatypes.ReasonFailedPlacement, err))
+		return r.endWithNegativeCondition(ctx, app, utils.ErrorCondition("Placement", err))
+	}
+	if err := handler.recordClusters(ctx); err != nil {
+		klog.ErrorS(err, "Failed to record selected clusters", "application", klog.KObj(app))
+		return r.endWithNegativeCondition(ctx, app, utils.ErrorCondition("Placement", err))
+	}
+	if appFile.Topology != nil || app.Status.GetCondition("Placement").Type != "" {
+		app.Status.SetConditions(utils.ReadyCondition("Placement"))
+	}
 
 	if err := handler.PrepareCurrentAppRevision(ctx, appFile); err != nil {
 		klog.ErrorS(err, "Failed to prepare app revision", "application", klog.KObj(app))
@@ -130,12 +151,22 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		"revisionHash", handler.currentRevHash, "isNewRevision", handler.isNewRevision)
 
 	var comps []*velatypes.ComponentManifest
+	renderStart := time.Now()
 	comps, err = appFile.GenerateComponentManifests()
+	metrics.ObserveSince(metrics.ApplicationRenderDuration, renderStart)
 	if err != nil {
+		if definition.IsPolicyViolation(err) {
+			klog.ErrorS(err, "Application violates policies", "application", klog.KObj(app))
+			r.Recorder.Event(app, event.Warning(velatypes.ReasonFailedPolicy, err))
+			return r.endWithNegativeCondition(ctx, app, utils.ErrorCondition("Policy", err))
+		}
 		klog.ErrorS(err, "Failed to render components", "application", klog.KObj(app))
 		r.Recorder.Event(app, event.Warning(velatypes.ReasonFailedRender, err))
 		return r.endWithNegativeCondition(ctx, app, utils.ErrorCondition("Render", err))
 	}
+	if len(appFile.PatchPolicies) != 0 || app.Status.GetCondition("Policy").Type != "" {
+		app.Status.SetConditions(utils.ReadyCondition("Policy"))
+	}
 	if err := handler.HandleComponentsRevision(ctx, comps); err != nil {
 		klog.ErrorS(err, "Failed to handle compoents revision", "application", klog.KObj(app))
 		r.Recorder.Event(app, event.Warning(velatypes.ReasonFailedRevision, err))
@@ -151,9 +182,21 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	r.Recorder.Event(app, event.Normal(velatypes.ReasonRevisoned, velatypes.MessageRevisioned))
 	klog.Info("Successfully apply application revision", "application", klog.KObj(app))
 
-	policies, wfSteps, err := appFile.GenerateWorkflowAndPolicy()
+	if err := handler.RenderPlacements(ctx, appParser, appFile, comps); err != nil {
+		klog.ErrorS(err, "Failed to render application for clusters", "application", klog.KObj(app))
+		r.Recorder.Event(app, event.Warning(velatypes.ReasonFailedRender, err))
+		return r.endWithNegativeCondition(ctx, app, utils.ErrorCondition("Render", err))
+	}
+
+	policies, err := appFile.GeneratePolicyManifests()
+	if err != nil {
+		klog.Error(err, "[Handle GeneratePolicyManifests]")
+		r.Recorder.Event(app, event.Warning(velatypes.ReasonFailedRender, err))
+		return r.endWithNegativeCondition(ctx, app, utils.ErrorCondition("Render", err))
+	}
+	taskRunners, err := handler.GenerateTaskRunners(appFile, comps)
 	if err != nil {
-		klog.Error(err, "[Handle GenerateWorkflowAndPolicy]")
+		klog.Error(err, "[Handle GenerateTaskRunners]")
 		r.Recorder.Event(app, event.Warning(velatypes.ReasonFailedRender, err))
 		return r.endWithNegativeCondition(ctx, app, utils.ErrorCondition("Render", err))
 	}
@@ -161,6 +204,10 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	r.Recorder.Event(app, event.Normal(velatypes.ReasonRendered, velatypes.MessageRendered))
 	klog.Info("Successfully render application resources", "application", klog.KObj(app))
 
+	if err := handler.DetectDrift(ctx, comps); err != nil {
+		klog.ErrorS(err, "Failed to detect drift", "application", klog.KObj(app))
+		return r.endWithNegativeCondition(ctx, app, utils.ErrorCondition("DriftCheck", err))
+	}
 	if err := handler.ApplyAppManifests(ctx, comps, policies); err != nil {
 		klog.ErrorS(err, "Failed to apply application manifests",
 			"application", klog.KObj(app))
@@ -175,15 +222,33 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	r.Recorder.Event(app, event.Normal(velatypes.ReasonApplied, velatypes.MessageApplied))
 	klog.Info("Successfully apply application manifests", "application", klog.KObj(app))
 
-	done, err := workflow.NewWorkflow(app, handler.r.applicator).ExecuteSteps(ctx, handler.currentAppRev.Name, wfSteps)
+	done, err := workflow.NewWorkflow(app, r.Client, handler.rollbackToPreviousRevision).ExecuteSteps(ctx, handler.currentAppRev.Name, taskRunners)
+	if err := workflow.RecordHistory(ctx, r.Client, app); err != nil {
+		// the history is only for auditing, so it doesn't block the workflow
+		klog.ErrorS(err, "Failed to record workflow history", "application", klog.KObj(app))
+	}
 	if err != nil {
 		klog.Error(err, "[handle workflow]")
 		r.Recorder.Event(app, event.Warning(velatypes.ReasonFailedWorkflow, err))
 		return r.endWithNegativeCondition(ctx, app, utils.ErrorCondition("Workflow", err))
 	}
 	if !done {
+		if wfStatus := app.Status.Workflow; wfStatus != nil && (wfStatus.Suspend || wfStatus.Terminated) {
+			// a suspended or terminated workflow has nothing to do until it's resumed or restarted
+			return reconcile.Result{}, r.patchStatus(ctx, app)
+		}
 		return reconcile.Result{RequeueAfter: WorkflowReconcileWaitTime}, r.patchStatus(ctx, app)
 	}
+	if err := handler.garbageCollectWorkflow(ctx); err != nil {
+		klog.ErrorS(err, "Failed to garbage collect resources after workflow", "application", klog.KObj(app))
+		r.Recorder.Event(app, event.Warning(velatypes.ReasonFailedGC, err))
+		return r.endWithNegativeCondition(ctx, app, v1alpha1.ReconcileError(err))
+	}
+	if err := handler.garbageCollectClusters(ctx); err != nil {
+		klog.ErrorS(err, "Failed to garbage collect resources in unselected clusters", "application", klog.KObj(app))
+		r.Recorder.Event(app, event.Warning(velatypes.ReasonFailedGC, err))
+		return r.endWithNegativeCondition(ctx, app, v1alpha1.ReconcileError(err))
+	}
 
 	// if inplace is false and rolloutPlan is nil, it means the user will use an outer AppRollout object to rollout the application
 	if handler.app.Spec.RolloutPlan != nil {
@@ -206,6 +271,13 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		// there is no need reconcile immediately, that means the rollout operation have finished
 		r.Recorder.Event(app, event.Normal(velatypes.ReasonRollout, velatypes.MessageRollout))
 		app.Status.SetConditions(utils.ReadyCondition("Rollout"))
+		// the approvals are cleared once the rollout finishes so that they don't approve the next rollout,
+		// a copy is patched to keep the status of the application in memory
+		approved := app.DeepCopy()
+		if err := applicationrollout.ClearRolloutApproval(ctx, r.Client, approved); err != nil {
+			return r.endWithNegativeCondition(ctx, app, utils.ErrorCondition("Rollout", err))
+		}
+		app.ObjectMeta = approved.ObjectMeta
 		klog.Info("Finished rollout ")
 	}
 
@@ -240,6 +312,13 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	if err := r.patchStatus(ctx, app); err != nil {
 		return r.endWithNegativeCondition(ctx, app, v1alpha1.ReconcileError(err))
 	}
+	if handler.isGarbageCollectPending() {
+		// retry the resources which are not garbage collected yet
+		return ctrl.Result{RequeueAfter: GarbageCollectWaitTime}, nil
+	}
+	if r.driftDetectionInterval > 0 {
+		return ctrl.Result{RequeueAfter: r.driftDetectionInterval}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -275,11 +354,28 @@ func (r *Reconciler) handleFinalizers(ctx context.Context, app *v1beta1.Applicat
 			return true, errors.Wrap(r.Client.Update(ctx, app), errUpdateApplicationFinalizer)
 		}
 		if meta.FinalizerExists(app, resourceTrackerFinalizer) {
+			gcPolicy, err := appfile.ParseGarbageCollectPolicy(app.Spec.Policies)
+			if err != nil {
+				klog.ErrorS(err, "Failed to parse garbage-collect policy", "application", klog.KObj(app))
+				return true, errors.WithMessage(err, "cannot remove finalizer")
+			}
 			if app.Status.LatestRevision != nil && len(app.Status.LatestRevision.Name) != 0 {
-				latestTracker := &v1beta1.ResourceTracker{}
-				latestTracker.SetName(dispatch.ConstructResourceTrackerName(app.Status.LatestRevision.Name, app.Namespace))
-				if err := r.Client.Delete(ctx, latestTracker); err != nil && !kerrors.IsNotFound(err) {
-					klog.ErrorS(err, "Failed to delete latest resource tracker", "name", latestTracker.Name)
+				if err := finalizeHostResourceTrackers(ctx, r.Client, app, gcPolicy); err != nil {
+					klog.ErrorS(err, "Failed to delete resource trackers", "application", klog.KObj(app))
+					return true, errors.WithMessage(err, "cannot remove finalizer")
+				}
+			}
+			for _, cluster := range app.Status.Clusters {
+				cli, err := getClusterClient(ctx, r.Client, app.Namespace, cluster)
+				if kerrors.IsNotFound(err) {
+					klog.InfoS("Skip deleting resource trackers in a deleted cluster", "application", klog.KObj(app), "cluster", cluster)
+					continue
+				}
+				if err == nil {
+					err = deleteResourceTrackers(ctx, cli, app, gcPolicy)
+				}
+				if err != nil {
+					klog.ErrorS(err, "Failed to delete resource trackers in cluster", "cluster", cluster)
 					return true, errors.WithMessage(err, "cannot remove finalizer")
 				}
 			}
@@ -344,14 +440,15 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 // Setup adds a controller that reconciles AppRollout.
 func Setup(mgr ctrl.Manager, args core.Args) error {
 	reconciler := Reconciler{
-		Client:               mgr.GetClient(),
-		Scheme:               mgr.GetScheme(),
-		Recorder:             event.NewAPIRecorder(mgr.GetEventRecorderFor("Application")),
-		dm:                   args.DiscoveryMapper,
-		pd:                   args.PackageDiscover,
-		applicator:           apply.NewAPIApplicator(mgr.GetClient()),
-		appRevisionLimit:     args.AppRevisionLimit,
-		concurrentReconciles: args.ConcurrentReconciles,
+		Client:                 mgr.GetClient(),
+		Scheme:                 mgr.GetScheme(),
+		Recorder:               event.NewAPIRecorder(mgr.GetEventRecorderFor("Application")),
+		dm:                     args.DiscoveryMapper,
+		pd:                     args.PackageDiscover,
+		applicator:             apply.NewApplicator(mgr.GetClient()),
+		appRevisionLimit:       args.AppRevisionLimit,
+		concurrentReconciles:   args.ConcurrentReconciles,
+		driftDetectionInterval: args.DriftDetectionInterval,
 	}
 	return reconciler.SetupWithManager(mgr)
 }
@@ -20,10 +20,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -1091,6 +1094,137 @@ spec:
 		Expect(k8sClient.Delete(ctx, app)).Should(BeNil())
 	})
 
+	It("app with the health policy of the built-in worker", func() {
+		By("create the built-in worker definition")
+		workerYaml, err := ioutil.ReadFile(filepath.Join("..", "..", "..", "..", "..", "charts", "vela-core", "templates", "defwithtemplate", "worker.yaml"))
+		Expect(err).Should(BeNil())
+		workerJson, err := yaml.YAMLToJSON([]byte(strings.ReplaceAll(string(workerYaml), "{{.Values.systemDefinitionNamespace}}", "vela-system")))
+		Expect(err).Should(BeNil())
+		builtinWorker := &v1beta1.ComponentDefinition{}
+		Expect(json.Unmarshal(workerJson, builtinWorker)).Should(BeNil())
+		builtinWorker.SetName("builtin-worker")
+		Expect(k8sClient.Create(ctx, builtinWorker)).Should(SatisfyAny(BeNil(), &util.AlreadyExistMatcher{}))
+
+		By("create the new namespace")
+		ns := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "vela-test-with-builtin-health",
+			},
+		}
+		Expect(k8sClient.Create(ctx, ns)).Should(BeNil())
+
+		compName := "myworker"
+		app := appwithNoTrait.DeepCopy()
+		app.SetName("app-with-builtin-health")
+		app.SetNamespace(ns.Name)
+		app.Spec.Components[0].Name = compName
+		app.Spec.Components[0].Type = builtinWorker.Name
+
+		By("create a partially ready deployment")
+		expDeployment := getExpDeployment(compName, app.Name)
+		expDeployment.Name = compName
+		expDeployment.Namespace = ns.Name
+		expDeployment.Labels["workload.oam.dev/type"] = builtinWorker.Name
+		expDeployment.Labels["app.oam.dev/resourceType"] = "WORKLOAD"
+		replicas := int32(3)
+		expDeployment.Spec.Replicas = &replicas
+		Expect(k8sClient.Create(ctx, expDeployment)).Should(BeNil())
+		expDeployment.Status.Replicas = 3
+		expDeployment.Status.UpdatedReplicas = 3
+		expDeployment.Status.ReadyReplicas = 2
+		Expect(k8sClient.Status().Update(ctx, expDeployment)).Should(BeNil())
+
+		By("apply appfile")
+		Expect(k8sClient.Create(ctx, app)).Should(BeNil())
+		appKey := client.ObjectKey{
+			Name:      app.Name,
+			Namespace: app.Namespace,
+		}
+		reconcileOnceAfterFinalizer(reconciler, reconcile.Request{NamespacedName: appKey})
+
+		By("Check the component is progressing")
+		checkApp := &v1beta1.Application{}
+		Eventually(func() common.HealthPhase {
+			if _, err := reconciler.Reconcile(reconcile.Request{NamespacedName: appKey}); err != nil {
+				return ""
+			}
+			if err := k8sClient.Get(ctx, appKey, checkApp); err != nil || len(checkApp.Status.Services) == 0 {
+				return ""
+			}
+			return checkApp.Status.Services[0].HealthPhase
+		}, 5*time.Second, time.Second).Should(BeEquivalentTo(common.HealthPhaseProgressing))
+		Expect(checkApp.Status.Services[0].Healthy).Should(BeFalse())
+		Expect(checkApp.Status.Services[0].HealthReason).Should(Equal("2/3 replicas ready"))
+
+		Expect(k8sClient.Delete(ctx, app)).Should(BeNil())
+	})
+
+	It("app with drifted resources co-managed by HPA", func() {
+		By("detect drift in every reconcile")
+		reconciler.driftDetectionInterval = time.Minute
+		defer func() { reconciler.driftDetectionInterval = 0 }()
+
+		cd := &v1beta1.ComponentDefinition{}
+		cdJson, err := yaml.YAMLToJSON([]byte(componentDefWithReplicasYaml))
+		Expect(err).Should(BeNil())
+		Expect(json.Unmarshal(cdJson, cd)).Should(BeNil())
+		Expect(k8sClient.Create(ctx, cd)).Should(SatisfyAny(BeNil(), &util.AlreadyExistMatcher{}))
+
+		ns := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "vela-test-drift-with-hpa",
+			},
+		}
+		Expect(k8sClient.Create(ctx, ns)).Should(BeNil())
+
+		compName := "myweb-hpa"
+		app := appwithNoTrait.DeepCopy()
+		app.SetName("app-drift-with-hpa")
+		app.SetNamespace(ns.Name)
+		app.Spec.Components[0].Name = compName
+		app.Spec.Components[0].Type = cd.Name
+		app.Spec.Components[0].Properties = runtime.RawExtension{Raw: []byte(`{"image":"busybox"}`)}
+		Expect(k8sClient.Create(ctx, app)).Should(BeNil())
+		appKey := client.ObjectKey{
+			Name:      app.Name,
+			Namespace: app.Namespace,
+		}
+		reconcileOnceAfterFinalizer(reconciler, reconcile.Request{NamespacedName: appKey})
+		checkApp := &v1beta1.Application{}
+		Expect(k8sClient.Get(ctx, appKey, checkApp)).Should(BeNil())
+		Expect(checkApp.Status.Phase).Should(Equal(common.ApplicationRunning))
+
+		By("scale the deployment by HPA and change its image by kubectl")
+		deployKey := client.ObjectKey{Name: compName, Namespace: ns.Name}
+		deploy := &v1.Deployment{}
+		Expect(k8sClient.Get(ctx, deployKey, deploy)).Should(BeNil())
+		replicas := int32(3)
+		deploy.Spec.Replicas = &replicas
+		Expect(k8sClient.Update(ctx, deploy, client.FieldOwner("kube-controller-manager"))).Should(BeNil())
+		Expect(k8sClient.Get(ctx, deployKey, deploy)).Should(BeNil())
+		deploy.Spec.Template.Spec.Containers[0].Image = "nginx"
+		Expect(k8sClient.Update(ctx, deploy, client.FieldOwner("kubectl-edit"))).Should(BeNil())
+
+		By("resync the application with auto-heal off")
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: appKey})
+		Expect(err).Should(BeNil())
+		Expect(k8sClient.Get(ctx, appKey, checkApp)).Should(BeNil())
+		Expect(checkApp.Status.Drift).Should(Equal([]common.ResourceDrift{{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Namespace:  ns.Name,
+			Name:       compName,
+			Fields:     []string{"spec.template.spec.containers[0].image"},
+		}}))
+
+		By("check the deployment is still reconciled and the replicas scaled by HPA are kept")
+		Expect(k8sClient.Get(ctx, deployKey, deploy)).Should(BeNil())
+		Expect(deploy.Spec.Template.Spec.Containers[0].Image).Should(Equal("busybox"))
+		Expect(*deploy.Spec.Replicas).Should(Equal(int32(3)))
+
+		Expect(k8sClient.Delete(ctx, app)).Should(BeNil())
+	})
+
 	It("app with rollout annotation", func() {
 		By("create application with rolling out annotation")
 		ns := &corev1.Namespace{
@@ -1322,12 +1456,14 @@ spec:
 				Name:               compName,
 				WorkloadDefinition: ncd.Spec.Workload.Definition,
 				Healthy:            true,
+				HealthPhase:        common.HealthPhaseHealthy,
 				Message:            "type: busybox,\t enemies:alien",
 				Traits: []common.ApplicationTraitStatus{
 					{
-						Type:    "ingress",
-						Healthy: true,
-						Message: "type: ClusterIP,\t clusterIP:10.0.0.4,\t ports:80,\t domainexample.com",
+						Type:        "ingress",
+						Healthy:     true,
+						HealthPhase: common.HealthPhaseHealthy,
+						Message:     "type: ClusterIP,\t clusterIP:10.0.0.4,\t ports:80,\t domainexample.com",
 					},
 				},
 			},
@@ -2021,6 +2157,39 @@ spec:
       	cpu?: string
       }
 
+`
+	componentDefWithReplicasYaml = `
+apiVersion: core.oam.dev/v1beta1
+kind: ComponentDefinition
+metadata:
+  name: worker-with-replicas
+  namespace: vela-system
+spec:
+  workload:
+    definition:
+      apiVersion: apps/v1
+      kind: Deployment
+  schematic:
+    cue:
+      template: |
+        output: {
+        	apiVersion: "apps/v1"
+        	kind:       "Deployment"
+        	spec: {
+        		replicas: 1
+        		selector: matchLabels: "app.oam.dev/component": context.name
+        		template: {
+        			metadata: labels: "app.oam.dev/component": context.name
+        			spec: containers: [{
+        				name:  context.name
+        				image: parameter.image
+        			}]
+        		}
+        	}
+        }
+        parameter: {
+        	image: string
+        }
 `
 	componentDefWithHealthYaml = `
 apiVersion: core.oam.dev/v1beta1
@@ -18,12 +18,15 @@ package application
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -34,13 +37,13 @@ import (
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	"github.com/oam-dev/kubevela/apis/types"
 	"github.com/oam-dev/kubevela/pkg/appfile"
-	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/application/assemble"
 	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/application/dispatch"
 	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/applicationrollout"
 	"github.com/oam-dev/kubevela/pkg/controller/utils"
 	"github.com/oam-dev/kubevela/pkg/cue/process"
 	"github.com/oam-dev/kubevela/pkg/oam"
 	oamutil "github.com/oam-dev/kubevela/pkg/oam/util"
+	"github.com/oam-dev/kubevela/pkg/workflow"
 )
 
 // AppHandler handles application reconcile
@@ -51,6 +54,21 @@ type AppHandler struct {
 	latestAppRev   *v1beta1.ApplicationRevision
 	isNewRevision  bool
 	currentRevHash string
+
+	// placements are the clusters selected by the topology policy to dispatch the application to
+	placements []clusterPlacement
+	// unreadyClusters are the clusters selected by the topology policy but skipped for not being ready
+	unreadyClusters []string
+
+	// wasRunning is whether the application was running before this reconcile
+	wasRunning bool
+
+	// gcPolicy is the garbage-collect policy of the application, it's nil if there is no garbage-collect policy
+	gcPolicy *types.GarbageCollectPolicySpec
+
+	// dispatchMutex serializes dispatching by concurrent workflow steps,
+	// so that the resource tracker is updated by one dispatcher at a time.
+	dispatchMutex sync.Mutex
 }
 
 // ApplyAppManifests will dispatch Application manifests
@@ -63,37 +81,367 @@ func (h *AppHandler) ApplyAppManifests(ctx context.Context, comps []*types.Compo
 		return nil
 	}
 
-	var latestTracker *v1beta1.ResourceTracker
-	if h.app.Status.LatestRevision != nil {
-		latestTracker = &v1beta1.ResourceTracker{}
+	for _, p := range h.clusterPlacements() {
+		latestTracker, err := h.latestTracker(ctx, p)
+		if err != nil {
+			return err
+		}
+		// only do GC when ALL resources are dispatched successfully
+		// so skip GC while dispatching addon resources
+		d := dispatch.NewAppManifestsDispatcher(p.Client, appRev).StartAndSkipGC(latestTracker)
+		// dispatch packaged workload resources before dispatching assembled manifests
+		for _, comp := range p.components(comps) {
+			if len(comp.PackagedWorkloadResources) != 0 {
+				if _, err := d.Dispatch(ctx, comp.PackagedWorkloadResources); err != nil {
+					return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot dispatch packaged workload resources"))
+				}
+			}
+		}
+		manifests, err := p.assembleManifests(ctx, appRev).AssembledManifests()
+		if err != nil {
+			return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot assemble application manifests"))
+		}
+		if _, err := d.EndAndGC(latestTracker).WithGCPolicy(h.gcPolicy).Dispatch(ctx, manifests); err != nil {
+			return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot dispatch application manifests"))
+		}
+		if results := d.GarbageCollectResults(); results != nil {
+			h.recordGarbageCollect(p.Cluster, results)
+		}
+	}
+	return nil
+}
+
+// recordGarbageCollect replaces the garbage collection results of the cluster in the application status.
+func (h *AppHandler) recordGarbageCollect(cluster string, results []common.GarbageCollectResult) {
+	var merged []common.GarbageCollectResult
+	for _, result := range h.app.Status.GarbageCollect {
+		if result.Cluster != cluster {
+			merged = append(merged, result)
+		}
+	}
+	for _, result := range results {
+		result.Cluster = cluster
+		merged = append(merged, result)
+	}
+	h.app.Status.GarbageCollect = merged
+}
+
+// isGarbageCollectPending checks whether some removed resources are not garbage collected yet.
+func (h *AppHandler) isGarbageCollectPending() bool {
+	for _, result := range h.app.Status.GarbageCollect {
+		if result.Result == common.GarbageCollectResultPending || result.Result == common.GarbageCollectResultFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// latestTracker returns the resource tracker of the latest app revision in the cluster of the placement.
+// It's nil if the application is dispatched to the cluster for the first time.
+func (h *AppHandler) latestTracker(ctx context.Context, p clusterPlacement) (*v1beta1.ResourceTracker, error) {
+	if h.app.Status.LatestRevision == nil {
+		return nil, nil
+	}
+	if p.Cluster == hostCluster {
+		latestTracker := &v1beta1.ResourceTracker{}
 		latestTracker.SetName(dispatch.ConstructResourceTrackerName(h.app.Status.LatestRevision.Name, h.app.Namespace))
+		return latestTracker, nil
 	}
-	// only do GC when ALL resources are dispatched successfully
-	// so skip GC while dispatching addon resources
-	d := dispatch.NewAppManifestsDispatcher(h.r.Client, appRev).StartAndSkipGC(latestTracker)
-	// dispatch packaged workload resources before dispatching assembled manifests
-	for _, comp := range comps {
-		if len(comp.PackagedWorkloadResources) != 0 {
+	return getResourceTracker(ctx, p.Client, h.app.Status.LatestRevision.Name, h.app.Namespace)
+}
+
+func clusterMessage(cluster, message string) string {
+	if cluster == hostCluster {
+		return message
+	}
+	return fmt.Sprintf("cluster %s: %s", cluster, message)
+}
+
+// GenerateTaskRunners generates the task runners of the application workflow steps.
+func (h *AppHandler) GenerateTaskRunners(af *appfile.Appfile, comps []*types.ComponentManifest) ([]workflow.TaskRunner, error) {
+	if h.app.Spec.Workflow == nil {
+		return nil, nil
+	}
+	discover := workflow.NewTaskDiscover(h.app, h.r.applicator, h.r.pd, func(ctx context.Context, components ...string) error {
+		return h.applyComponents(ctx, comps, components...)
+	})
+	var taskRunners []workflow.TaskRunner
+	for i, step := range h.app.Spec.Workflow.Steps {
+		wl := af.WorkflowSteps[i]
+		runner, err := discover.GetTaskRunner(step, wl.FullTemplate.TemplateStr, wl.Params)
+		if err != nil {
+			return nil, err
+		}
+		taskRunners = append(taskRunners, runner)
+	}
+	return taskRunners, nil
+}
+
+// applyComponents dispatches the resources of the given components of the current app revision.
+// All components are dispatched if no component is given.
+func (h *AppHandler) applyComponents(ctx context.Context, comps []*types.ComponentManifest, components ...string) error {
+	selected := func(name string) bool {
+		if len(components) == 0 {
+			return true
+		}
+		for _, c := range components {
+			if c == name {
+				return true
+			}
+		}
+		return false
+	}
+	for _, name := range components {
+		found := false
+		for _, comp := range h.app.Spec.Components {
+			if comp.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("component %s is not found in application", name)
+		}
+	}
+
+	h.dispatchMutex.Lock()
+	defer h.dispatchMutex.Unlock()
+	for _, p := range h.clusterPlacements() {
+		previousTracker, err := h.previousTracker(ctx, p.Client)
+		if err != nil {
+			return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot get resource tracker of previous revision"))
+		}
+		// the resources of the previous revision are taken over, and they're garbage collected after the workflow
+		// finishes, see garbageCollectWorkflow
+		d := dispatch.NewAppManifestsDispatcher(p.Client, h.currentAppRev).StartAndSkipGC(previousTracker)
+		for _, comp := range p.components(comps) {
+			if !selected(comp.Name) || len(comp.PackagedWorkloadResources) == 0 {
+				continue
+			}
 			if _, err := d.Dispatch(ctx, comp.PackagedWorkloadResources); err != nil {
-				return errors.WithMessage(err, "cannot dispatch packaged workload resources")
+				return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot dispatch packaged workload resources"))
+			}
+		}
+		workloads, traits, _, err := p.assembleManifests(ctx, h.currentAppRev).GroupAssembledManifests()
+		if err != nil {
+			return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot assemble application manifests"))
+		}
+		var manifests []*unstructured.Unstructured
+		for _, comp := range h.app.Spec.Components {
+			wl, ok := workloads[comp.Name]
+			if !selected(comp.Name) || !ok {
+				continue
 			}
+			manifests = append(manifests, wl)
+			manifests = append(manifests, traits[comp.Name]...)
+		}
+		if _, err := d.Dispatch(ctx, manifests); err != nil {
+			return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot dispatch application manifests"))
+		}
+	}
+	return nil
+}
+
+// previousTracker returns the resource tracker of the latest app revision before the current one in the cluster,
+// it's nil if there is no such revision dispatched to the cluster.
+// The latest revision in the status can't be used as it's updated to the current revision before the workflow runs.
+func (h *AppHandler) previousTracker(ctx context.Context, cli client.Client) (*v1beta1.ResourceTracker, error) {
+	currentRevNum, err := utils.ExtractRevision(h.currentAppRev.Name)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "cannot get revision number of %s", h.currentAppRev.Name)
+	}
+	rtList := &v1beta1.ResourceTrackerList{}
+	if err := cli.List(ctx, rtList, client.MatchingLabels{
+		oam.LabelAppName:      h.app.Name,
+		oam.LabelAppNamespace: h.app.Namespace,
+	}); err != nil {
+		return nil, errors.WithMessage(err, "cannot list resource trackers")
+	}
+	var previous *v1beta1.ResourceTracker
+	previousRevNum := 0
+	for i, rt := range rtList.Items {
+		revNum, err := utils.ExtractRevision(dispatch.ExtractAppRevisionName(rt.Name, h.app.Namespace))
+		if err != nil || revNum >= currentRevNum || revNum <= previousRevNum {
+			continue
 		}
-		if comp.InsertConfigNotReady {
+		previous, previousRevNum = &rtList.Items[i], revNum
+	}
+	return previous, nil
+}
+
+// garbageCollectWorkflow garbage collects the resources of the previous revision which are not dispatched by the
+// workflow of the current revision, e.g., the resources of the removed components. It's done once the workflow
+// finishes, as the steps dispatch the components one by one.
+func (h *AppHandler) garbageCollectWorkflow(ctx context.Context) error {
+	if h.app.Spec.Workflow == nil || len(h.app.Spec.Workflow.Steps) == 0 {
+		return nil
+	}
+	h.dispatchMutex.Lock()
+	defer h.dispatchMutex.Unlock()
+	for _, p := range h.clusterPlacements() {
+		previousTracker, err := h.previousTracker(ctx, p.Client)
+		if err != nil {
+			return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot get resource tracker of previous revision"))
+		}
+		if previousTracker == nil {
 			continue
 		}
+		d := dispatch.NewAppManifestsDispatcher(p.Client, h.currentAppRev).EndAndGC(previousTracker).WithGCPolicy(h.gcPolicy)
+		if _, err := d.Dispatch(ctx, nil); err != nil {
+			return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot garbage collect resources of previous revision"))
+		}
+		if results := d.GarbageCollectResults(); results != nil {
+			h.recordGarbageCollect(p.Cluster, results)
+		}
 	}
-	a := assemble.NewAppManifests(appRev).WithWorkloadOption(assemble.DiscoveryHelmBasedWorkload(ctx, h.r.Client))
-	manifests, err := a.AssembledManifests()
+	return nil
+}
+
+// rollbackToPreviousRevision dispatches the resources of the previous app revision again,
+// and garbage collects the resources only dispatched by the current app revision.
+func (h *AppHandler) rollbackToPreviousRevision(ctx context.Context) error {
+	currentRevNum, err := utils.ExtractRevision(h.currentAppRev.Name)
 	if err != nil {
-		return errors.WithMessage(err, "cannot assemble application manifests")
+		return errors.WithMessagef(err, "cannot get revision number of %s", h.currentAppRev.Name)
+	}
+	appRevList := &v1beta1.ApplicationRevisionList{}
+	if err := h.r.List(ctx, appRevList, client.InNamespace(h.app.Namespace),
+		client.MatchingLabels{oam.LabelAppName: h.app.Name}); err != nil {
+		return errors.WithMessage(err, "cannot list application revisions")
 	}
-	if _, err := d.EndAndGC(latestTracker).Dispatch(ctx, manifests); err != nil {
-		return errors.WithMessage(err, "cannot dispatch application manifests")
+	var prevRev *v1beta1.ApplicationRevision
+	prevRevNum := 0
+	for i, rev := range appRevList.Items {
+		revNum, err := utils.ExtractRevision(rev.Name)
+		if err != nil || revNum >= currentRevNum || revNum <= prevRevNum {
+			continue
+		}
+		prevRev, prevRevNum = &appRevList.Items[i], revNum
+	}
+	if prevRev == nil {
+		return errors.Errorf("no previous revision of application %s to rollback to", h.app.Name)
+	}
+
+	h.dispatchMutex.Lock()
+	defer h.dispatchMutex.Unlock()
+	parser := appfile.NewApplicationParser(h.r.Client, h.r.dm, h.r.pd)
+	prevApp := prevRev.Spec.Application.DeepCopy()
+	prevApp.SetNamespace(h.app.Namespace)
+	for _, p := range h.clusterPlacements() {
+		p.AppFile, p.Components = nil, nil
+		if hasOverridePolicy(prevApp) {
+			// render the previous revision with its own override policies of the cluster
+			prevComps, err := oamutil.AppConfig2ComponentManifests(prevRev.Spec.ApplicationConfiguration, prevRev.Spec.Components)
+			if err != nil {
+				return errors.WithMessagef(err, "cannot get components of application revision %s", prevRev.Name)
+			}
+			if p.AppFile, p.Components, err = renderForCluster(ctx, parser, prevApp, prevRev.Name, prevComps, p.Cluster); err != nil {
+				return errors.WithMessagef(err, clusterMessage(p.Cluster, "cannot render application revision %s"), prevRev.Name)
+			}
+		}
+		manifests, err := p.assembleManifests(ctx, prevRev).AssembledManifests()
+		if err != nil {
+			return errors.WithMessagef(err, clusterMessage(p.Cluster, "cannot assemble manifests of application revision %s"), prevRev.Name)
+		}
+		currentTracker, err := getResourceTracker(ctx, p.Client, h.currentAppRev.Name, h.app.Namespace)
+		if err != nil {
+			return err
+		}
+		d := dispatch.NewAppManifestsDispatcher(p.Client, prevRev).EndAndGC(currentTracker).WithGCPolicy(h.gcPolicy)
+		if _, err := d.Dispatch(ctx, manifests); err != nil {
+			return errors.WithMessagef(err, clusterMessage(p.Cluster, "cannot rollback to application revision %s"), prevRev.Name)
+		}
+		if results := d.GarbageCollectResults(); results != nil {
+			h.recordGarbageCollect(p.Cluster, results)
+		}
 	}
+	klog.InfoS("Rollback application to previous revision", "application", klog.KObj(h.app), "revision", prevRev.Name)
 	return nil
 }
 
+func hasOverridePolicy(app *v1beta1.Application) bool {
+	for _, policy := range app.Spec.Policies {
+		if policy.Type == types.PolicyTypeOverride {
+			return true
+		}
+	}
+	return false
+}
+
+// aggregateHealthStatus checks the health of the application in all the clusters it's dispatched to.
+// A component or trait is healthy only if it's healthy in all clusters.
 func (h *AppHandler) aggregateHealthStatus(appFile *appfile.Appfile) ([]common.ApplicationComponentStatus, bool, error) {
+	placements := h.clusterPlacements()
+	if len(placements) == 1 {
+		return h.aggregateClusterHealthStatus(placements[0].appFile(appFile), placements[0].Client)
+	}
+	var appStatus []common.ApplicationComponentStatus
+	var healthy = true
+	for _, p := range placements {
+		clusterStatus, clusterHealthy, err := h.aggregateClusterHealthStatus(p.appFile(appFile), p.Client)
+		if err != nil {
+			return nil, false, errors.WithMessage(err, clusterMessage(p.Cluster, "check health error"))
+		}
+		healthy = healthy && clusterHealthy
+		if appStatus == nil {
+			appStatus = make([]common.ApplicationComponentStatus, len(clusterStatus))
+			for i := range clusterStatus {
+				appStatus[i] = clusterStatus[i]
+				appStatus[i].Healthy = true
+				appStatus[i].HealthPhase = common.HealthPhaseHealthy
+				appStatus[i].HealthReason = ""
+				appStatus[i].Message = ""
+				appStatus[i].Traits = make([]common.ApplicationTraitStatus, len(clusterStatus[i].Traits))
+				for j := range clusterStatus[i].Traits {
+					appStatus[i].Traits[j] = common.ApplicationTraitStatus{Type: clusterStatus[i].Traits[j].Type, Healthy: true,
+						HealthPhase: common.HealthPhaseHealthy}
+				}
+			}
+		}
+		for i, status := range clusterStatus {
+			appStatus[i].Healthy = appStatus[i].Healthy && status.Healthy
+			appStatus[i].HealthPhase = worseHealthPhase(appStatus[i].HealthPhase, status.HealthPhase)
+			appStatus[i].HealthReason = joinClusterMessage(appStatus[i].HealthReason, p.Cluster, status.HealthReason)
+			appStatus[i].Message = joinClusterMessage(appStatus[i].Message, p.Cluster, status.Message)
+			for j, traitStatus := range status.Traits {
+				appStatus[i].Traits[j].Healthy = appStatus[i].Traits[j].Healthy && traitStatus.Healthy
+				appStatus[i].Traits[j].HealthPhase = worseHealthPhase(appStatus[i].Traits[j].HealthPhase, traitStatus.HealthPhase)
+				appStatus[i].Traits[j].HealthReason = joinClusterMessage(appStatus[i].Traits[j].HealthReason, p.Cluster, traitStatus.HealthReason)
+				appStatus[i].Traits[j].Message = joinClusterMessage(appStatus[i].Traits[j].Message, p.Cluster, traitStatus.Message)
+			}
+		}
+	}
+	return appStatus, healthy, nil
+}
+
+// healthPhaseSeverity orders the health phases, unhealthy is worse than progressing which is worse than healthy
+var healthPhaseSeverity = map[common.HealthPhase]int{
+	common.HealthPhaseHealthy:     0,
+	common.HealthPhaseProgressing: 1,
+	common.HealthPhaseUnhealthy:   2,
+}
+
+// worseHealthPhase returns the worse one of the health phases in different clusters
+func worseHealthPhase(phase, clusterPhase common.HealthPhase) common.HealthPhase {
+	if healthPhaseSeverity[clusterPhase] > healthPhaseSeverity[phase] {
+		return clusterPhase
+	}
+	return phase
+}
+
+func joinClusterMessage(message, cluster, clusterMessage string) string {
+	if clusterMessage == "" {
+		return message
+	}
+	clusterMessage = fmt.Sprintf("%s: %s", cluster, clusterMessage)
+	if message == "" {
+		return clusterMessage
+	}
+	return message + "; " + clusterMessage
+}
+
+// aggregateClusterHealthStatus checks the health of the application in the cluster of the client.
+func (h *AppHandler) aggregateClusterHealthStatus(appFile *appfile.Appfile, cli client.Client) ([]common.ApplicationComponentStatus, bool, error) {
 	var appStatus []common.ApplicationComponentStatus
 	var healthy = true
 	for _, wl := range appFile.Workloads {
@@ -101,6 +449,7 @@ func (h *AppHandler) aggregateHealthStatus(appFile *appfile.Appfile) ([]common.A
 			Name:               wl.Name,
 			WorkloadDefinition: wl.FullTemplate.Reference.Definition,
 			Healthy:            true,
+			HealthPhase:        common.HealthPhaseHealthy,
 		}
 
 		var (
@@ -112,6 +461,8 @@ func (h *AppHandler) aggregateHealthStatus(appFile *appfile.Appfile) ([]common.A
 		// this can help detect the componentManifest not ready and reconcile again
 		if wl.ConfigNotReady {
 			status.Healthy = false
+			status.HealthPhase = common.HealthPhaseProgressing
+			status.HealthReason = "secrets or configs not ready"
 			status.Message = "secrets or configs not ready"
 			appStatus = append(appStatus, status)
 			healthy = false
@@ -130,14 +481,23 @@ func (h *AppHandler) aggregateHealthStatus(appFile *appfile.Appfile) ([]common.A
 			pCtx = appfile.NewBasicContext(wl, appFile.Name, appFile.RevisionName, appFile.Namespace)
 			ctx := context.Background()
 			var configuration terraformapi.Configuration
-			if err := h.r.Client.Get(ctx, client.ObjectKey{Name: wl.Name, Namespace: h.app.Namespace}, &configuration); err != nil {
+			if err := cli.Get(ctx, client.ObjectKey{Name: wl.Name, Namespace: h.app.Namespace}, &configuration); err != nil {
 				return nil, false, errors.WithMessagef(err, "app=%s, comp=%s, check health error", appFile.Name, wl.Name)
 			}
-			if configuration.Status.State != terraformtypes.Available {
+			switch configuration.Status.State {
+			case terraformtypes.Available:
+				status.Healthy = true
+			case terraformtypes.Unavailable:
 				healthy = false
 				status.Healthy = false
-			} else {
-				status.Healthy = true
+				status.HealthPhase = common.HealthPhaseUnhealthy
+			default:
+				healthy = false
+				status.Healthy = false
+				status.HealthPhase = common.HealthPhaseProgressing
+			}
+			if !status.Healthy {
+				status.HealthReason = configuration.Status.Message
 			}
 			status.Message = configuration.Status.Message
 		default:
@@ -153,17 +513,18 @@ func (h *AppHandler) aggregateHealthStatus(appFile *appfile.Appfile) ([]common.A
 			if err := wl.EvalContext(pCtx); err != nil {
 				return nil, false, errors.WithMessagef(err, "app=%s, comp=%s, evaluate context error", appFile.Name, wl.Name)
 			}
-			workloadHealth, err := wl.EvalHealth(pCtx, h.r, h.app.Namespace)
+			workloadHealth, err := wl.EvalHealth(pCtx, cli, h.app.Namespace)
 			if err != nil {
 				return nil, false, errors.WithMessagef(err, "app=%s, comp=%s, check health error", appFile.Name, wl.Name)
 			}
-			if !workloadHealth {
-				// TODO(wonderflow): we should add a custom way to let the template say why it's unhealthy, only a bool flag is not enough
-				status.Healthy = false
+			status.Healthy = workloadHealth.Healthy()
+			status.HealthPhase = workloadHealth.Phase
+			status.HealthReason = workloadHealth.Reason
+			if !status.Healthy {
 				healthy = false
 			}
 
-			status.Message, err = wl.EvalStatus(pCtx, h.r, h.app.Namespace)
+			status.Message, err = wl.EvalStatus(pCtx, cli, h.app.Namespace)
 			if err != nil {
 				return nil, false, errors.WithMessagef(err, "app=%s, comp=%s, evaluate workload status message error", appFile.Name, wl.Name)
 			}
@@ -176,19 +537,19 @@ func (h *AppHandler) aggregateHealthStatus(appFile *appfile.Appfile) ([]common.A
 			}
 
 			var traitStatus = common.ApplicationTraitStatus{
-				Type:    tr.Name,
-				Healthy: true,
+				Type: tr.Name,
 			}
-			traitHealth, err := tr.EvalHealth(pCtx, h.r, h.app.Namespace)
+			traitHealth, err := tr.EvalHealth(pCtx, cli, h.app.Namespace)
 			if err != nil {
 				return nil, false, errors.WithMessagef(err, "app=%s, comp=%s, trait=%s, check health error", appFile.Name, wl.Name, tr.Name)
 			}
-			if !traitHealth {
-				// TODO(wonderflow): we should add a custom way to let the template say why it's unhealthy, only a bool flag is not enough
-				traitStatus.Healthy = false
+			traitStatus.Healthy = traitHealth.Healthy()
+			traitStatus.HealthPhase = traitHealth.Phase
+			traitStatus.HealthReason = traitHealth.Reason
+			if !traitStatus.Healthy {
 				healthy = false
 			}
-			traitStatus.Message, err = tr.EvalStatus(pCtx, h.r, h.app.Namespace)
+			traitStatus.Message, err = tr.EvalStatus(pCtx, cli, h.app.Namespace)
 			if err != nil {
 				return nil, false, errors.WithMessagef(err, "app=%s, comp=%s, trait=%s, evaluate status message error", appFile.Name, wl.Name, tr.Name)
 			}
@@ -217,10 +578,12 @@ func generateScopeReference(scopes []appfile.Scope) []runtimev1alpha1.TypedRefer
 type garbageCollectFunc func(ctx context.Context, h *AppHandler) error
 
 // execute garbage collection functions, including:
+// - resume the garbage collection of resources left pending
 // - clean up legacy app revisions
 // - clean up legacy component revisions
 func garbageCollection(ctx context.Context, h *AppHandler) error {
 	collectFuncs := []garbageCollectFunc{
+		garbageCollectFunc(resumeGarbageCollect),
 		garbageCollectFunc(cleanUpApplicationRevision),
 		garbageCollectFunc(cleanUpComponentRevision),
 	}
@@ -232,7 +595,51 @@ func garbageCollection(ctx context.Context, h *AppHandler) error {
 	return nil
 }
 
-func (h *AppHandler) handleRollout(ctx context.Context) (reconcile.Result, error) {
+// resumeGarbageCollect garbage collects the resources of the resource trackers left pending by the previous
+// garbage collection in every cluster, e.g., the resources waiting for the workloads deleted before them.
+func resumeGarbageCollect(ctx context.Context, h *AppHandler) error {
+	if h.app.Status.LatestRevision == nil || appWillRollout(h.app) {
+		return nil
+	}
+	for _, p := range h.clusterPlacements() {
+		latestTracker, err := getResourceTracker(ctx, p.Client, h.app.Status.LatestRevision.Name, h.app.Namespace)
+		if err != nil {
+			return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot resume garbage collection"))
+		}
+		if latestTracker == nil {
+			continue
+		}
+		rtList := &v1beta1.ResourceTrackerList{}
+		if err := p.Client.List(ctx, rtList, client.MatchingLabels{
+			oam.LabelAppName:      h.app.Name,
+			oam.LabelAppNamespace: h.app.Namespace,
+		}); err != nil {
+			return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot list resource trackers"))
+		}
+		var results []common.GarbageCollectResult
+		resumed := false
+		for _, rt := range rtList.Items {
+			if rt.Name == latestTracker.Name || rt.GetAnnotations()[oam.AnnotationGCPending] != "true" {
+				continue
+			}
+			klog.InfoS("Resume pending garbage collection", "application", klog.KObj(h.app), "resourceTracker", rt.Name)
+			rtResults, err := dispatch.NewGCHandler(p.Client, h.app.Namespace).WithPolicy(h.gcPolicy).
+				GarbageCollect(ctx, rt.DeepCopy(), latestTracker)
+			if err != nil {
+				return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot resume garbage collection"))
+			}
+			results = append(results, rtResults...)
+			resumed = true
+		}
+		if resumed {
+			h.recordGarbageCollect(p.Cluster, results)
+		}
+	}
+	return nil
+}
+
+// newAppRollout constructs the AppRollout of the rollout plan of the application in memory
+func (h *AppHandler) newAppRollout() *v1beta1.AppRollout {
 	var comps []string
 	for _, component := range h.app.Spec.Components {
 		comps = append(comps, component.Name)
@@ -250,24 +657,38 @@ func (h *AppHandler) handleRollout(ctx context.Context) (reconcile.Result, error
 		srcRevision = utils.ConstructRevisionName(h.app.Name, int64(target-1))
 	}
 
-	appRollout := v1beta1.AppRollout{
+	// the rollout is paused by annotation since changing the rollout plan creates a new app revision
+	rolloutPlan := h.app.Spec.RolloutPlan.DeepCopy()
+	if h.app.GetAnnotations()[oam.AnnotationRolloutPaused] == "true" {
+		rolloutPlan.Paused = true
+	}
+	return &v1beta1.AppRollout{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      h.app.Name,
 			Namespace: h.app.Namespace,
 			UID:       h.app.UID,
+			// the batches are approved by the annotations of the application
+			Annotations: h.app.GetAnnotations(),
+			// the resources created by the rollout, e.g., the services of the traffic routing, are owned by
+			// the application since this AppRollout doesn't exist
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(h.app, v1beta1.ApplicationKindVersionKind)},
 		},
 		Spec: v1beta1.AppRolloutSpec{
 			SourceAppRevisionName: srcRevision,
 			TargetAppRevisionName: targetRevision,
 			ComponentList:         comps,
-			RolloutPlan:           *h.app.Spec.RolloutPlan,
+			RolloutPlan:           *rolloutPlan,
 		},
 		Status: h.app.Status.Rollout,
 	}
+}
+
+func (h *AppHandler) handleRollout(ctx context.Context) (reconcile.Result, error) {
+	appRollout := h.newAppRollout()
 
 	// construct a fake rollout object and call rollout.DoReconcile
 	r := applicationrollout.NewReconciler(h.r.Client, h.r.dm, h.r.Recorder, h.r.Scheme)
-	res, err := r.DoReconcile(ctx, &appRollout)
+	res, err := r.DoReconcile(ctx, appRollout)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
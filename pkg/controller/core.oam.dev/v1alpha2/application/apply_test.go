@@ -31,11 +31,13 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
 	velatypes "github.com/oam-dev/kubevela/apis/types"
 	"github.com/oam-dev/kubevela/pkg/appfile"
 )
@@ -154,6 +156,53 @@ var _ = Describe("Test Application apply", func() {
 		Expect(exist).Should(BeTrue())
 		Expect(strings.Compare(applabel, app.Name) == 0).Should(BeTrue())
 	})
+
+	It("Test record the selected clusters before dispatching", func() {
+		ctx := context.TODO()
+		app.Name = "record-clusters"
+		Expect(k8sClient.Create(ctx, app)).Should(BeNil())
+		app.Status.Clusters = []string{"cluster-c"}
+		Expect(k8sClient.Status().Update(ctx, app)).Should(BeNil())
+
+		handler := &AppHandler{r: reconciler, app: app}
+		handler.placements = []clusterPlacement{{Cluster: "cluster-b"}, {Cluster: "cluster-a"}}
+		app.Status.Phase = common.ApplicationRunningWorkflow
+		Expect(handler.recordClusters(ctx)).Should(BeNil())
+		// the status in progress is kept
+		Expect(app.Status.Phase).Should(Equal(common.ApplicationRunningWorkflow))
+		Expect(app.Status.Clusters).Should(Equal([]string{"cluster-a", "cluster-b", "cluster-c"}))
+
+		checkApp := &v1beta1.Application{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: app.Namespace, Name: app.Name}, checkApp)).Should(BeNil())
+		// the clusters no longer selected are kept until they're garbage collected
+		Expect(checkApp.Status.Clusters).Should(Equal([]string{"cluster-a", "cluster-b", "cluster-c"}))
+		Expect(checkApp.ResourceVersion).Should(Equal(app.ResourceVersion))
+	})
+
+	It("Test the AppRollout of the rollout plan is controlled by the application", func() {
+		app.Name = "rollout-plan"
+		app.UID = "app-uid"
+		app.Spec.RolloutPlan = &v1alpha1.RolloutPlan{
+			TrafficRouting: &v1alpha1.TrafficRouting{Provider: v1alpha1.IstioTrafficProvider, Service: "test-app", Port: 80},
+			RolloutBatches: []v1alpha1.RolloutBatch{{}},
+		}
+		app.Status.LatestRevision = &common.Revision{Name: "rollout-plan-v2", Revision: 2}
+
+		handler := &AppHandler{r: reconciler, app: app}
+		appRollout := handler.newAppRollout()
+		Expect(appRollout.Spec.SourceAppRevisionName).Should(Equal("rollout-plan-v1"))
+		Expect(appRollout.Spec.TargetAppRevisionName).Should(Equal("rollout-plan-v2"))
+		// the AppRollout doesn't exist, the resources of the traffic routing are owned by the application
+		Expect(appRollout.UID).Should(Equal(app.UID))
+		Expect(metav1.GetControllerOf(appRollout)).Should(Equal(&metav1.OwnerReference{
+			APIVersion:         v1beta1.SchemeGroupVersion.String(),
+			Kind:               v1beta1.ApplicationKind,
+			Name:               app.Name,
+			UID:                app.UID,
+			Controller:         pointer.BoolPtr(true),
+			BlockOwnerDeletion: pointer.BoolPtr(true),
+		}))
+	})
 })
 
 var _ = Describe("Test statusAggregate", func() {
@@ -96,6 +96,13 @@ func (am *AppManifests) WithWorkloadOption(wo WorkloadOption) *AppManifests {
 	return am
 }
 
+// WithComponentManifests makes the AppManifests assemble the given component manifests instead of the ones
+// recorded in the ApplicationRevision, e.g., the component manifests overridden for a cluster.
+func (am *AppManifests) WithComponentManifests(comps []*types.ComponentManifest) *AppManifests {
+	am.componentManifests = comps
+	return am
+}
+
 // AssembledManifests do assemble and merge all assembled resources(except referenced scopes) into one array
 // The result guarantee the order of resources as defined in application originally.
 // If it contains more than one component, the resources are well-orderred and also grouped.
@@ -203,8 +210,13 @@ func (am *AppManifests) assemble() {
 			Name:       wl.GetName(),
 		}
 		am.assembledTraits[compName] = make([]*unstructured.Unstructured, len(comp.Traits))
+		applyMode, hasApplyMode := wl.GetAnnotations()[oam.AnnotationApplyMode]
 		for i, trait := range comp.Traits {
 			trait := am.assembleTrait(trait, compName, commonLabels)
+			if _, ok := trait.GetAnnotations()[oam.AnnotationApplyMode]; hasApplyMode && !ok {
+				// the apply mode is set per component, the traits are applied in the same mode as the workload
+				util.AddAnnotations(trait, map[string]string{oam.AnnotationApplyMode: applyMode})
+			}
 			if err := am.setWorkloadRefToTrait(workloadRef, trait); err != nil {
 				am.finalizeAssemble(errors.WithMessagef(err, "cannot set workload reference to trait %q", trait.GetName()))
 				return
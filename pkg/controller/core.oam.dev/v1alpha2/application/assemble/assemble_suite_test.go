@@ -26,6 +26,7 @@ import (
 
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	"github.com/oam-dev/kubevela/pkg/oam"
+	"github.com/oam-dev/kubevela/pkg/oam/util"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -206,4 +207,24 @@ var _ = Describe("Test Assemble Options", func() {
 		Expect(annotationKeys).ShouldNot(ContainElements("notPassAnno1", "notPassAnno2"))
 		Expect(annotationKeys).Should(ContainElements("canPassAnno"))
 	})
+
+	It("test apply mode of component", func() {
+		compName := "test-comp"
+		appRev := &v1beta1.ApplicationRevision{}
+		b, err := ioutil.ReadFile("./testdata/apprevision.yaml")
+		Expect(err).Should(BeNil())
+		Expect(yaml.Unmarshal(b, appRev)).Should(BeNil())
+		comps, err := util.AppConfig2ComponentManifests(appRev.Spec.ApplicationConfiguration, appRev.Spec.Components)
+		Expect(err).Should(BeNil())
+		util.AddAnnotations(comps[0].StandardWorkload, map[string]string{oam.AnnotationApplyMode: "server-side"})
+		util.AddAnnotations(comps[0].Traits[1], map[string]string{oam.AnnotationApplyMode: "client-side"})
+
+		workloads, traits, _, err := NewAppManifests(appRev).WithComponentManifests(comps).GroupAssembledManifests()
+		Expect(err).Should(BeNil())
+		Expect(workloads[compName].GetAnnotations()[oam.AnnotationApplyMode]).Should(Equal("server-side"))
+		By("the traits are applied in the mode of the component unless they set their own")
+		Expect(traits[compName][0].GetAnnotations()[oam.AnnotationApplyMode]).Should(Equal("server-side"))
+		Expect(traits[compName][1].GetAnnotations()[oam.AnnotationApplyMode]).Should(Equal("client-side"))
+		Expect(traits[compName][2].GetAnnotations()[oam.AnnotationApplyMode]).Should(Equal("server-side"))
+	})
 })
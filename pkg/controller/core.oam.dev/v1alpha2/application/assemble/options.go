@@ -19,6 +19,7 @@ package assemble
 import (
 	"context"
 	"fmt"
+	"math"
 	"reflect"
 	"strings"
 
@@ -131,6 +132,9 @@ func PrepareWorkloadForRollout(rolloutComp string) WorkloadOption {
 			cloneSetDisablePath            = "spec.updateStrategy.paused"
 			advancedStatefulSetDisablePath = "spec.updateStrategy.rollingUpdate.paused"
 			deploymentDisablePath          = "spec.paused"
+			statefulSetStrategyTypePath    = "spec.updateStrategy.type"
+			statefulSetDisablePath         = "spec.updateStrategy.rollingUpdate.partition"
+			daemonSetDisablePath           = "spec.updateStrategy"
 		)
 		pv := fieldpath.Pave(assembledWorkload.UnstructuredContent())
 		// TODO: we can get the workloadDefinition name from workload.GetLabels()["oam.WorkloadTypeLabel"]
@@ -156,15 +160,37 @@ func PrepareWorkloadForRollout(rolloutComp string) WorkloadOption {
 					"kind", assembledWorkload.GetKind(), "instance name", assembledWorkload.GetName())
 				return nil
 			}
-		} else if assembledWorkload.GroupVersionKind().Group == appsv1.GroupName &&
-			assembledWorkload.GetKind() == reflect.TypeOf(appsv1.Deployment{}).Name() {
-			err := pv.SetBool(deploymentDisablePath, true)
-			if err != nil {
-				return err
+		} else if assembledWorkload.GroupVersionKind().Group == appsv1.GroupName {
+			switch assembledWorkload.GetKind() {
+			case reflect.TypeOf(appsv1.Deployment{}).Name():
+				err := pv.SetBool(deploymentDisablePath, true)
+				if err != nil {
+					return err
+				}
+				klog.InfoS("we render a deployment assembledWorkload.paused on the first time",
+					"kind", assembledWorkload.GetKind(), "instance name", assembledWorkload.GetName())
+				return nil
+			case reflect.TypeOf(appsv1.StatefulSet{}).Name():
+				// a statefulset has no paused field, no pod is upgraded if the partition is not less than the replicas
+				if err := pv.SetString(statefulSetStrategyTypePath, string(appsv1.RollingUpdateStatefulSetStrategyType)); err != nil {
+					return err
+				}
+				if err := pv.SetNumber(statefulSetDisablePath, math.MaxInt32); err != nil {
+					return err
+				}
+				klog.InfoS("we render a statefulset assembledWorkload.partition to hold all the pods on the first time",
+					"kind", assembledWorkload.GetKind(), "instance name", assembledWorkload.GetName())
+				return nil
+			case reflect.TypeOf(appsv1.DaemonSet{}).Name():
+				// a daemonset has no paused field, no pod is upgraded by the daemonset controller with OnDelete strategy
+				if err := pv.SetValue(daemonSetDisablePath, map[string]interface{}{
+					"type": string(appsv1.OnDeleteDaemonSetStrategyType)}); err != nil {
+					return err
+				}
+				klog.InfoS("we render a daemonset assembledWorkload.updateStrategy as OnDelete on the first time",
+					"kind", assembledWorkload.GetKind(), "instance name", assembledWorkload.GetName())
+				return nil
 			}
-			klog.InfoS("we render a deployment assembledWorkload.paused on the first time",
-				"kind", assembledWorkload.GetKind(), "instance name", assembledWorkload.GetName())
-			return nil
 		}
 
 		klog.InfoS("we encountered an unknown resource, we don't know how to prepare it",
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"reflect"
 
 	"github.com/ghodss/yaml"
@@ -120,6 +121,49 @@ var _ = Describe("Test WorkloadOption", func() {
 			Expect(assembledDeploy.Spec.Paused).Should(BeTrue())
 		})
 
+		It("test rollout StatefulSet", func() {
+			By("Use StatefulSet as workload")
+			sts := &unstructured.Unstructured{}
+			sts.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind(reflect.TypeOf(appsv1.StatefulSet{}).Name()))
+			comp := types.ComponentManifest{
+				Name:             compName,
+				StandardWorkload: sts,
+			}
+			By("Add PrepareWorkloadForRollout WorkloadOption")
+			ao := NewAppManifests(appRev).WithWorkloadOption(PrepareWorkloadForRollout(compName))
+			ao.componentManifests = []*types.ComponentManifest{&comp}
+			workloads, _, _, err := ao.GroupAssembledManifests()
+			Expect(err).Should(BeNil())
+			Expect(len(workloads)).Should(Equal(1))
+
+			By("Verify all the pods are held by the partition")
+			assembledSts := &appsv1.StatefulSet{}
+			runtime.DefaultUnstructuredConverter.FromUnstructured(workloads[compName].Object, assembledSts)
+			Expect(assembledSts.Spec.UpdateStrategy.Type).Should(Equal(appsv1.RollingUpdateStatefulSetStrategyType))
+			Expect(*assembledSts.Spec.UpdateStrategy.RollingUpdate.Partition).Should(BeEquivalentTo(math.MaxInt32))
+		})
+
+		It("test rollout DaemonSet", func() {
+			By("Use DaemonSet as workload")
+			ds := &unstructured.Unstructured{}
+			ds.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind(reflect.TypeOf(appsv1.DaemonSet{}).Name()))
+			comp := types.ComponentManifest{
+				Name:             compName,
+				StandardWorkload: ds,
+			}
+			By("Add PrepareWorkloadForRollout WorkloadOption")
+			ao := NewAppManifests(appRev).WithWorkloadOption(PrepareWorkloadForRollout(compName))
+			ao.componentManifests = []*types.ComponentManifest{&comp}
+			workloads, _, _, err := ao.GroupAssembledManifests()
+			Expect(err).Should(BeNil())
+			Expect(len(workloads)).Should(Equal(1))
+
+			By("Verify the DaemonSet is updated on delete")
+			assembledDs := &appsv1.DaemonSet{}
+			runtime.DefaultUnstructuredConverter.FromUnstructured(workloads[compName].Object, assembledDs)
+			Expect(assembledDs.Spec.UpdateStrategy.Type).Should(Equal(appsv1.OnDeleteDaemonSetStrategyType))
+		})
+
 	})
 
 	Describe("test DiscoveryHelmBasedWorkload", func() {
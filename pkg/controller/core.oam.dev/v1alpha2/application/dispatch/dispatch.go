@@ -26,13 +26,15 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/types"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/types"
 	"github.com/oam-dev/kubevela/pkg/oam"
 	"github.com/oam-dev/kubevela/pkg/utils/apply"
 )
@@ -41,7 +43,7 @@ import (
 func NewAppManifestsDispatcher(c client.Client, appRev *v1beta1.ApplicationRevision) *AppManifestsDispatcher {
 	return &AppManifestsDispatcher{
 		c:          c,
-		applicator: apply.NewAPIApplicator(c),
+		applicator: apply.NewApplicator(c),
 		appRev:     appRev,
 		gcHandler:  NewGCHandler(c, appRev.Namespace),
 	}
@@ -58,6 +60,7 @@ type AppManifestsDispatcher struct {
 	appRev     *v1beta1.ApplicationRevision
 	previousRT *v1beta1.ResourceTracker
 	skipGC     bool
+	gcResults  []common.GarbageCollectResult
 
 	appRevName    string
 	namespace     string
@@ -76,6 +79,19 @@ func (a *AppManifestsDispatcher) EndAndGC(rt *v1beta1.ResourceTracker) *AppManif
 	return a
 }
 
+// WithGCPolicy sets the garbage-collect policy which decides how the resources removed from the application are
+// garbage collected.
+func (a *AppManifestsDispatcher) WithGCPolicy(policy *types.GarbageCollectPolicySpec) *AppManifestsDispatcher {
+	a.gcHandler = NewGCHandler(a.c, a.appRev.Namespace).WithPolicy(policy)
+	return a
+}
+
+// GarbageCollectResults returns the results of the resources garbage collected by the last dispatching,
+// it's nil if no garbage collection is done.
+func (a *AppManifestsDispatcher) GarbageCollectResults() []common.GarbageCollectResult {
+	return a.gcResults
+}
+
 // StartAndSkipGC return an AppManifestsDispatcher that skips GC after dispatching resources.
 // For resources exists in two revision, dispatcher will update their owner to the new resource tracker.
 // It's helpful in a rollout scenario where new revision is going to create a new workload while the old one should not
@@ -105,7 +121,9 @@ func (a *AppManifestsDispatcher) Dispatch(ctx context.Context, manifests []*unst
 		return nil, err
 	}
 	if !a.skipGC && a.previousRT != nil && a.previousRT.Name != a.currentRTName {
-		if err := a.gcHandler.GarbageCollect(ctx, a.previousRT, a.currentRT); err != nil {
+		results, err := a.gcHandler.GarbageCollect(ctx, a.previousRT, a.currentRT)
+		a.gcResults = results
+		if err != nil {
 			return nil, errors.WithMessagef(err, "cannot do GC based on resource trackers %q and %q", a.previousRT.Name, a.currentRTName)
 		}
 	}
@@ -177,7 +195,7 @@ func (a *AppManifestsDispatcher) createOrGetResourceTracker(ctx context.Context)
 }
 
 func (a *AppManifestsDispatcher) applyAndRecordManifests(ctx context.Context, manifests []*unstructured.Unstructured) error {
-	ctrlUIDs := []types.UID{a.currentRT.UID}
+	ctrlUIDs := []k8stypes.UID{a.currentRT.UID}
 	if a.previousRT != nil && a.previousRT.Name != a.currentRTName {
 		klog.InfoS("Going to apply or upgrade resources", "from", a.previousRT.Name, "to", a.currentRTName)
 		// if two RT's names are different, it means dispatching operation happens in an upgrade or rollout scenario
@@ -187,14 +205,7 @@ func (a *AppManifestsDispatcher) applyAndRecordManifests(ctx context.Context, ma
 		ctrlUIDs = append(ctrlUIDs, a.previousRT.UID)
 	}
 	applyOpts := []apply.ApplyOption{apply.MustBeControllableByAny(ctrlUIDs)}
-	ownerRef := metav1.OwnerReference{
-		APIVersion:         v1beta1.SchemeGroupVersion.String(),
-		Kind:               reflect.TypeOf(v1beta1.ResourceTracker{}).Name(),
-		Name:               a.currentRT.Name,
-		UID:                a.currentRT.UID,
-		Controller:         pointer.BoolPtr(true),
-		BlockOwnerDeletion: pointer.BoolPtr(true),
-	}
+	ownerRef := resourceTrackerOwnerRef(a.currentRT)
 	for _, rsc := range manifests {
 
 		immutable, err := a.ImmutableResourcesUpdate(ctx, rsc, ownerRef, applyOpts)
@@ -289,6 +300,18 @@ func (a *AppManifestsDispatcher) updateResourceTrackerStatus(ctx context.Context
 	return nil
 }
 
+// resourceTrackerOwnerRef returns the controller owner reference of the resources dispatched with the resource tracker.
+func resourceTrackerOwnerRef(rt *v1beta1.ResourceTracker) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion:         v1beta1.SchemeGroupVersion.String(),
+		Kind:               reflect.TypeOf(v1beta1.ResourceTracker{}).Name(),
+		Name:               rt.Name,
+		UID:                rt.UID,
+		Controller:         pointer.BoolPtr(true),
+		BlockOwnerDeletion: pointer.BoolPtr(true),
+	}
+}
+
 // ObjectOwner is a interface for get and set ownerReference
 type ObjectOwner interface {
 	GetOwnerReferences() []metav1.OwnerReference
@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatch
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+	"github.com/oam-dev/kubevela/pkg/utils/apply"
+)
+
+// DetectDrift compares the live resources tracked by the resource tracker with the desired manifests they're
+// dispatched from, and returns the resources changed or deleted outside of the application. A resource not in the
+// manifests is compared with its last-applied-state.
+func DetectDrift(ctx context.Context, c client.Client, rt *v1beta1.ResourceTracker, manifests []*unstructured.Unstructured) ([]common.ResourceDrift, error) {
+	var drifts []common.ResourceDrift
+	for _, ref := range rt.Status.TrackedResources {
+		drift := common.ResourceDrift{
+			APIVersion: ref.APIVersion,
+			Kind:       ref.Kind,
+			Namespace:  ref.Namespace,
+			Name:       ref.Name,
+		}
+		live, err := getLiveResource(ctx, c, drift)
+		if kerrors.IsNotFound(err) {
+			drift.Missing = true
+			drifts = append(drifts, drift)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var desired runtime.Object
+		if manifest := findManifest(manifests, drift); manifest != nil {
+			desired = manifest
+		}
+		if drift.Fields, err = apply.DetectDrift(live, desired); err != nil {
+			return nil, errors.WithMessagef(err, "cannot detect drift of resource %q", ref)
+		}
+		if len(drift.Fields) != 0 {
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts, nil
+}
+
+// findManifest finds the manifest of the drifted resource, it returns nil if not found
+func findManifest(manifests []*unstructured.Unstructured, drift common.ResourceDrift) *unstructured.Unstructured {
+	for _, manifest := range manifests {
+		if manifest.GetAPIVersion() == drift.APIVersion && manifest.GetKind() == drift.Kind &&
+			manifest.GetNamespace() == drift.Namespace && manifest.GetName() == drift.Name {
+			return manifest
+		}
+	}
+	return nil
+}
+
+// HealDrift applies the desired manifest of the drifted resource again to revert the changes made outside of
+// the application, or its last-applied-state if it's not in the manifests. A deleted resource can't be healed here,
+// it's created again when the application is dispatched.
+func HealDrift(ctx context.Context, c client.Client, drift common.ResourceDrift, manifests []*unstructured.Unstructured) error {
+	if drift.Missing {
+		return nil
+	}
+	live, err := getLiveResource(ctx, c, drift)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	desired := &unstructured.Unstructured{}
+	if manifest := findManifest(manifests, drift); manifest != nil {
+		desired = manifest.DeepCopy()
+		// keep the owners set by dispatching
+		desired.SetOwnerReferences(live.GetOwnerReferences())
+	} else {
+		lastApplied, ok := live.GetAnnotations()[oam.AnnotationLastAppliedConfig]
+		if !ok {
+			return nil
+		}
+		if err := json.Unmarshal([]byte(lastApplied), &desired.Object); err != nil {
+			return errors.Wrapf(err, "cannot unmarshal last-applied-state of resource %s %s/%s", drift.Kind, drift.Namespace, drift.Name)
+		}
+	}
+	// the desired manifest carries the apply-mode annotation, so it's applied in the same mode as dispatched
+	if err := apply.NewApplicator(c).Apply(ctx, desired); err != nil {
+		return errors.WithMessagef(err, "cannot heal resource %s %s/%s", drift.Kind, drift.Namespace, drift.Name)
+	}
+	return nil
+}
+
+func getLiveResource(ctx context.Context, c client.Client, drift common.ResourceDrift) (*unstructured.Unstructured, error) {
+	live := &unstructured.Unstructured{}
+	live.SetAPIVersion(drift.APIVersion)
+	live.SetKind(drift.Kind)
+	if err := c.Get(ctx, client.ObjectKey{Namespace: drift.Namespace, Name: drift.Name}, live); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, err
+		}
+		return nil, errors.Wrapf(err, "cannot get resource %s %s/%s", drift.Kind, drift.Namespace, drift.Name)
+	}
+	return live, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/utils/apply"
+)
+
+func TestDetectAndHealDrift(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	cli := fake.NewFakeClientWithScheme(scheme)
+
+	deploy := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32Ptr(1)},
+	}
+	require.NoError(t, apply.NewAPIApplicator(cli).Apply(ctx, deploy))
+	rt := &v1beta1.ResourceTracker{}
+	rt.Status.TrackedResources = []v1beta1.TypedReference{
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"},
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "deleted"},
+	}
+
+	drifts, err := DetectDrift(ctx, cli, rt, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []common.ResourceDrift{
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "deleted", Missing: true},
+	}, drifts)
+
+	live := &appsv1.Deployment{}
+	require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, live))
+	live.Spec.Replicas = pointer.Int32Ptr(3)
+	require.NoError(t, cli.Update(ctx, live))
+
+	drifts, err = DetectDrift(ctx, cli, rt, nil)
+	require.NoError(t, err)
+	require.Len(t, drifts, 2)
+	assert.Equal(t, []string{"spec.replicas"}, drifts[0].Fields)
+
+	for _, drift := range drifts {
+		require.NoError(t, HealDrift(ctx, cli, drift, nil))
+	}
+	require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, live))
+	assert.Equal(t, int32(1), *live.Spec.Replicas)
+}
+
+func TestDetectAndHealDriftWithManifests(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	cli := fake.NewFakeClientWithScheme(scheme)
+
+	// the resources applied in server side have no last-applied-state
+	owner := metav1.OwnerReference{APIVersion: "core.oam.dev/v1beta1", Kind: "ResourceTracker", Name: "app-v1", UID: "uid"}
+	deploy := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", OwnerReferences: []metav1.OwnerReference{owner}},
+		Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32Ptr(3)},
+	}
+	require.NoError(t, cli.Create(ctx, deploy))
+	manifest := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec":       map[string]interface{}{"replicas": int64(1)},
+	}}
+	rt := &v1beta1.ResourceTracker{}
+	rt.Status.TrackedResources = []v1beta1.TypedReference{
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"},
+	}
+
+	drifts, err := DetectDrift(ctx, cli, rt, nil)
+	require.NoError(t, err)
+	assert.Empty(t, drifts)
+
+	drifts, err = DetectDrift(ctx, cli, rt, []*unstructured.Unstructured{manifest})
+	require.NoError(t, err)
+	assert.Equal(t, []common.ResourceDrift{
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web", Fields: []string{"spec.replicas"}},
+	}, drifts)
+
+	require.NoError(t, HealDrift(ctx, cli, drifts[0], []*unstructured.Unstructured{manifest}))
+	live := &appsv1.Deployment{}
+	require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, live))
+	assert.Equal(t, int32(1), *live.Spec.Replicas)
+	assert.Equal(t, []metav1.OwnerReference{owner}, live.OwnerReferences)
+}
@@ -18,76 +18,399 @@ package dispatch
 
 import (
 	"context"
+	"reflect"
 	"strings"
 
 	"github.com/pkg/errors"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/oam"
+	"github.com/oam-dev/kubevela/pkg/oam/util"
 )
 
 // GarbageCollector do GC according two resource trackers
 type GarbageCollector interface {
-	GarbageCollect(ctx context.Context, oldRT, newRT *v1beta1.ResourceTracker) error
+	GarbageCollect(ctx context.Context, oldRT, newRT *v1beta1.ResourceTracker) ([]common.GarbageCollectResult, error)
 }
 
 // NewGCHandler create a GCHandler
 func NewGCHandler(c client.Client, ns string) *GCHandler {
-	return &GCHandler{c, ns, nil, nil}
+	return &GCHandler{c: c, namespace: ns}
 }
 
 // GCHandler implement GarbageCollector interface
 type GCHandler struct {
 	c         client.Client
 	namespace string
+	policy    *types.GarbageCollectPolicySpec
 
 	oldRT *v1beta1.ResourceTracker
 	newRT *v1beta1.ResourceTracker
 }
 
-// GarbageCollect delete the old resources that are no longer in the new resource tracker
-func (h *GCHandler) GarbageCollect(ctx context.Context, oldRT, newRT *v1beta1.ResourceTracker) error {
+// WithPolicy sets the garbage-collect policy of the application, the resources are deleted at the same time
+// when they are removed from the application if the policy is nil.
+func (h *GCHandler) WithPolicy(policy *types.GarbageCollectPolicySpec) *GCHandler {
+	h.policy = policy
+	return h
+}
+
+// GarbageCollect handles the old resources that are no longer in the new resource tracker by their strategies,
+// and returns the result of every resource.
+// Failing to garbage collect a resource doesn't stop the others, the old resource tracker is kept with
+// the pending annotation until all its resources are garbage collected, so that it can be resumed later.
+func (h *GCHandler) GarbageCollect(ctx context.Context, oldRT, newRT *v1beta1.ResourceTracker) ([]common.GarbageCollectResult, error) {
 	h.oldRT = oldRT
 	h.newRT = newRT
 	if err := h.validate(); err != nil {
-		return err
+		return nil, err
 	}
 	klog.InfoS("Garbage collect for application", "old", h.oldRT.Name, "new", h.newRT.Name)
+	results := []common.GarbageCollectResult{}
+	var toKeep []*unstructured.Unstructured
+	var toDelete [3][]*unstructured.Unstructured
 	for _, oldRsc := range h.oldRT.Status.TrackedResources {
-		isRemoved := true
-		for _, newRsc := range h.newRT.Status.TrackedResources {
-			if oldRsc.APIVersion == newRsc.APIVersion && oldRsc.Kind == newRsc.Kind &&
-				oldRsc.Namespace == newRsc.Namespace && oldRsc.Name == newRsc.Name {
-				isRemoved = false
-				break
+		if isTracked(h.newRT, oldRsc) {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(oldRsc.APIVersion)
+		obj.SetKind(oldRsc.Kind)
+		if err := h.c.Get(ctx, client.ObjectKey{Namespace: oldRsc.Namespace, Name: oldRsc.Name}, obj); err != nil {
+			if kerrors.IsNotFound(err) {
+				results = append(results, gcResult(oldRsc, common.GarbageCollectResultDeleted, ""))
+				continue
 			}
+			results = append(results, gcResult(oldRsc, common.GarbageCollectResultFailed, err.Error()))
+			continue
 		}
-		if isRemoved {
-			toBeDeleted := &unstructured.Unstructured{}
-			toBeDeleted.SetAPIVersion(oldRsc.APIVersion)
-			toBeDeleted.SetKind(oldRsc.Kind)
-			toBeDeleted.SetNamespace(oldRsc.Namespace)
-			toBeDeleted.SetName(oldRsc.Name)
-			if err := h.c.Delete(ctx, toBeDeleted); err != nil && !kerrors.IsNotFound(err) {
-				klog.ErrorS(err, "Failed to delete a resource", "name", oldRsc.Name, "apiVersion", oldRsc.APIVersion, "kind", oldRsc.Kind)
-				return errors.Wrapf(err, "cannot delete resource %q", oldRsc)
+		switch strategy := h.strategyOf(obj); strategy {
+		case types.GarbageCollectStrategyOnAppDelete:
+			toKeep = append(toKeep, obj)
+		case types.GarbageCollectStrategyNever:
+			if err := orphan(ctx, h.c, obj); err != nil {
+				klog.ErrorS(err, "Failed to orphan a resource", "name", oldRsc.Name, "apiVersion", oldRsc.APIVersion, "kind", oldRsc.Kind)
+				results = append(results, gcResult(oldRsc, common.GarbageCollectResultFailed, err.Error()))
+				continue
 			}
-			klog.InfoS("Successfully GC a resource", "name", oldRsc.Name, "apiVersion", oldRsc.APIVersion, "kind", oldRsc.Kind)
+			results = append(results, gcResult(oldRsc, common.GarbageCollectResultOrphaned, ""))
+		default:
+			group := 0
+			if h.ordered() {
+				group = deleteGroup(obj)
+			}
+			toDelete[group] = append(toDelete[group], obj)
+		}
+	}
+	results = append(results, h.keep(ctx, toKeep)...)
+	results = append(results, h.delete(ctx, toDelete)...)
+
+	if !isComplete(results) {
+		klog.InfoS("Resources are not all garbage collected, keep the resource tracker", "name", h.oldRT.Name)
+		if err := markGCPending(ctx, h.c, h.oldRT); err != nil {
+			return results, err
 		}
+		return results, nil
 	}
 	// delete the old resource tracker
 	if err := h.c.Delete(ctx, h.oldRT); err != nil && !kerrors.IsNotFound(err) {
 		klog.ErrorS(err, "Failed to delete resource tracker", "name", h.oldRT.Name)
-		return errors.Wrapf(err, "cannot delete resource tracker %q", h.oldRT.Name)
+		return results, errors.Wrapf(err, "cannot delete resource tracker %q", h.oldRT.Name)
 	}
 	klog.InfoS("Successfully GC a resource tracker and its resources", "name", h.oldRT.Name)
+	return results, nil
+}
+
+// Finalize handles the resources of the resource tracker before it's deleted along with the application.
+// The resources whose strategy is never are orphaned, and the others are deleted in order if the policy requires,
+// the rest are deleted along with the resource tracker.
+// It returns false if some resources are still being deleted.
+func (h *GCHandler) Finalize(ctx context.Context, rt *v1beta1.ResourceTracker) (bool, error) {
+	var toDelete [3][]*unstructured.Unstructured
+	for _, rsc := range rt.Status.TrackedResources {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(rsc.APIVersion)
+		obj.SetKind(rsc.Kind)
+		if err := h.c.Get(ctx, client.ObjectKey{Namespace: rsc.Namespace, Name: rsc.Name}, obj); err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return false, errors.Wrapf(err, "cannot get resource %q", rsc)
+		}
+		switch h.strategyOf(obj) {
+		case types.GarbageCollectStrategyNever:
+			if err := orphan(ctx, h.c, obj); err != nil {
+				return false, err
+			}
+		default:
+			if h.ordered() {
+				group := deleteGroup(obj)
+				toDelete[group] = append(toDelete[group], obj)
+			}
+		}
+	}
+	for _, result := range h.delete(ctx, toDelete) {
+		if result.Result == common.GarbageCollectResultFailed {
+			return false, errors.Errorf("cannot delete resource %s %s/%s: %s", result.Kind, result.Namespace, result.Name, result.Message)
+		}
+		if result.Result == common.GarbageCollectResultPending {
+			return false, nil
+		}
+	}
+	return true, nil
+}
 
+// strategyOf returns the strategy of the resource, the annotation of the resource takes precedence over the policy.
+func (h *GCHandler) strategyOf(obj *unstructured.Unstructured) types.GarbageCollectStrategy {
+	if strategy := types.GarbageCollectStrategy(obj.GetAnnotations()[oam.AnnotationGCStrategy]); types.IsGarbageCollectStrategy(strategy) {
+		return strategy
+	}
+	if h.policy == nil {
+		return types.GarbageCollectStrategyOnAppUpdate
+	}
+	for _, rule := range h.policy.Rules {
+		if selectResource(rule.Selector, obj) {
+			return rule.Strategy
+		}
+	}
+	if h.policy.KeepLegacyResource {
+		return types.GarbageCollectStrategyOnAppDelete
+	}
+	return types.GarbageCollectStrategyOnAppUpdate
+}
+
+func (h *GCHandler) ordered() bool {
+	return h.policy != nil && h.policy.Order == types.GarbageCollectOrderDependency
+}
+
+// keep moves the resources to the new resource tracker, so that they are deleted along with the application.
+func (h *GCHandler) keep(ctx context.Context, objs []*unstructured.Unstructured) []common.GarbageCollectResult {
+	if len(objs) == 0 {
+		return nil
+	}
+	results := make([]common.GarbageCollectResult, 0, len(objs))
+	ownerRef := resourceTrackerOwnerRef(h.newRT)
+	var kept []v1beta1.TypedReference
+	for _, obj := range objs {
+		ref := typedReference(obj)
+		removeOwner(obj, h.oldRT.UID)
+		setOrOverrideControllerOwner(obj, ownerRef)
+		if err := h.c.Update(ctx, obj); err != nil {
+			klog.ErrorS(err, "Failed to keep a resource", "name", ref.Name, "apiVersion", ref.APIVersion, "kind", ref.Kind)
+			results = append(results, gcResult(ref, common.GarbageCollectResultFailed, err.Error()))
+			continue
+		}
+		kept = append(kept, ref)
+	}
+	if len(kept) == 0 {
+		return results
+	}
+	rt := h.newRT.DeepCopy()
+	if err := retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		if err = h.c.Get(ctx, client.ObjectKey{Name: h.newRT.Name}, rt); err != nil {
+			return
+		}
+		for _, ref := range kept {
+			if !isTracked(rt, ref) {
+				rt.Status.TrackedResources = append(rt.Status.TrackedResources, ref)
+			}
+		}
+		return h.c.Status().Update(ctx, rt)
+	}); err != nil {
+		klog.ErrorS(err, "Failed to track kept resources", "resourceTracker", h.newRT.Name)
+		for _, ref := range kept {
+			results = append(results, gcResult(ref, common.GarbageCollectResultFailed, err.Error()))
+		}
+		return results
+	}
+	h.newRT.Status = rt.Status
+	for _, ref := range kept {
+		results = append(results, gcResult(ref, common.GarbageCollectResultKept, ""))
+	}
+	return results
+}
+
+// delete deletes the groups of resources in order, a group is deleted only after the groups before it are gone.
+func (h *GCHandler) delete(ctx context.Context, groups [3][]*unstructured.Unstructured) []common.GarbageCollectResult {
+	var results []common.GarbageCollectResult
+	var opts []client.DeleteOption
+	if h.ordered() {
+		// wait for the dependents of a workload to be deleted, e.g., the pods of a deployment
+		opts = append(opts, client.PropagationPolicy(metav1.DeletePropagationForeground))
+	}
+	blocked := false
+	for _, group := range groups {
+		if blocked {
+			for _, obj := range group {
+				results = append(results, gcResult(typedReference(obj), common.GarbageCollectResultPending,
+					"waiting for the resources deleted before it"))
+			}
+			continue
+		}
+		for _, obj := range group {
+			ref := typedReference(obj)
+			result, err := deleteResource(ctx, h.c, obj, opts...)
+			if err != nil {
+				klog.ErrorS(err, "Failed to delete a resource", "name", ref.Name, "apiVersion", ref.APIVersion, "kind", ref.Kind)
+				results = append(results, gcResult(ref, common.GarbageCollectResultFailed, err.Error()))
+				blocked = h.ordered()
+				continue
+			}
+			if result == common.GarbageCollectResultPending {
+				results = append(results, gcResult(ref, result, "being deleted"))
+				blocked = h.ordered()
+				continue
+			}
+			klog.InfoS("Successfully GC a resource", "name", ref.Name, "apiVersion", ref.APIVersion, "kind", ref.Kind)
+			results = append(results, gcResult(ref, result, ""))
+		}
+	}
+	return results
+}
+
+// deleteResource deletes the resource, the result is pending if it still exists after deletion.
+func deleteResource(ctx context.Context, c client.Client, obj *unstructured.Unstructured, opts ...client.DeleteOption) (common.GarbageCollectResultType, error) {
+	if obj.GetDeletionTimestamp() == nil {
+		if err := c.Delete(ctx, obj, opts...); err != nil {
+			if kerrors.IsNotFound(err) {
+				return common.GarbageCollectResultDeleted, nil
+			}
+			return common.GarbageCollectResultFailed, errors.Wrapf(err, "cannot delete resource %q", typedReference(obj))
+		}
+		if len(opts) == 0 {
+			return common.GarbageCollectResultDeleted, nil
+		}
+	}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}, obj.DeepCopy()); err != nil {
+		if kerrors.IsNotFound(err) {
+			return common.GarbageCollectResultDeleted, nil
+		}
+		return common.GarbageCollectResultFailed, errors.Wrapf(err, "cannot get resource %q", typedReference(obj))
+	}
+	return common.GarbageCollectResultPending, nil
+}
+
+// orphan removes the resource trackers from the owners of the resource, so that it's not deleted with them.
+func orphan(ctx context.Context, c client.Client, obj *unstructured.Unstructured) error {
+	var owners []metav1.OwnerReference
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.Kind == reflect.TypeOf(v1beta1.ResourceTracker{}).Name() && owner.APIVersion == v1beta1.SchemeGroupVersion.String() {
+			continue
+		}
+		owners = append(owners, owner)
+	}
+	if len(owners) == len(obj.GetOwnerReferences()) {
+		return nil
+	}
+	obj.SetOwnerReferences(owners)
+	return errors.Wrapf(c.Update(ctx, obj), "cannot orphan resource %q", typedReference(obj))
+}
+
+// markGCPending annotates the resource tracker whose resources are not all garbage collected.
+func markGCPending(ctx context.Context, c client.Client, rt *v1beta1.ResourceTracker) error {
+	if rt.GetAnnotations()[oam.AnnotationGCPending] == "true" {
+		return nil
+	}
+	patch := client.MergeFrom(rt.DeepCopy())
+	util.AddAnnotations(rt, map[string]string{oam.AnnotationGCPending: "true"})
+	if err := c.Patch(ctx, rt, patch); err != nil {
+		klog.ErrorS(err, "Failed to mark resource tracker pending", "name", rt.Name)
+		return errors.Wrapf(err, "cannot mark resource tracker %q pending", rt.Name)
+	}
 	return nil
 }
 
+// selectResource checks whether the resource is selected by any condition of the selector.
+func selectResource(selector types.ResourceSelector, obj *unstructured.Unstructured) bool {
+	labels := obj.GetLabels()
+	for _, name := range selector.ComponentNames {
+		if labels[oam.LabelAppComponent] == name {
+			return true
+		}
+	}
+	for _, traitType := range selector.TraitTypes {
+		if labels[oam.TraitTypeLabel] == traitType {
+			return true
+		}
+	}
+	for _, kind := range selector.ResourceTypes {
+		if obj.GetKind() == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteGroup returns the group of the resource in the dependency order: workloads, traits, and the other resources.
+func deleteGroup(obj *unstructured.Unstructured) int {
+	switch obj.GetLabels()[oam.LabelOAMResourceType] {
+	case oam.ResourceTypeWorkload:
+		return 0
+	case oam.ResourceTypeTrait:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func isComplete(results []common.GarbageCollectResult) bool {
+	for _, result := range results {
+		if result.Result == common.GarbageCollectResultPending || result.Result == common.GarbageCollectResultFailed {
+			return false
+		}
+	}
+	return true
+}
+
+func isTracked(rt *v1beta1.ResourceTracker, ref v1beta1.TypedReference) bool {
+	for _, tracked := range rt.Status.TrackedResources {
+		if tracked.APIVersion == ref.APIVersion && tracked.Kind == ref.Kind &&
+			tracked.Namespace == ref.Namespace && tracked.Name == ref.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func removeOwner(obj *unstructured.Unstructured, uid k8stypes.UID) {
+	var owners []metav1.OwnerReference
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.UID != uid {
+			owners = append(owners, owner)
+		}
+	}
+	obj.SetOwnerReferences(owners)
+}
+
+func typedReference(obj *unstructured.Unstructured) v1beta1.TypedReference {
+	return v1beta1.TypedReference{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	}
+}
+
+func gcResult(ref v1beta1.TypedReference, result common.GarbageCollectResultType, message string) common.GarbageCollectResult {
+	return common.GarbageCollectResult{
+		APIVersion: ref.APIVersion,
+		Kind:       ref.Kind,
+		Namespace:  ref.Namespace,
+		Name:       ref.Name,
+		Result:     result,
+		Message:    message,
+	}
+}
+
 // validate two resource trackers come from the same application
 func (h *GCHandler) validate() error {
 	oldRTName := h.oldRT.Name
@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+func TestGarbageCollectWithPolicy(t *testing.T) {
+	ctx := context.Background()
+	oldRT := &v1beta1.ResourceTracker{ObjectMeta: metav1.ObjectMeta{Name: "app-v1-default", UID: "v1"}}
+	newRT := &v1beta1.ResourceTracker{ObjectMeta: metav1.ObjectMeta{Name: "app-v2-default", UID: "v2"}}
+	owner := []metav1.OwnerReference{resourceTrackerOwnerRef(oldRT)}
+	objectMeta := func(name, resourceType string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "default", OwnerReferences: owner,
+			Labels: map[string]string{oam.LabelOAMResourceType: resourceType}}
+	}
+	deploy := &appsv1.Deployment{ObjectMeta: objectMeta("web", oam.ResourceTypeWorkload)}
+	svc := &corev1.Service{ObjectMeta: objectMeta("web", oam.ResourceTypeTrait)}
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: objectMeta("data", oam.ResourceTypeTrait)}
+	cm := &corev1.ConfigMap{ObjectMeta: objectMeta("config", oam.ResourceTypeTrait)}
+	cm.SetAnnotations(map[string]string{oam.AnnotationGCStrategy: string(types.GarbageCollectStrategyOnAppDelete)})
+	oldRT.Status.TrackedResources = []v1beta1.TypedReference{
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"},
+		{APIVersion: "v1", Kind: "Service", Namespace: "default", Name: "web"},
+		{APIVersion: "v1", Kind: "PersistentVolumeClaim", Namespace: "default", Name: "data"},
+		{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "config"},
+	}
+	policy := &types.GarbageCollectPolicySpec{
+		Order: types.GarbageCollectOrderDependency,
+		Rules: []types.GarbageCollectPolicyRule{{
+			Selector: types.ResourceSelector{ResourceTypes: []string{"PersistentVolumeClaim"}},
+			Strategy: types.GarbageCollectStrategyNever,
+		}},
+	}
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1beta1.SchemeBuilder.AddToScheme(scheme))
+
+	t.Run("keep, orphan and delete in order", func(t *testing.T) {
+		cli := fake.NewFakeClientWithScheme(scheme, oldRT.DeepCopy(), newRT.DeepCopy(), deploy.DeepCopy(), svc.DeepCopy(), pvc.DeepCopy(), cm.DeepCopy())
+		rt := newRT.DeepCopy()
+		results, err := NewGCHandler(cli, "default").WithPolicy(policy).GarbageCollect(ctx, oldRT.DeepCopy(), rt)
+		require.NoError(t, err)
+		got := map[string]common.GarbageCollectResultType{}
+		for _, r := range results {
+			got[r.Kind] = r.Result
+		}
+		assert.Equal(t, map[string]common.GarbageCollectResultType{
+			"Deployment":            common.GarbageCollectResultDeleted,
+			"Service":               common.GarbageCollectResultDeleted,
+			"PersistentVolumeClaim": common.GarbageCollectResultOrphaned,
+			"ConfigMap":             common.GarbageCollectResultKept,
+		}, got)
+
+		assert.True(t, kerrors.IsNotFound(cli.Get(ctx, client.ObjectKey{Name: oldRT.Name}, &v1beta1.ResourceTracker{})))
+		gotPVC := &corev1.PersistentVolumeClaim{}
+		require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "data"}, gotPVC))
+		assert.Empty(t, gotPVC.OwnerReferences)
+		gotCM := &corev1.ConfigMap{}
+		require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "config"}, gotCM))
+		assert.Equal(t, newRT.UID, gotCM.OwnerReferences[0].UID)
+		gotRT := &v1beta1.ResourceTracker{}
+		require.NoError(t, cli.Get(ctx, client.ObjectKey{Name: newRT.Name}, gotRT))
+		assert.Equal(t, []v1beta1.TypedReference{oldRT.Status.TrackedResources[3]}, gotRT.Status.TrackedResources)
+	})
+
+	t.Run("wait for the workload being deleted", func(t *testing.T) {
+		deleting := deploy.DeepCopy()
+		now := metav1.Now()
+		deleting.SetDeletionTimestamp(&now)
+		cli := fake.NewFakeClientWithScheme(scheme, oldRT.DeepCopy(), newRT.DeepCopy(), deleting, svc.DeepCopy())
+		results, err := NewGCHandler(cli, "default").WithPolicy(policy).GarbageCollect(ctx, oldRT.DeepCopy(), newRT.DeepCopy())
+		require.NoError(t, err)
+		for _, r := range results {
+			if r.Kind == "Deployment" || r.Kind == "Service" {
+				assert.Equal(t, common.GarbageCollectResultPending, r.Result, r.Kind)
+			}
+		}
+		require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, &corev1.Service{}))
+		gotRT := &v1beta1.ResourceTracker{}
+		require.NoError(t, cli.Get(ctx, client.ObjectKey{Name: oldRT.Name}, gotRT))
+		assert.Equal(t, "true", gotRT.Annotations[oam.AnnotationGCPending])
+	})
+
+	t.Run("finalize orphans resources", func(t *testing.T) {
+		cli := fake.NewFakeClientWithScheme(scheme, oldRT.DeepCopy(), deploy.DeepCopy(), svc.DeepCopy(), pvc.DeepCopy())
+		done, err := NewGCHandler(cli, "default").WithPolicy(policy).Finalize(ctx, oldRT.DeepCopy())
+		require.NoError(t, err)
+		assert.True(t, done)
+		assert.True(t, kerrors.IsNotFound(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, &appsv1.Deployment{})))
+		gotPVC := &corev1.PersistentVolumeClaim{}
+		require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "data"}, gotPVC))
+		assert.Empty(t, gotPVC.OwnerReferences)
+	})
+}
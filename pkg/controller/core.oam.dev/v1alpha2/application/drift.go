@@ -0,0 +1,121 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	velatypes "github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/application/dispatch"
+	"github.com/oam-dev/kubevela/pkg/controller/utils"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+// DetectDrift compares the live resources of a running application with the ones applied by the current app revision
+// in every cluster. The drifted resources are reverted at once if auto-heal is enabled, otherwise they're recorded in
+// the application status and reported by an event. Detecting drift doesn't change dispatching, the resources are
+// applied again when the application is dispatched either way.
+func (h *AppHandler) DetectDrift(ctx context.Context, comps []*velatypes.ComponentManifest) error {
+	if h.isNewRevision {
+		h.app.Status.Drift = nil
+	}
+	if h.r.driftDetectionInterval <= 0 || !h.wasRunning || h.isNewRevision ||
+		h.app.Status.LatestRevision == nil || h.app.Status.LatestRevision.Name != h.currentAppRev.Name {
+		return nil
+	}
+	autoHeal := h.app.GetAnnotations()[oam.AnnotationAutoHeal] == "true"
+	var drifts []common.ResourceDrift
+	for _, p := range h.clusterPlacements() {
+		rt, err := getResourceTracker(ctx, p.Client, h.currentAppRev.Name, h.app.Namespace)
+		if err != nil {
+			return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot detect drift"))
+		}
+		if rt == nil {
+			continue
+		}
+		manifests, err := h.desiredManifests(ctx, p, comps)
+		if err != nil {
+			return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot assemble application manifests"))
+		}
+		clusterDrifts, err := dispatch.DetectDrift(ctx, p.Client, rt, manifests)
+		if err != nil {
+			return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot detect drift"))
+		}
+		for _, drift := range clusterDrifts {
+			drift.Cluster = p.Cluster
+			if autoHeal && !drift.Missing {
+				if err := dispatch.HealDrift(ctx, p.Client, drift, manifests); err != nil {
+					return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot heal drift"))
+				}
+				klog.InfoS("Heal drifted resource", "application", klog.KObj(h.app), "cluster", p.Cluster,
+					"kind", drift.Kind, "resource", klog.KRef(drift.Namespace, drift.Name), "fields", drift.Fields)
+				h.r.Recorder.Event(h.app, event.Normal(velatypes.ReasonDriftHealed,
+					clusterMessage(p.Cluster, fmt.Sprintf("reverted %s", driftMessage(drift)))))
+				continue
+			}
+			drifts = append(drifts, drift)
+		}
+	}
+	h.app.Status.Drift = drifts
+	if len(drifts) == 0 {
+		h.app.Status.SetConditions(utils.ReadyCondition("DriftCheck"))
+		return nil
+	}
+	messages := make([]string, 0, len(drifts))
+	for _, drift := range drifts {
+		messages = append(messages, clusterMessage(drift.Cluster, driftMessage(drift)))
+	}
+	err := errors.Errorf("resources changed outside of the application: %s", strings.Join(messages, "; "))
+	klog.InfoS("Detect drifted resources", "application", klog.KObj(h.app), "drift", err.Error())
+	h.r.Recorder.Event(h.app, event.Warning(velatypes.ReasonDriftDetected, err))
+	h.app.Status.SetConditions(utils.ErrorCondition("DriftCheck", err))
+	return nil
+}
+
+// desiredManifests assembles the manifests dispatched to the cluster by the current app revision, so that the drift
+// is detected against them. It returns nil if the resources are not dispatched by the application controller directly,
+// their last-applied-state is compared then.
+func (h *AppHandler) desiredManifests(ctx context.Context, p clusterPlacement, comps []*velatypes.ComponentManifest) ([]*unstructured.Unstructured, error) {
+	if (h.app.Spec.Workflow != nil && len(h.app.Spec.Workflow.Steps) > 0) || h.app.Annotations[oam.AnnotationAppRevisionOnly] == "true" ||
+		appWillRollout(h.app) {
+		return nil, nil
+	}
+	var manifests []*unstructured.Unstructured
+	for _, comp := range p.components(comps) {
+		manifests = append(manifests, comp.PackagedWorkloadResources...)
+	}
+	assembled, err := p.assembleManifests(ctx, h.currentAppRev).AssembledManifests()
+	if err != nil {
+		return nil, err
+	}
+	return append(manifests, assembled...), nil
+}
+
+func driftMessage(drift common.ResourceDrift) string {
+	if drift.Missing {
+		return fmt.Sprintf("%s %s is deleted", drift.Kind, drift.Name)
+	}
+	return fmt.Sprintf("%s %s fields %s", drift.Kind, drift.Name, strings.Join(drift.Fields, ","))
+}
@@ -0,0 +1,344 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/appfile"
+	"github.com/oam-dev/kubevela/pkg/clustermanager"
+	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/application/assemble"
+	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/application/dispatch"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+// hostCluster is the name of the placement in the cluster where the controller runs
+const hostCluster = ""
+
+// clusterPlacement is a cluster the application is dispatched to.
+// Every cluster has its own resource trackers, so that the resources are garbage collected in the cluster
+// they are dispatched to.
+type clusterPlacement struct {
+	Cluster string
+	Client  client.Client
+
+	// AppFile and Components are the application rendered with the override policies of the cluster,
+	// they are nil if the application has no override policy.
+	AppFile    *appfile.Appfile
+	Components []*types.ComponentManifest
+}
+
+// assembleManifests returns the AppManifests to assemble the resources of the app revision for the cluster.
+func (p clusterPlacement) assembleManifests(ctx context.Context, appRev *v1beta1.ApplicationRevision) *assemble.AppManifests {
+	a := assemble.NewAppManifests(appRev).WithWorkloadOption(assemble.DiscoveryHelmBasedWorkload(ctx, p.Client))
+	if p.Components != nil {
+		a.WithComponentManifests(p.Components)
+	}
+	return a
+}
+
+// appFile returns the application rendered for the cluster.
+func (p clusterPlacement) appFile(af *appfile.Appfile) *appfile.Appfile {
+	if p.AppFile != nil {
+		return p.AppFile
+	}
+	return af
+}
+
+// components returns the component manifests to dispatch to the cluster.
+func (p clusterPlacement) components(comps []*types.ComponentManifest) []*types.ComponentManifest {
+	if p.Components != nil {
+		return p.Components
+	}
+	return comps
+}
+
+// PreparePlacements selects the clusters to dispatch the application to by the topology policy.
+// The application is dispatched to the host cluster if there is no topology policy.
+func (h *AppHandler) PreparePlacements(ctx context.Context, topology *types.TopologyPolicySpec) error {
+	if topology == nil {
+		h.placements = []clusterPlacement{{Cluster: hostCluster, Client: h.r.Client}}
+		return nil
+	}
+	clusters, err := clustermanager.SelectClusters(ctx, h.r.Client, h.app.Namespace, topology.Clusters, topology.ClusterSelector)
+	if err != nil {
+		return err
+	}
+	if len(clusters) == 0 {
+		return errors.New("no cluster is selected by the topology policy")
+	}
+	placements := make([]clusterPlacement, 0, len(clusters))
+	h.unreadyClusters = nil
+	for i := range clusters {
+		if ready, err := clustermanager.CheckClusterReady(ctx, h.r.Client, &clusters[i]); !ready {
+			klog.InfoS("Skip dispatching to a cluster which is not ready", "application", klog.KObj(h.app),
+				"cluster", clusters[i].Name, "reason", err.Error())
+			h.unreadyClusters = append(h.unreadyClusters, clusters[i].Name)
+			continue
+		}
+		cli, err := clustermanager.GetClusterClient(ctx, h.r.Client, &clusters[i])
+		if err != nil {
+			return err
+		}
+		placements = append(placements, clusterPlacement{Cluster: clusters[i].Name, Client: cli})
+	}
+	if len(placements) == 0 {
+		return errors.Errorf("none of the selected clusters %v is ready", h.unreadyClusters)
+	}
+	h.placements = placements
+	return nil
+}
+
+// RenderPlacements renders the application for every cluster with the override policies of the cluster applied.
+// The component revisions of the overridden components are the same as the ones in the current app revision.
+func (h *AppHandler) RenderPlacements(ctx context.Context, parser *appfile.Parser, af *appfile.Appfile, comps []*types.ComponentManifest) error {
+	if len(af.Overrides) == 0 {
+		return nil
+	}
+	for i := range h.placements {
+		p := &h.placements[i]
+		var err error
+		p.AppFile, p.Components, err = renderForCluster(ctx, parser, h.app, af.RevisionName, comps, p.Cluster)
+		if err != nil {
+			return errors.WithMessage(err, clusterMessage(p.Cluster, "cannot render application"))
+		}
+	}
+	return nil
+}
+
+func renderForCluster(ctx context.Context, parser *appfile.Parser, app *v1beta1.Application, revisionName string,
+	comps []*types.ComponentManifest, cluster string) (*appfile.Appfile, []*types.ComponentManifest, error) {
+	af, err := parser.GenerateAppFileForCluster(ctx, app, cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+	af.RevisionName = revisionName
+	clusterComps, err := af.GenerateComponentManifests()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, clusterComp := range clusterComps {
+		for _, comp := range comps {
+			if comp.Name == clusterComp.Name {
+				clusterComp.RevisionName = comp.RevisionName
+				clusterComp.RevisionHash = comp.RevisionHash
+				break
+			}
+		}
+	}
+	return af, clusterComps, nil
+}
+
+// clusterPlacements returns the clusters to dispatch the application to,
+// it's the host cluster if the placements are not prepared.
+func (h *AppHandler) clusterPlacements() []clusterPlacement {
+	if len(h.placements) == 0 {
+		return []clusterPlacement{{Cluster: hostCluster, Client: h.r.Client}}
+	}
+	return h.placements
+}
+
+// placedClusters returns the names of the clusters the application is dispatched to,
+// it's empty if the application is only dispatched to the host cluster.
+// The selected clusters which are not ready are kept, so that the resources in them are not garbage collected.
+func (h *AppHandler) placedClusters() []string {
+	var clusters []string
+	for _, p := range h.clusterPlacements() {
+		if p.Cluster != hostCluster {
+			clusters = append(clusters, p.Cluster)
+		}
+	}
+	for _, cluster := range h.unreadyClusters {
+		if h.wasPlaced(cluster) {
+			clusters = append(clusters, cluster)
+		}
+	}
+	sort.Strings(clusters)
+	return clusters
+}
+
+func (h *AppHandler) wasPlaced(cluster string) bool {
+	for _, c := range h.app.Status.Clusters {
+		if c == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// recordClusters records the selected clusters in the application status before the application is dispatched
+// to them, so that the resources in them are deleted along with the application even if the workflow never finishes.
+// The clusters which are no longer selected are kept until they're garbage collected by garbageCollectClusters.
+func (h *AppHandler) recordClusters(ctx context.Context) error {
+	clusters := append([]string{}, h.app.Status.Clusters...)
+	for _, p := range h.clusterPlacements() {
+		if p.Cluster != hostCluster && !h.wasPlaced(p.Cluster) {
+			clusters = append(clusters, p.Cluster)
+		}
+	}
+	if len(clusters) == len(h.app.Status.Clusters) {
+		return nil
+	}
+	sort.Strings(clusters)
+	data, err := json.Marshal(map[string]interface{}{"status": map[string]interface{}{"clusters": clusters}})
+	if err != nil {
+		return err
+	}
+	// the status is patched on a copy, so that the status in progress is not overwritten by the response
+	app := h.app.DeepCopy()
+	if err := h.r.Client.Status().Patch(ctx, app, client.RawPatch(ktypes.MergePatchType, data)); err != nil {
+		return errors.WithMessage(err, "cannot record the selected clusters")
+	}
+	h.app.Status.Clusters = clusters
+	h.app.ResourceVersion = app.ResourceVersion
+	return nil
+}
+
+// garbageCollectClusters deletes the resources of the application in the clusters
+// which are no longer selected, and records the selected clusters in the application status.
+func (h *AppHandler) garbageCollectClusters(ctx context.Context) error {
+	// the host cluster is not recorded in the status, so it's always checked if it's not selected
+	previous := append([]string{hostCluster}, h.app.Status.Clusters...)
+	for _, cluster := range previous {
+		if h.isPlaced(cluster) {
+			continue
+		}
+		cli, err := h.getClusterClient(ctx, cluster)
+		if kerrors.IsNotFound(err) {
+			klog.InfoS("Skip garbage collecting resources in a deleted cluster", "application", klog.KObj(h.app), "cluster", cluster)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := deleteResourceTrackers(ctx, cli, h.app, h.gcPolicy); err != nil {
+			return errors.WithMessagef(err, "cannot garbage collect resources in cluster %s", cluster)
+		}
+		klog.InfoS("Garbage collect resources in a cluster no longer selected", "application", klog.KObj(h.app), "cluster", cluster)
+	}
+	h.app.Status.Clusters = h.placedClusters()
+	return nil
+}
+
+// isPlaced checks whether the cluster is still selected by the topology policy.
+func (h *AppHandler) isPlaced(cluster string) bool {
+	for _, p := range h.clusterPlacements() {
+		if p.Cluster == cluster {
+			return true
+		}
+	}
+	for _, c := range h.unreadyClusters {
+		if c == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *AppHandler) getClusterClient(ctx context.Context, name string) (client.Client, error) {
+	return getClusterClient(ctx, h.r.Client, h.app.Namespace, name)
+}
+
+func getClusterClient(ctx context.Context, cli client.Client, ns, name string) (client.Client, error) {
+	if name == hostCluster {
+		return cli, nil
+	}
+	cluster := &v1beta1.Cluster{}
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: ns, Name: name}, cluster); err != nil {
+		return nil, err
+	}
+	return clustermanager.GetClusterClient(ctx, cli, cluster)
+}
+
+// getResourceTracker returns the resource tracker of the app revision in the cluster, it's nil if not found.
+func getResourceTracker(ctx context.Context, cli client.Client, appRevName, ns string) (*v1beta1.ResourceTracker, error) {
+	rt := &v1beta1.ResourceTracker{}
+	if err := cli.Get(ctx, client.ObjectKey{Name: dispatch.ConstructResourceTrackerName(appRevName, ns)}, rt); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.WithMessage(err, "cannot get resource tracker")
+	}
+	return rt, nil
+}
+
+// deleteResourceTrackers deletes all resource trackers of the application in the cluster,
+// the resources they control are deleted along with them by the garbage-collect policy.
+func deleteResourceTrackers(ctx context.Context, cli client.Client, app *v1beta1.Application, gcPolicy *types.GarbageCollectPolicySpec) error {
+	rtList := &v1beta1.ResourceTrackerList{}
+	if err := cli.List(ctx, rtList, client.MatchingLabels{
+		oam.LabelAppName:      app.Name,
+		oam.LabelAppNamespace: app.Namespace,
+	}); err != nil {
+		return errors.WithMessage(err, "cannot list resource trackers")
+	}
+	for i := range rtList.Items {
+		if err := finalizeResourceTracker(ctx, cli, app, &rtList.Items[i], gcPolicy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finalizeHostResourceTrackers deletes the resource tracker of the latest app revision in the host cluster,
+// and the ones whose garbage collection is pending.
+func finalizeHostResourceTrackers(ctx context.Context, cli client.Client, app *v1beta1.Application, gcPolicy *types.GarbageCollectPolicySpec) error {
+	rtList := &v1beta1.ResourceTrackerList{}
+	if err := cli.List(ctx, rtList, client.MatchingLabels{
+		oam.LabelAppName:      app.Name,
+		oam.LabelAppNamespace: app.Namespace,
+	}); err != nil {
+		return errors.WithMessage(err, "cannot list resource trackers")
+	}
+	latestTrackerName := dispatch.ConstructResourceTrackerName(app.Status.LatestRevision.Name, app.Namespace)
+	for i, rt := range rtList.Items {
+		if rt.Name != latestTrackerName && rt.GetAnnotations()[oam.AnnotationGCPending] != "true" {
+			continue
+		}
+		if err := finalizeResourceTracker(ctx, cli, app, &rtList.Items[i], gcPolicy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finalizeResourceTracker orphans or deletes in order the resources of the resource tracker by the garbage-collect
+// policy, and then deletes the resource tracker.
+func finalizeResourceTracker(ctx context.Context, cli client.Client, app *v1beta1.Application, rt *v1beta1.ResourceTracker,
+	gcPolicy *types.GarbageCollectPolicySpec) error {
+	done, err := dispatch.NewGCHandler(cli, app.Namespace).WithPolicy(gcPolicy).Finalize(ctx, rt)
+	if err != nil {
+		return errors.WithMessagef(err, "cannot garbage collect resources of resource tracker %s", rt.Name)
+	}
+	if !done {
+		return errors.Errorf("waiting for the resources of resource tracker %s to be deleted", rt.Name)
+	}
+	if err := cli.Delete(ctx, rt); err != nil && !kerrors.IsNotFound(err) {
+		return errors.WithMessagef(err, "cannot delete resource tracker %s", rt.Name)
+	}
+	return nil
+}
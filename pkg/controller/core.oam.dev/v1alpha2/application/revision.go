@@ -157,7 +157,14 @@ func (h *AppHandler) gatherRevisionSpec(af *appfile.Appfile) (*v1beta1.Applicati
 		if w.FullTemplate.ComponentDefinition != nil {
 			cd := w.FullTemplate.ComponentDefinition.DeepCopy()
 			cd.Status = v1beta1.ComponentDefinitionStatus{}
-			appRev.Spec.ComponentDefinitions[w.FullTemplate.ComponentDefinition.Name] = *cd
+			// key by the type instead of the definition name, so that the revisions of a definition are kept apart
+			appRev.Spec.ComponentDefinitions[w.Type] = *cd
+			if w.FullTemplate.DefinitionRevision != nil {
+				if appRev.Spec.ComponentDefinitionRevisions == nil {
+					appRev.Spec.ComponentDefinitionRevisions = make(map[string]common.Revision)
+				}
+				appRev.Spec.ComponentDefinitionRevisions[w.Type] = *w.FullTemplate.DefinitionRevision
+			}
 		}
 		if w.FullTemplate.WorkloadDefinition != nil {
 			wd := w.FullTemplate.WorkloadDefinition.DeepCopy()
@@ -171,7 +178,13 @@ func (h *AppHandler) gatherRevisionSpec(af *appfile.Appfile) (*v1beta1.Applicati
 			if t.FullTemplate.TraitDefinition != nil {
 				td := t.FullTemplate.TraitDefinition.DeepCopy()
 				td.Status = v1beta1.TraitDefinitionStatus{}
-				appRev.Spec.TraitDefinitions[t.FullTemplate.TraitDefinition.Name] = *td
+				appRev.Spec.TraitDefinitions[t.Name] = *td
+				if t.FullTemplate.DefinitionRevision != nil {
+					if appRev.Spec.TraitDefinitionRevisions == nil {
+						appRev.Spec.TraitDefinitionRevisions = make(map[string]common.Revision)
+					}
+					appRev.Spec.TraitDefinitionRevisions[t.Name] = *t.FullTemplate.DefinitionRevision
+				}
 			}
 		}
 		// TODO(wonderflow): take scope into the revision
@@ -33,6 +33,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1alpha2"
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
@@ -218,6 +219,25 @@ var _ = Describe("test generate revision ", func() {
 		verifyNotEqual()
 	})
 
+	It("Test gather revision spec records the definition revisions", func() {
+		cdRev := common.Revision{Name: "webserver-v2", Revision: 2, RevisionHash: "cd-hash"}
+		tdRev := common.Revision{Name: td.Name + "-v1", Revision: 1, RevisionHash: "td-hash"}
+		af := &appfile.Appfile{Workloads: []*appfile.Workload{{
+			Type:         "webserver-v2",
+			FullTemplate: &appfile.Template{ComponentDefinition: webCompDef.DeepCopy(), DefinitionRevision: &cdRev},
+			Traits: []*appfile.Trait{{
+				Name:         td.Name + "-v1",
+				FullTemplate: &appfile.Template{TraitDefinition: td.DeepCopy(), DefinitionRevision: &tdRev},
+			}},
+		}}}
+		appRev, _, err := handler.gatherRevisionSpec(af)
+		Expect(err).Should(Succeed())
+		Expect(appRev.Spec.ComponentDefinitions).Should(HaveKey("webserver-v2"))
+		Expect(appRev.Spec.TraitDefinitions).Should(HaveKey(td.Name + "-v1"))
+		Expect(appRev.Spec.ComponentDefinitionRevisions).Should(Equal(map[string]common.Revision{"webserver-v2": cdRev}))
+		Expect(appRev.Spec.TraitDefinitionRevisions).Should(Equal(map[string]common.Revision{td.Name + "-v1": tdRev}))
+	})
+
 	It("Test apply success for none rollout case", func() {
 		By("Apply the application")
 		appParser := appfile.NewApplicationParser(reconciler.Client, reconciler.dm, reconciler.pd)
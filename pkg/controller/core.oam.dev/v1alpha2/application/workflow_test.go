@@ -37,6 +37,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	oamcore "github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/application/dispatch"
 	"github.com/oam-dev/kubevela/pkg/oam/util"
 	"github.com/oam-dev/kubevela/pkg/workflow"
 )
@@ -157,6 +159,56 @@ var _ = Describe("Test Workflow", func() {
 			Namespace: appWithWorkflow.Namespace,
 		}, step2obj)).Should(BeNil())
 	})
+
+	It("should take over and garbage collect the resources of the previous revision with apply steps", func() {
+		app := &oamcore.Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-wf-upgrade",
+				Namespace: namespace,
+			},
+			Spec: oamcore.ApplicationSpec{
+				Components: []oamcore.ApplicationComponent{{
+					Name:       "wf-web",
+					Type:       "worker",
+					Properties: runtime.RawExtension{Raw: []byte(`{"cmd":["sleep","1000"],"image":"busybox"}`)},
+				}, {
+					Name:       "wf-backend",
+					Type:       "worker",
+					Properties: runtime.RawExtension{Raw: []byte(`{"cmd":["sleep","1000"],"image":"busybox"}`)},
+				}},
+				Workflow: &oamcore.Workflow{
+					Steps: []oamcore.WorkflowStep{{
+						Name: "apply",
+						Type: types.WorkflowStepTypeApplyApplication,
+					}},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, app)).Should(BeNil())
+		tryReconcile(reconciler, app.Name, app.Namespace)
+		tryReconcile(reconciler, app.Name, app.Namespace)
+
+		for _, name := range []string{"wf-web", "wf-backend"} {
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, &appsv1.Deployment{})).Should(BeNil())
+		}
+
+		By("upgrade the application to v2 with one component removed")
+		checkApp := &oamcore.Application{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: app.Name, Namespace: namespace}, checkApp)).Should(BeNil())
+		checkApp.Spec.Components = checkApp.Spec.Components[:1]
+		Expect(k8sClient.Update(ctx, checkApp)).Should(BeNil())
+		tryReconcile(reconciler, app.Name, app.Namespace)
+		tryReconcile(reconciler, app.Name, app.Namespace)
+
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: app.Name, Namespace: namespace}, checkApp)).Should(BeNil())
+		Expect(checkApp.Status.LatestRevision.Name).Should(Equal(app.Name + "-v2"))
+		web := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "wf-web", Namespace: namespace}, web)).Should(BeNil())
+		Expect(metav1.GetControllerOf(web).Name).Should(Equal(dispatch.ConstructResourceTrackerName(app.Name+"-v2", namespace)))
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "wf-backend", Namespace: namespace}, &appsv1.Deployment{})).Should(&util.NotFoundMatcher{})
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: dispatch.ConstructResourceTrackerName(app.Name+"-v1", namespace)},
+			&oamcore.ResourceTracker{})).Should(&util.NotFoundMatcher{})
+	})
 })
 
 func markWorkflowSucceeded(obj *unstructured.Unstructured) {
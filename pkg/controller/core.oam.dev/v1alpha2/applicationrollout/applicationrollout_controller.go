@@ -25,7 +25,6 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/pkg/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
@@ -108,15 +107,22 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (res reconcile.Result, retErr e
 		}
 		return reconcile.Result{}, fmt.Errorf("approllout namespace: %s, name : %s reconcile error %w", appRollout.Namespace, appRollout.Name, err)
 	}
-	return reconRes, r.updateStatus(ctx, &appRollout)
+	if err := r.updateStatus(ctx, &appRollout); err != nil {
+		return reconcile.Result{}, err
+	}
+	// the approvals are cleared once the rollout finishes so that they don't approve the next rollout
+	if appRollout.Status.IsTerminated() {
+		return reconRes, ClearRolloutApproval(ctx, r, &appRollout)
+	}
+	return reconRes, nil
 }
 
 // DoReconcile is real reconcile logic for appRollout.
 // 1.prepare rollout info: use assemble module in application pkg to generate manifest with appRevision
-// 2.determine which component is the common component between source and target AppRevision
+// 2.determine the components to rollout, they are the component list or the common component between source and target AppRevision
 // 3.if target workload isn't exist yet, template the targetAppRevision to apply target manifest
-// 4.extract target workload and source workload(if sourceAppRevision not empty)
-// 5.generate a rolloutPlan controller with source and target workload and call rolloutPlan's reconcile func
+// 4.extract target workload and source workload(if sourceAppRevision not empty) of each component
+// 5.generate a rolloutPlan controller with source and target workload and call rolloutPlan's reconcile func for each component
 // 6.handle output status
 // !!! Note the AppRollout object should not be updated in this function as it could be logically used in Application reconcile loop which does not have real AppRollout object.
 func (r *Reconciler) DoReconcile(ctx context.Context, appRollout *v1beta1.AppRollout) (reconcile.Result, error) {
@@ -125,6 +131,20 @@ func (r *Reconciler) DoReconcile(ctx context.Context, appRollout *v1beta1.AppRol
 	}
 	var err error
 
+	// roll back the failed rollout to the source app revision instead of leaving the workloads half upgraded
+	if needRollback(*appRollout) {
+		reason := rolloutFailureReason(appRollout.Status.RolloutStatus)
+		klog.InfoS("rollout failed, roll back to the source app revision", "appRollout", klog.KObj(appRollout),
+			"source", appRollout.Spec.SourceAppRevisionName, "target", appRollout.Spec.TargetAppRevisionName,
+			"reason", reason)
+		r.record.Event(appRollout, event.Warning("Rollout Rolling Back", errors.Errorf(
+			"rollout to %s failed, roll back to %s: %s", appRollout.Spec.TargetAppRevisionName,
+			appRollout.Spec.SourceAppRevisionName, reason)))
+		appRollout.Status.RollingBack = true
+		appRollout.Status.RollbackReason = reason
+		appRollout.Status.StateTransition(v1alpha1.RollingBackEvent)
+	}
+
 	// no need to proceed if rollout is already in a terminal state and there is no source/target change
 	doneReconcile := handleRollingTerminated(*appRollout)
 	if doneReconcile {
@@ -142,10 +162,13 @@ func (r *Reconciler) DoReconcile(ctx context.Context, appRollout *v1beta1.AppRol
 		h.sourceRevName = appRollout.Spec.SourceAppRevisionName
 		h.targetRevName = appRollout.Spec.TargetAppRevisionName
 	}
+	// the rollback upgrades the workload of the target back to the source
+	if appRollout.Status.RollingBack {
+		h.sourceRevName, h.targetRevName = h.targetRevName, h.sourceRevName
+	}
 
-	// 	TODO we only support rollout for one component and it should be specified in componentList
-	if len(appRollout.Spec.ComponentList) == 1 {
-		h.needRollComponent = appRollout.Spec.ComponentList[0]
+	if len(appRollout.Spec.ComponentList) != 0 {
+		h.needRollComponents = appRollout.Spec.ComponentList
 	}
 
 	// call assemble func generate source and target manifest
@@ -153,7 +176,7 @@ func (r *Reconciler) DoReconcile(ctx context.Context, appRollout *v1beta1.AppRol
 		return reconcile.Result{}, err
 	}
 
-	// we only support one workload rollout now, so here is determine witch component is need to rollout
+	// determine the components need to rollout if the component list is not set
 	if err = h.determineRolloutComponent(); err != nil {
 		return reconcile.Result{}, err
 	}
@@ -162,8 +185,6 @@ func (r *Reconciler) DoReconcile(ctx context.Context, appRollout *v1beta1.AppRol
 		return reconcile.Result{}, err
 	}
 
-	var sourceWorkload, targetWorkload *unstructured.Unstructured
-
 	// we should handle two special cases before call rolloutPlan Reconcile
 	switch h.appRollout.Status.RollingState {
 	case v1alpha1.RolloutDeletingState:
@@ -177,9 +198,10 @@ func (r *Reconciler) DoReconcile(ctx context.Context, appRollout *v1beta1.AppRol
 		}
 	case v1alpha1.LocatingTargetAppState:
 		if h.sourceAppRevision != nil {
-			err = h.handleSourceWorkload(ctx)
-			if err != nil {
-				return reconcile.Result{}, err
+			for _, comp := range h.needRollComponents {
+				if err = h.handleSourceWorkload(ctx, comp); err != nil {
+					return reconcile.Result{}, err
+				}
 			}
 		}
 		// target manifest haven't template yet, call dispatch template target manifest firstly
@@ -191,6 +213,8 @@ func (r *Reconciler) DoReconcile(ctx context.Context, appRollout *v1beta1.AppRol
 		h.appRollout.Status.SetConditions(utils.ReadyCondition("template"))
 		// this ensures that we template workload only once
 		h.appRollout.Status.StateTransition(v1alpha1.AppLocatedEvent)
+		// the components start to roll from the beginning
+		h.appRollout.Status.Components = nil
 		klog.InfoS("AppRollout have complete templateTarget", "name", h.appRollout.Name, "namespace",
 			h.appRollout.Namespace, "rollingState", h.appRollout.Status.RollingState)
 		return reconcile.Result{RequeueAfter: 3 * time.Second}, nil
@@ -198,26 +222,42 @@ func (r *Reconciler) DoReconcile(ctx context.Context, appRollout *v1beta1.AppRol
 		// in other cases there is no need do anything
 	}
 
-	sourceWorkload, targetWorkload, err = h.fetchSourceAndTargetWorkload(ctx)
-	if err != nil {
-		return reconcile.Result{}, err
-	}
+	abandoning := appRollout.Status.RollingState == v1alpha1.RolloutAbandoningState
+	var result reconcile.Result
+	if len(h.needRollComponents) > 1 {
+		// every component is rolled by its own rollout plan controller
+		if result, err = h.reconcileComponents(ctx); err != nil {
+			return reconcile.Result{}, err
+		}
+	} else {
+		sourceWorkload, targetWorkload, err := h.fetchSourceAndTargetWorkload(ctx, h.needRollComponents[0])
+		if err != nil {
+			return reconcile.Result{}, err
+		}
 
-	klog.InfoS("get the target workload we need to work on", "targetWorkload", klog.KObj(targetWorkload))
-	if sourceWorkload != nil {
-		klog.InfoS("get the source workload we need to work on", "sourceWorkload", klog.KObj(sourceWorkload))
-	}
+		klog.InfoS("get the target workload we need to work on", "targetWorkload", klog.KObj(targetWorkload))
+		if sourceWorkload != nil {
+			klog.InfoS("get the source workload we need to work on", "sourceWorkload", klog.KObj(sourceWorkload))
+		}
 
-	// reconcile the rollout part of the spec given the target and source workload
-	rolloutPlanController := rollout.NewRolloutPlanController(r, appRollout, r.record,
-		&appRollout.Spec.RolloutPlan, &appRollout.Status.RolloutStatus, targetWorkload, sourceWorkload)
-	result, rolloutStatus := rolloutPlanController.Reconcile(ctx)
-	// make sure that the new status is copied back
-	appRollout.Status.RolloutStatus = *rolloutStatus
+		// reconcile the rollout part of the spec given the target and source workload
+		rolloutPlanController := rollout.NewRolloutPlanController(r, appRollout, r.record,
+			rolloutPlanOf(appRollout), &appRollout.Status.RolloutStatus, targetWorkload, sourceWorkload)
+		var rolloutStatus *v1alpha1.RolloutStatus
+		result, rolloutStatus = rolloutPlanController.Reconcile(ctx)
+		// make sure that the new status is copied back
+		appRollout.Status.RolloutStatus = *rolloutStatus
+	}
+	rolloutStatus := &appRollout.Status.RolloutStatus
 	// do not update the last with new revision if we are still trying to abandon the previous rollout
 	if rolloutStatus.RollingState != v1alpha1.RolloutAbandoningState {
 		appRollout.Status.LastUpgradedTargetAppRevision = appRollout.Spec.TargetAppRevisionName
 		appRollout.Status.LastSourceAppRevision = appRollout.Spec.SourceAppRevisionName
+		if abandoning {
+			// the abandoned rollback is replaced by the new rollout
+			appRollout.Status.RollingBack = false
+			appRollout.Status.RollbackReason = ""
+		}
 	}
 
 	if rolloutStatus.RollingState == v1alpha1.RolloutSucceedState {
@@ -227,6 +267,12 @@ func (r *Reconciler) DoReconcile(ctx context.Context, appRollout *v1beta1.AppRol
 		}
 		klog.InfoS("rollout succeeded, record the source and target app revision", "source", appRollout.Spec.SourceAppRevisionName,
 			"target", appRollout.Spec.TargetAppRevisionName)
+		if appRollout.Status.RollingBack {
+			appRollout.Status.StateTransition(v1alpha1.RolledBackEvent)
+			r.record.Event(appRollout, event.Normal("Rollout Rolled Back", fmt.Sprintf(
+				"rolled back to %s because the rollout to %s failed: %s", appRollout.Spec.SourceAppRevisionName,
+				appRollout.Spec.TargetAppRevisionName, appRollout.Status.RollbackReason)))
+		}
 	} else if rolloutStatus.RollingState == v1alpha1.RolloutFailedState {
 		klog.InfoS("rollout failed, record the source and target app revision", "source", appRollout.Spec.SourceAppRevisionName,
 			"target", appRollout.Spec.TargetAppRevisionName, "revert on deletion", appRollout.Spec.RevertOnDelete)
@@ -245,7 +291,8 @@ func (r *Reconciler) handleFinalizer(ctx context.Context, appRollout *v1beta1.Ap
 			return true, reconcile.Result{}, errors.Wrap(r.Update(ctx, appRollout), errUpdateAppRollout)
 		}
 	} else if meta.FinalizerExists(&appRollout.ObjectMeta, appRolloutFinalizer) {
-		if appRollout.Status.RollingState == v1alpha1.RolloutSucceedState {
+		if appRollout.Status.RollingState == v1alpha1.RolloutSucceedState ||
+			appRollout.Status.RollingState == v1alpha1.RolloutRolledBackState {
 			klog.InfoS("Safe to delete the succeeded rollout", "rollout", appRollout.Name)
 			meta.RemoveFinalizer(&appRollout.ObjectMeta, appRolloutFinalizer)
 			return true, reconcile.Result{}, errors.Wrap(r.Update(ctx, appRollout), errUpdateAppRollout)
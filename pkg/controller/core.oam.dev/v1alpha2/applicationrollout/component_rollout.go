@@ -0,0 +1,242 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applicationrollout
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	commontypes "github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/pkg/controller/common"
+	"github.com/oam-dev/kubevela/pkg/controller/common/rollout"
+)
+
+// reconcileComponents rolls out multiple components, every component is rolled out by the rollout plan with its own
+// status, and the overall rollout status follows the component being rolled out.
+// The components move batch by batch together in the lockstep strategy, or one by one in the order of the component
+// list in the ordered strategy.
+func (h *rolloutHandler) reconcileComponents(ctx context.Context) (reconcile.Result, error) {
+	status := &h.appRollout.Status
+	status.Components = initComponentStatus(status.Components, h.needRollComponents, status.RollingState)
+	propagateRolloutState(status)
+
+	ordered := h.appRollout.Spec.ComponentRolloutStrategy == v1beta1.OrderedComponentRollout
+	plan := rolloutPlanOf(h.appRollout)
+	if !ordered {
+		plan.BatchPartition = lockstepBatchPartition(plan.BatchPartition, status.Components, len(plan.RolloutBatches))
+	}
+	var result reconcile.Result
+	for i := range status.Components {
+		compStatus := &status.Components[i]
+		if ordered && compStatus.RollingState == v1alpha1.VerifyingSpecState && !componentsSucceeded(status.Components[:i]) {
+			klog.V(common.LogDebug).InfoS("the component is waiting for the components before it", "component", compStatus.Component)
+			continue
+		}
+		sourceWorkload, targetWorkload, err := h.fetchSourceAndTargetWorkload(ctx, compStatus.Component)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		klog.InfoS("reconcile the rollout of the component", "component", compStatus.Component,
+			"targetWorkload", klog.KObj(targetWorkload), "rolling state", compStatus.RollingState)
+		rolloutPlanController := rollout.NewRolloutPlanController(h, h.appRollout, h.record, plan,
+			&compStatus.RolloutStatus, targetWorkload, sourceWorkload)
+		res, rolloutStatus := rolloutPlanController.Reconcile(ctx)
+		compStatus.RolloutStatus = *rolloutStatus
+		if res.RequeueAfter > 0 && (result.RequeueAfter == 0 || res.RequeueAfter < result.RequeueAfter) {
+			result = res
+		}
+	}
+	aggregateComponentStatus(status)
+	return result, nil
+}
+
+// initComponentStatus returns the status of the components in order, the status of a new component starts
+// from verifying the spec
+func initComponentStatus(existing []commontypes.ComponentRolloutStatus, comps []string,
+	state v1alpha1.RollingState) []commontypes.ComponentRolloutStatus {
+	statuses := make([]commontypes.ComponentRolloutStatus, 0, len(comps))
+	for _, comp := range comps {
+		if compStatus := getComponentStatus(existing, comp); compStatus != nil {
+			statuses = append(statuses, *compStatus)
+			continue
+		}
+		compStatus := commontypes.ComponentRolloutStatus{Component: comp}
+		compStatus.ResetStatus()
+		if state != v1alpha1.LocatingTargetAppState {
+			compStatus.StateTransition(v1alpha1.AppLocatedEvent)
+		}
+		statuses = append(statuses, compStatus)
+	}
+	return statuses
+}
+
+func getComponentStatus(statuses []commontypes.ComponentRolloutStatus, comp string) *commontypes.ComponentRolloutStatus {
+	for i := range statuses {
+		if statuses[i].Component == comp {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// propagateRolloutState passes the abandoning or deleting of the rollout to the components, and fails all the
+// components once one of them fails. A component not started yet is moved to the end state directly
+func propagateRolloutState(status *commontypes.AppRolloutStatus) {
+	switch status.RollingState {
+	case v1alpha1.RolloutAbandoningState:
+		for i := range status.Components {
+			compStatus := &status.Components[i].RolloutStatus
+			switch compStatus.RollingState {
+			case v1alpha1.LocatingTargetAppState, v1alpha1.RolloutAbandoningState:
+			case v1alpha1.VerifyingSpecState:
+				compStatus.ResetStatus()
+			default:
+				compStatus.StateTransition(v1alpha1.RollingModifiedEvent)
+			}
+		}
+		return
+	case v1alpha1.RolloutDeletingState:
+		for i := range status.Components {
+			compStatus := &status.Components[i].RolloutStatus
+			switch {
+			case compStatus.RollingState == v1alpha1.RolloutDeletingState || compStatus.IsTerminated():
+			case compStatus.RollingState == v1alpha1.VerifyingSpecState:
+				compStatus.RolloutFailed("Rollout is being deleted")
+			default:
+				compStatus.StateTransition(v1alpha1.RollingDeletedEvent)
+			}
+		}
+		return
+	}
+
+	var failed string
+	for _, compStatus := range status.Components {
+		if compStatus.RollingState == v1alpha1.RolloutFailingState || compStatus.RollingState == v1alpha1.RolloutFailedState {
+			failed = compStatus.Component
+			break
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+	reason := fmt.Sprintf("the rollout of component %s failed", failed)
+	for i := range status.Components {
+		compStatus := &status.Components[i].RolloutStatus
+		switch compStatus.RollingState {
+		case v1alpha1.VerifyingSpecState:
+			compStatus.RolloutFailed(reason)
+		case v1alpha1.InitializingState, v1alpha1.RollingInBatchesState:
+			compStatus.RolloutFailing(reason)
+		default:
+		}
+	}
+}
+
+// lockstepBatchPartition returns the batch partition that holds the components in the lockstep, a component can only
+// move to the next batch after all the components are ready in the current batch
+func lockstepBatchPartition(partition *int32, statuses []commontypes.ComponentRolloutStatus, numBatches int) *int32 {
+	readyBatch := int32(numBatches)
+	for _, compStatus := range statuses {
+		var batch int32
+		switch compStatus.RollingState {
+		case v1alpha1.RollingInBatchesState:
+			batch = compStatus.CurrentBatch
+			if compStatus.BatchRollingState != v1alpha1.BatchReadyState {
+				batch--
+			}
+		case v1alpha1.FinalisingState, v1alpha1.RolloutSucceedState:
+			batch = int32(numBatches)
+		default:
+			batch = -1
+		}
+		if batch < readyBatch {
+			readyBatch = batch
+		}
+	}
+	lockstep := readyBatch + 1
+	if partition != nil && *partition < lockstep {
+		return partition
+	}
+	return &lockstep
+}
+
+func componentsSucceeded(statuses []commontypes.ComponentRolloutStatus) bool {
+	for _, compStatus := range statuses {
+		if compStatus.RollingState != v1alpha1.RolloutSucceedState {
+			return false
+		}
+	}
+	return true
+}
+
+// aggregateComponentStatus sets the overall rollout status by the status of the components. It follows the first
+// component being deleted, abandoned, failed or rolled out in order, the rollout fails if any component fails and
+// succeeds after all the components succeed
+func aggregateComponentStatus(status *commontypes.AppRolloutStatus) {
+	var deleting, abandoning, failed, rolling *v1alpha1.RolloutStatus
+	terminated := true
+	for i := range status.Components {
+		compStatus := &status.Components[i].RolloutStatus
+		switch compStatus.RollingState {
+		case v1alpha1.RolloutDeletingState:
+			if deleting == nil {
+				deleting = compStatus
+			}
+		case v1alpha1.RolloutAbandoningState:
+			if abandoning == nil {
+				abandoning = compStatus
+			}
+		case v1alpha1.RolloutFailingState, v1alpha1.RolloutFailedState:
+			if failed == nil {
+				failed = compStatus
+			}
+		}
+		if !compStatus.IsTerminated() {
+			terminated = false
+			if rolling == nil {
+				rolling = compStatus
+			}
+		}
+	}
+	followed, state := rolling, v1alpha1.RollingState("")
+	switch {
+	case deleting != nil:
+		followed = deleting
+	case abandoning != nil:
+		followed = abandoning
+	case failed != nil:
+		followed = failed
+		if !terminated {
+			state = v1alpha1.RolloutFailingState
+		}
+	case terminated:
+		// all the components succeed
+		followed = &status.Components[len(status.Components)-1].RolloutStatus
+	}
+	conditions := status.Conditions
+	status.RolloutStatus = *followed.DeepCopy()
+	status.Conditions = conditions
+	status.SetConditions(followed.Conditions...)
+	if len(state) != 0 {
+		status.RollingState = state
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applicationrollout
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"k8s.io/utils/pointer"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	oamstandard "github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+)
+
+func componentStatus(comp string, state oamstandard.RollingState, batchState oamstandard.BatchRollingState,
+	batch int32) common.ComponentRolloutStatus {
+	return common.ComponentRolloutStatus{Component: comp, RolloutStatus: oamstandard.RolloutStatus{
+		RollingState:      state,
+		BatchRollingState: batchState,
+		CurrentBatch:      batch,
+	}}
+}
+
+func TestInitComponentStatus(t *testing.T) {
+	existing := []common.ComponentRolloutStatus{
+		componentStatus("backend", oamstandard.RollingInBatchesState, oamstandard.BatchReadyState, 1),
+		componentStatus("removed", oamstandard.RollingInBatchesState, oamstandard.BatchReadyState, 1),
+	}
+	statuses := initComponentStatus(existing, []string{"frontend", "backend"}, oamstandard.VerifyingSpecState)
+	assert.Equal(t, len(statuses), 2)
+	assert.Equal(t, statuses[0].Component, "frontend")
+	assert.Equal(t, statuses[0].RollingState, oamstandard.VerifyingSpecState)
+	assert.Equal(t, statuses[0].RolloutTargetSize, int32(-1))
+	assert.DeepEqual(t, statuses[1], existing[0])
+}
+
+func TestLockstepBatchPartition(t *testing.T) {
+	testCases := map[string]struct {
+		partition *int32
+		statuses  []common.ComponentRolloutStatus
+		want      int32
+	}{
+		"wait for the component rolling the batch": {
+			statuses: []common.ComponentRolloutStatus{
+				componentStatus("a", oamstandard.RollingInBatchesState, oamstandard.BatchReadyState, 0),
+				componentStatus("b", oamstandard.RollingInBatchesState, oamstandard.BatchInRollingState, 0),
+			},
+			want: 0,
+		},
+		"all the components are ready": {
+			statuses: []common.ComponentRolloutStatus{
+				componentStatus("a", oamstandard.RollingInBatchesState, oamstandard.BatchReadyState, 1),
+				componentStatus("b", oamstandard.RollingInBatchesState, oamstandard.BatchReadyState, 1),
+			},
+			want: 2,
+		},
+		"wait for the component initializing": {
+			statuses: []common.ComponentRolloutStatus{
+				componentStatus("a", oamstandard.RollingInBatchesState, oamstandard.BatchReadyState, 0),
+				componentStatus("b", oamstandard.InitializingState, oamstandard.BatchInitializingState, 0),
+			},
+			want: 0,
+		},
+		"the finished component does not hold the others": {
+			statuses: []common.ComponentRolloutStatus{
+				componentStatus("a", oamstandard.RolloutSucceedState, oamstandard.BatchReadyState, 2),
+				componentStatus("b", oamstandard.RollingInBatchesState, oamstandard.BatchReadyState, 1),
+			},
+			want: 2,
+		},
+		"keep the partition of the plan": {
+			partition: pointer.Int32Ptr(1),
+			statuses: []common.ComponentRolloutStatus{
+				componentStatus("a", oamstandard.RollingInBatchesState, oamstandard.BatchReadyState, 1),
+				componentStatus("b", oamstandard.RollingInBatchesState, oamstandard.BatchReadyState, 1),
+			},
+			want: 1,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := lockstepBatchPartition(tc.partition, tc.statuses, 3)
+			assert.Equal(t, *got, tc.want)
+		})
+	}
+}
+
+func TestPropagateRolloutState(t *testing.T) {
+	status := &common.AppRolloutStatus{Components: []common.ComponentRolloutStatus{
+		componentStatus("a", oamstandard.RollingInBatchesState, oamstandard.BatchReadyState, 1),
+		componentStatus("b", oamstandard.VerifyingSpecState, oamstandard.BatchInitializingState, 0),
+	}}
+	status.RollingState = oamstandard.RolloutAbandoningState
+	propagateRolloutState(status)
+	assert.Equal(t, status.Components[0].RollingState, oamstandard.RolloutAbandoningState)
+	assert.Equal(t, status.Components[1].RollingState, oamstandard.LocatingTargetAppState)
+
+	status.Components = []common.ComponentRolloutStatus{
+		componentStatus("a", oamstandard.RolloutSucceedState, oamstandard.BatchReadyState, 1),
+		componentStatus("b", oamstandard.RollingInBatchesState, oamstandard.BatchInRollingState, 0),
+		componentStatus("c", oamstandard.VerifyingSpecState, oamstandard.BatchInitializingState, 0),
+	}
+	status.RollingState = oamstandard.RolloutDeletingState
+	propagateRolloutState(status)
+	assert.Equal(t, status.Components[0].RollingState, oamstandard.RolloutSucceedState)
+	assert.Equal(t, status.Components[1].RollingState, oamstandard.RolloutDeletingState)
+	assert.Equal(t, status.Components[2].RollingState, oamstandard.RolloutFailedState)
+
+	status.Components = []common.ComponentRolloutStatus{
+		componentStatus("a", oamstandard.RollingInBatchesState, oamstandard.BatchReadyState, 1),
+		componentStatus("b", oamstandard.RolloutFailingState, oamstandard.BatchInitializingState, 1),
+		componentStatus("c", oamstandard.VerifyingSpecState, oamstandard.BatchInitializingState, 0),
+	}
+	status.RollingState = oamstandard.RollingInBatchesState
+	propagateRolloutState(status)
+	assert.Equal(t, status.Components[0].RollingState, oamstandard.RolloutFailingState)
+	assert.Equal(t, status.Components[1].RollingState, oamstandard.RolloutFailingState)
+	assert.Equal(t, status.Components[2].RollingState, oamstandard.RolloutFailedState)
+	assert.Equal(t, rolloutFailureReason(status.Components[0].RolloutStatus), "the rollout of component b failed")
+}
+
+func TestAggregateComponentStatus(t *testing.T) {
+	status := &common.AppRolloutStatus{Components: []common.ComponentRolloutStatus{
+		componentStatus("a", oamstandard.RolloutSucceedState, oamstandard.BatchReadyState, 2),
+		componentStatus("b", oamstandard.RollingInBatchesState, oamstandard.BatchInRollingState, 1),
+	}}
+	status.Components[1].UpgradedReplicas = 3
+	aggregateComponentStatus(status)
+	assert.Equal(t, status.RollingState, oamstandard.RollingInBatchesState)
+	assert.Equal(t, status.CurrentBatch, int32(1))
+	assert.Equal(t, status.UpgradedReplicas, int32(3))
+
+	status.Components[1].RolloutFailing("no pods")
+	status.Components = append(status.Components,
+		componentStatus("c", oamstandard.RollingInBatchesState, oamstandard.BatchInRollingState, 1))
+	aggregateComponentStatus(status)
+	assert.Equal(t, status.RollingState, oamstandard.RolloutFailingState)
+	assert.Equal(t, rolloutFailureReason(status.RolloutStatus), "no pods")
+
+	status.Components[1].RollingState = oamstandard.RolloutFailedState
+	status.Components[2].RollingState = oamstandard.RolloutFailedState
+	aggregateComponentStatus(status)
+	assert.Equal(t, status.RollingState, oamstandard.RolloutFailedState)
+
+	for i := range status.Components {
+		status.Components[i].RollingState = oamstandard.RolloutSucceedState
+	}
+	aggregateComponentStatus(status)
+	assert.Equal(t, status.RollingState, oamstandard.RolloutSucceedState)
+}
@@ -22,7 +22,9 @@ import (
 	"reflect"
 
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -47,17 +49,14 @@ func rolloutWorkloadName(rolloutComp string) assemble.WorkloadOption {
 		}
 
 		// we hard code the behavior depends on the workload group/kind for now. The only in-place upgradable resources
-		// we support is cloneset/statefulset for now. We can easily add more later.
-		if w.GroupVersionKind().Group == v1alpha1.GroupVersion.Group {
-			if w.GetKind() == reflect.TypeOf(v1alpha1.CloneSet{}).Name() ||
-				w.GetKind() == reflect.TypeOf(v1alpha1.StatefulSet{}).Name() {
-				// we use the component name alone for those resources that do support in-place upgrade
-				klog.InfoS("we reuse the component name for resources that support in-place upgrade",
-					"GVK", w.GroupVersionKind(), "instance name", w.GetName())
-				// assemble use component name as workload name by default
-				// so no need to re-set name
-				return nil
-			}
+		// we support is cloneset/statefulset/daemonset for now. We can easily add more later.
+		if isInPlaceUpgradable(w) {
+			// we use the component name alone for those resources that do support in-place upgrade
+			klog.InfoS("we reuse the component name for resources that support in-place upgrade",
+				"GVK", w.GroupVersionKind(), "instance name", w.GetName())
+			// assemble use component name as workload name by default
+			// so no need to re-set name
+			return nil
 		}
 		// we assume that the rest of the resources do not support in-place upgrade
 		compRevName := w.GetLabels()[oam.LabelAppComponentRevision]
@@ -80,8 +79,12 @@ func handleReplicas(ctx context.Context, rolloutComp string, c client.Client) as
 		// we hard code here, but we can easily support more types of workload by add more cases logic in switch
 		var replicasFieldPath string
 		switch u.GetKind() {
-		case reflect.TypeOf(v1alpha1.CloneSet{}).Name(), reflect.TypeOf(appsv1.Deployment{}).Name():
+		case reflect.TypeOf(v1alpha1.CloneSet{}).Name(), reflect.TypeOf(appsv1.Deployment{}).Name(),
+			reflect.TypeOf(appsv1.StatefulSet{}).Name():
 			replicasFieldPath = "spec.replicas"
+		case reflect.TypeOf(appsv1.DaemonSet{}).Name():
+			// the pods of DaemonSet are scheduled by the nodes, there is no replicas to keep
+			return nil
 		default:
 			klog.Errorf("rollout meet a workload we cannot support yet", "Kind", u.GetKind(), "name", u.GetName())
 			return fmt.Errorf("rollout meet a workload we cannot support yet Kind  %s name %s", u.GetKind(), u.GetName())
@@ -116,6 +119,19 @@ func handleReplicas(ctx context.Context, rolloutComp string, c client.Client) as
 	})
 }
 
+// isInPlaceUpgradable checks if the workload is upgraded in place, the source and target workload are the same one
+func isInPlaceUpgradable(w *unstructured.Unstructured) bool {
+	switch w.GroupVersionKind().Group {
+	case v1alpha1.GroupVersion.Group:
+		return w.GetKind() == reflect.TypeOf(v1alpha1.CloneSet{}).Name() ||
+			w.GetKind() == reflect.TypeOf(v1alpha1.StatefulSet{}).Name()
+	case appsv1.GroupName:
+		return w.GetKind() == reflect.TypeOf(appsv1.StatefulSet{}).Name() ||
+			w.GetKind() == reflect.TypeOf(appsv1.DaemonSet{}).Name()
+	}
+	return false
+}
+
 // appRollout should take over updating workload, so disable previous controller owner(resourceTracker)
 func disableControllerOwner(workload *unstructured.Unstructured) {
 	if workload == nil {
@@ -143,8 +159,7 @@ func enableControllerOwner(workload *unstructured.Unstructured) {
 
 func handleRollingTerminated(appRollout v1beta1.AppRollout) bool {
 	// handle rollout completed
-	if appRollout.Status.RollingState == oamstd.RolloutSucceedState ||
-		appRollout.Status.RollingState == oamstd.RolloutFailedState {
+	if appRollout.Status.IsTerminated() {
 		if appRollout.Status.LastUpgradedTargetAppRevision == appRollout.Spec.TargetAppRevisionName &&
 			appRollout.Status.LastSourceAppRevision == appRollout.Spec.SourceAppRevisionName {
 			// spec.targetSize could be nil, If targetSize isn't nil and not equal to status.RolloutTargetSize it's
@@ -165,6 +180,47 @@ func handleRollingTerminated(appRollout v1beta1.AppRollout) bool {
 	return false
 }
 
+// needRollback checks if the failed rollout should be rolled back to the source app revision, a rollout is rolled back
+// at most once and the rollback itself is not rolled back
+func needRollback(appRollout v1beta1.AppRollout) bool {
+	return appRollout.Spec.AutoRollback && appRollout.DeletionTimestamp.IsZero() &&
+		appRollout.Status.RollingState == oamstd.RolloutFailedState && !appRollout.Status.RollingBack &&
+		len(appRollout.Spec.SourceAppRevisionName) != 0 &&
+		appRollout.Status.LastUpgradedTargetAppRevision == appRollout.Spec.TargetAppRevisionName &&
+		appRollout.Status.LastSourceAppRevision == appRollout.Spec.SourceAppRevisionName
+}
+
+// rolloutPlanOf returns the rollout plan to reconcile the rollout with. The rollback restores the source app revision
+// without waiting for the batch partition and the approvals of the batches, as the approvals are cleared once the
+// rollout fails and nobody is expected to approve the batches of a rollback.
+func rolloutPlanOf(appRollout *v1beta1.AppRollout) *oamstd.RolloutPlan {
+	plan := appRollout.Spec.RolloutPlan.DeepCopy()
+	if !appRollout.Status.RollingBack {
+		return plan
+	}
+	plan.BatchPartition = nil
+	for i := range plan.RolloutBatches {
+		plan.RolloutBatches[i].RequireApproval = false
+	}
+	return plan
+}
+
+// rolloutFailureReason returns the message of the latest failed condition of the rollout
+func rolloutFailureReason(status oamstd.RolloutStatus) string {
+	reason := "unknown reason"
+	var latest metav1.Time
+	for _, cond := range status.Conditions {
+		if cond.Status != corev1.ConditionFalse || cond.Type == oamstd.BatchPaused || len(cond.Message) == 0 {
+			continue
+		}
+		if !cond.LastTransitionTime.Before(&latest) {
+			reason = cond.Message
+			latest = cond.LastTransitionTime
+		}
+	}
+	return reason
+}
+
 // check if either the source or the target of the appRollout has changed.
 // when reset the state machine, the controller will set the status.RolloutTargetSize as -1 in AppLocating phase
 // so we should ignore this case.
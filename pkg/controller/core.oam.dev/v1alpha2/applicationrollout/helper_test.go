@@ -18,15 +18,19 @@ package applicationrollout
 
 import (
 	"testing"
+	"time"
 
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1alpha2"
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	oamstandard "github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
 
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
 )
 
@@ -99,6 +103,22 @@ func TestHandleTerminated(t *testing.T) {
 			},
 			want: true,
 		},
+		"rolled back": {
+			rollout: v1beta1.AppRollout{
+				Spec: v1beta1.AppRolloutSpec{
+					SourceAppRevisionName: "v1",
+					TargetAppRevisionName: "v2",
+				},
+				Status: common.AppRolloutStatus{
+					LastSourceAppRevision:         "v1",
+					LastUpgradedTargetAppRevision: "v2",
+					RolloutStatus: oamstandard.RolloutStatus{
+						RollingState: oamstandard.RolloutRolledBackState,
+					},
+				},
+			},
+			want: true,
+		},
 		"restart after succeed": {
 			rollout: v1beta1.AppRollout{
 				Spec: v1beta1.AppRolloutSpec{
@@ -191,3 +211,101 @@ func TestHandleTerminated(t *testing.T) {
 		}
 	}
 }
+
+func TestNeedRollback(t *testing.T) {
+	failed := v1beta1.AppRollout{
+		Spec: v1beta1.AppRolloutSpec{
+			SourceAppRevisionName: "v1",
+			TargetAppRevisionName: "v2",
+			AutoRollback:          true,
+		},
+		Status: common.AppRolloutStatus{
+			LastSourceAppRevision:         "v1",
+			LastUpgradedTargetAppRevision: "v2",
+			RolloutStatus: oamstandard.RolloutStatus{
+				RollingState: oamstandard.RolloutFailedState,
+			},
+		},
+	}
+	testcases := map[string]struct {
+		mutate func(r *v1beta1.AppRollout)
+		want   bool
+	}{
+		"failed with auto rollback": {
+			mutate: func(r *v1beta1.AppRollout) {},
+			want:   true,
+		},
+		"auto rollback disabled": {
+			mutate: func(r *v1beta1.AppRollout) { r.Spec.AutoRollback = false },
+		},
+		"not failed": {
+			mutate: func(r *v1beta1.AppRollout) { r.Status.RollingState = oamstandard.RollingInBatchesState },
+		},
+		"the rollback failed": {
+			mutate: func(r *v1beta1.AppRollout) { r.Status.RollingBack = true },
+		},
+		"scale operation": {
+			mutate: func(r *v1beta1.AppRollout) {
+				r.Spec.SourceAppRevisionName = ""
+				r.Status.LastSourceAppRevision = ""
+			},
+		},
+		"target modified": {
+			mutate: func(r *v1beta1.AppRollout) { r.Spec.TargetAppRevisionName = "v3" },
+		},
+		"deleting": {
+			mutate: func(r *v1beta1.AppRollout) {
+				now := metav1.Now()
+				r.DeletionTimestamp = &now
+			},
+		},
+	}
+	for casename, c := range testcases {
+		rollout := failed.DeepCopy()
+		c.mutate(rollout)
+		assert.Equal(t, c.want, needRollback(*rollout), casename)
+	}
+}
+
+func TestRolloutPlanOf(t *testing.T) {
+	appRollout := &v1beta1.AppRollout{
+		Spec: v1beta1.AppRolloutSpec{
+			RolloutPlan: oamstandard.RolloutPlan{
+				BatchPartition: pointer.Int32Ptr(0),
+				RolloutBatches: []oamstandard.RolloutBatch{
+					{Replicas: intstr.FromInt(1)},
+					{Replicas: intstr.FromInt(1), RequireApproval: true},
+				},
+			},
+		},
+	}
+	plan := rolloutPlanOf(appRollout)
+	assert.DeepEqual(t, &appRollout.Spec.RolloutPlan, plan)
+
+	// the rollback doesn't wait for the batch partition or the approvals
+	appRollout.Status.RollingBack = true
+	plan = rolloutPlanOf(appRollout)
+	assert.Assert(t, plan.BatchPartition == nil)
+	assert.Equal(t, false, plan.RolloutBatches[1].RequireApproval)
+	// the spec is not changed
+	assert.Equal(t, int32(0), *appRollout.Spec.RolloutPlan.BatchPartition)
+	assert.Equal(t, true, appRollout.Spec.RolloutPlan.RolloutBatches[1].RequireApproval)
+}
+
+func TestRolloutFailureReason(t *testing.T) {
+	now := metav1.Now()
+	earlier := metav1.NewTime(now.Add(-time.Minute))
+	status := oamstandard.RolloutStatus{}
+	assert.Equal(t, "unknown reason", rolloutFailureReason(status))
+
+	status.Conditions = []runtimev1alpha1.Condition{
+		{Type: oamstandard.BatchVerifying, Status: corev1.ConditionFalse, LastTransitionTime: now,
+			Message: "the batch is not available"},
+		{Type: oamstandard.BatchPaused, Status: corev1.ConditionFalse, LastTransitionTime: now,
+			Message: "Rollout resumed"},
+		{Type: oamstandard.BatchInitializing, Status: corev1.ConditionFalse, LastTransitionTime: earlier,
+			Message: "failed to invoke a webhook"},
+		{Type: oamstandard.RolloutFailing, Status: corev1.ConditionTrue, LastTransitionTime: now},
+	}
+	assert.Equal(t, "the batch is not available", rolloutFailureReason(status))
+}
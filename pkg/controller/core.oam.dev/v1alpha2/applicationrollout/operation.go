@@ -0,0 +1,158 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applicationrollout
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+// PauseRollout pauses the rollout before the next batch. It operates on the AppRollout with the name, or on the
+// rollout plan of the application with the name if there is no such AppRollout.
+func PauseRollout(ctx context.Context, cli client.Client, namespace, name string) error {
+	return setRolloutPaused(ctx, cli, namespace, name, true)
+}
+
+// ResumeRollout continues the paused rollout. It operates on the AppRollout with the name, or on the
+// rollout plan of the application with the name if there is no such AppRollout.
+func ResumeRollout(ctx context.Context, cli client.Client, namespace, name string) error {
+	return setRolloutPaused(ctx, cli, namespace, name, false)
+}
+
+func setRolloutPaused(ctx context.Context, cli client.Client, namespace, name string, paused bool) error {
+	return updateRollout(ctx, cli, namespace, name, func(obj oam.Object, plan *v1alpha1.RolloutPlan, _ *v1alpha1.RolloutStatus) error {
+		annotations := obj.GetAnnotations()
+		switch o := obj.(type) {
+		case *v1beta1.AppRollout:
+			if o.Spec.RolloutPlan.Paused == paused {
+				return errors.Errorf("the rollout %s is already %s", name, pausedState(paused))
+			}
+			o.Spec.RolloutPlan.Paused = paused
+		default:
+			// the rollout plan of an application is paused by annotation so that no new app revision is created
+			if (plan.Paused || annotations[oam.AnnotationRolloutPaused] == "true") == paused {
+				return errors.Errorf("the rollout of application %s is already %s", name, pausedState(paused))
+			}
+			if !paused && plan.Paused {
+				return errors.Errorf("the rollout of application %s is paused by its rollout plan", name)
+			}
+			if paused {
+				if annotations == nil {
+					annotations = map[string]string{}
+				}
+				annotations[oam.AnnotationRolloutPaused] = "true"
+			} else {
+				delete(annotations, oam.AnnotationRolloutPaused)
+			}
+			obj.SetAnnotations(annotations)
+		}
+		return nil
+	})
+}
+
+func pausedState(paused bool) string {
+	if paused {
+		return "paused"
+	}
+	return "not paused"
+}
+
+// ApproveRolloutBatch approves the batches of the rollout up to the given one (included) to move on, the approval
+// and the approver are recorded in the rollout status once the rollout reaches the batch.
+// It operates on the AppRollout with the name, or on the rollout plan of the application with the name if there is
+// no such AppRollout.
+func ApproveRolloutBatch(ctx context.Context, cli client.Client, namespace, name string, batch int32, approver string) error {
+	return updateRollout(ctx, cli, namespace, name, func(obj oam.Object, plan *v1alpha1.RolloutPlan, status *v1alpha1.RolloutStatus) error {
+		if batch < 0 || int(batch) >= len(plan.RolloutBatches) {
+			return errors.Errorf("batch %d is out of range, the rollout %s has %d batches", batch, name,
+				len(plan.RolloutBatches))
+		}
+		if status.RollingState == v1alpha1.RollingInBatchesState && status.CurrentBatch > batch {
+			return errors.Errorf("batch %d of the rollout %s is already rolled out", batch, name)
+		}
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[oam.AnnotationRolloutApprovedBatch] = strconv.Itoa(int(batch))
+		if approver != "" {
+			annotations[oam.AnnotationRolloutApprover] = approver
+		} else {
+			delete(annotations, oam.AnnotationRolloutApprover)
+		}
+		obj.SetAnnotations(annotations)
+		return nil
+	})
+}
+
+type rolloutMutator func(obj oam.Object, plan *v1alpha1.RolloutPlan, status *v1alpha1.RolloutStatus) error
+
+func updateRollout(ctx context.Context, cli client.Client, namespace, name string, mutate rolloutMutator) error {
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		appRollout := &v1beta1.AppRollout{}
+		err := cli.Get(ctx, key, appRollout)
+		if err == nil {
+			if err := mutate(appRollout, &appRollout.Spec.RolloutPlan, &appRollout.Status.RolloutStatus); err != nil {
+				return err
+			}
+			return cli.Update(ctx, appRollout)
+		}
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "cannot get the rollout %s", name)
+		}
+		app := &v1beta1.Application{}
+		if err := cli.Get(ctx, key, app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return errors.Errorf("neither AppRollout nor Application %s is found", name)
+			}
+			return errors.Wrapf(err, "cannot get application %s", name)
+		}
+		if app.Spec.RolloutPlan == nil {
+			return errors.Errorf("application %s has no rollout plan", name)
+		}
+		if err := mutate(app, app.Spec.RolloutPlan.DeepCopy(), &app.Status.Rollout.RolloutStatus); err != nil {
+			return err
+		}
+		return cli.Update(ctx, app)
+	})
+}
+
+// ClearRolloutApproval removes the batch approvals from the finished rollout, so that they don't approve the batches
+// of the next rollout.
+func ClearRolloutApproval(ctx context.Context, cli client.Client, obj oam.Object) error {
+	annotations := obj.GetAnnotations()
+	_, approved := annotations[oam.AnnotationRolloutApprovedBatch]
+	_, approver := annotations[oam.AnnotationRolloutApprover]
+	if !approved && !approver {
+		return nil
+	}
+	patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:null,%q:null}}}`,
+		oam.AnnotationRolloutApprovedBatch, oam.AnnotationRolloutApprover)))
+	return errors.Wrap(cli.Patch(ctx, obj, patch), "cannot clear the rollout approval")
+}
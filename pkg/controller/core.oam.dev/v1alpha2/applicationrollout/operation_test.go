@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applicationrollout
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	oamstandard "github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+func TestRolloutOperations(t *testing.T) {
+	ctx := context.Background()
+	plan := oamstandard.RolloutPlan{
+		RolloutBatches: []oamstandard.RolloutBatch{
+			{Replicas: intstr.FromInt(1)},
+			{Replicas: intstr.FromInt(1), RequireApproval: true},
+		},
+	}
+	appRollout := &v1beta1.AppRollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "rollout", Namespace: "default"},
+		Spec:       v1beta1.AppRolloutSpec{RolloutPlan: plan},
+	}
+	app := &v1beta1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec:       v1beta1.ApplicationSpec{RolloutPlan: plan.DeepCopy()},
+	}
+	noRollout := &v1beta1.Application{ObjectMeta: metav1.ObjectMeta{Name: "no-rollout", Namespace: "default"}}
+	scheme := runtime.NewScheme()
+	assert.NilError(t, v1beta1.SchemeBuilder.AddToScheme(scheme))
+	cli := fake.NewFakeClientWithScheme(scheme, appRollout, app, noRollout)
+	getAppRollout := func() *v1beta1.AppRollout {
+		got := &v1beta1.AppRollout{}
+		assert.NilError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "rollout"}, got))
+		return got
+	}
+	getApp := func() *v1beta1.Application {
+		got := &v1beta1.Application{}
+		assert.NilError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "app"}, got))
+		return got
+	}
+
+	t.Run("pause and resume AppRollout", func(t *testing.T) {
+		assert.NilError(t, PauseRollout(ctx, cli, "default", "rollout"))
+		assert.ErrorContains(t, PauseRollout(ctx, cli, "default", "rollout"), "already paused")
+		assert.Equal(t, true, getAppRollout().Spec.RolloutPlan.Paused)
+
+		assert.NilError(t, ResumeRollout(ctx, cli, "default", "rollout"))
+		assert.Equal(t, false, getAppRollout().Spec.RolloutPlan.Paused)
+	})
+
+	t.Run("pause and resume application", func(t *testing.T) {
+		assert.NilError(t, PauseRollout(ctx, cli, "default", "app"))
+		got := getApp()
+		assert.Equal(t, "true", got.Annotations[oam.AnnotationRolloutPaused])
+		assert.Equal(t, false, got.Spec.RolloutPlan.Paused)
+
+		assert.NilError(t, ResumeRollout(ctx, cli, "default", "app"))
+		assert.ErrorContains(t, ResumeRollout(ctx, cli, "default", "app"), "not paused")
+		_, paused := getApp().Annotations[oam.AnnotationRolloutPaused]
+		assert.Equal(t, false, paused)
+	})
+
+	t.Run("approve batch", func(t *testing.T) {
+		assert.ErrorContains(t, ApproveRolloutBatch(ctx, cli, "default", "app", 2, "alice"), "out of range")
+		assert.NilError(t, ApproveRolloutBatch(ctx, cli, "default", "app", 1, "alice"))
+		got := getApp()
+		assert.Equal(t, "1", got.Annotations[oam.AnnotationRolloutApprovedBatch])
+		assert.Equal(t, "alice", got.Annotations[oam.AnnotationRolloutApprover])
+
+		assert.NilError(t, ClearRolloutApproval(ctx, cli, got))
+		assert.Equal(t, 0, len(getApp().Annotations))
+	})
+
+	t.Run("no rollout", func(t *testing.T) {
+		assert.ErrorContains(t, PauseRollout(ctx, cli, "default", "no-rollout"), "has no rollout plan")
+		assert.ErrorContains(t, ApproveRolloutBatch(ctx, cli, "default", "missing", 0, ""), "is found")
+	})
+}
@@ -34,6 +34,7 @@ import (
 
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/pkg/appfile"
 	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/application/assemble"
 	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/application/dispatch"
 	oamutil "github.com/oam-dev/kubevela/pkg/oam/util"
@@ -61,8 +62,9 @@ type rolloutHandler struct {
 	// targetManifests used by dispatch(template targetRevision) and handleSucceed(GC) phase
 	targetManifests []*unstructured.Unstructured
 
-	// needRollComponent is find common component between source and target revision
-	needRollComponent string
+	// needRollComponents are the components to roll, they are the component list or the common component
+	// between source and target revision
+	needRollComponents []string
 }
 
 // prepareWorkloads call assemble func to prepare workload of every component
@@ -76,9 +78,11 @@ func (h *rolloutHandler) prepareWorkloads(ctx context.Context) error {
 	}
 
 	// construct a assemble manifest for targetAppRevision
-	targetAssemble := assemble.NewAppManifests(h.targetAppRevision).
-		WithWorkloadOption(rolloutWorkloadName(h.needRollComponent)).
-		WithWorkloadOption(assemble.PrepareWorkloadForRollout(h.needRollComponent))
+	targetAssemble := assemble.NewAppManifests(h.targetAppRevision)
+	for _, comp := range h.needRollComponents {
+		targetAssemble.WithWorkloadOption(rolloutWorkloadName(comp)).
+			WithWorkloadOption(assemble.PrepareWorkloadForRollout(comp))
+	}
 
 	h.targetWorkloads, _, _, err = targetAssemble.GroupAssembledManifests()
 	if err != nil {
@@ -92,9 +96,11 @@ func (h *rolloutHandler) prepareWorkloads(ctx context.Context) error {
 			return err
 		}
 		// construct a assemble manifest for sourceAppRevision
-		sourceAssemble := assemble.NewAppManifests(h.sourceAppRevision).
-			WithWorkloadOption(assemble.PrepareWorkloadForRollout(h.needRollComponent)).
-			WithWorkloadOption(rolloutWorkloadName(h.needRollComponent))
+		sourceAssemble := assemble.NewAppManifests(h.sourceAppRevision)
+		for _, comp := range h.needRollComponents {
+			sourceAssemble.WithWorkloadOption(assemble.PrepareWorkloadForRollout(comp)).
+				WithWorkloadOption(rolloutWorkloadName(comp))
+		}
 		h.sourceWorkloads, _, _, err = sourceAssemble.GroupAssembledManifests()
 		if err != nil {
 			klog.Error("appRollout sourceAppRevision failed to assemble workloads", "appRollout", klog.KRef(h.appRollout.Namespace, h.appRollout.Name))
@@ -104,41 +110,40 @@ func (h *rolloutHandler) prepareWorkloads(ctx context.Context) error {
 	return nil
 }
 
-// we only support one workload now, so this func is to determine witch component is need to rollout
+// determineRolloutComponent determines the components need to rollout, they are the components in the component list
+// or the only common component of the source and the target if the component list is not set
 func (h *rolloutHandler) determineRolloutComponent() error {
-	if h.needRollComponent != "" {
+	if len(h.needRollComponents) != 0 {
 		return nil
 	}
 
-	componentList := h.appRollout.Spec.ComponentList
-	// if user not set ComponentList in AppRollout we also find a common component between source and target
-	if len(componentList) == 0 {
-		// we need to find a default component
-		commons := appUtil.FindCommonComponentWithManifest(h.targetWorkloads, h.sourceWorkloads)
-		if len(commons) != 1 {
-			return fmt.Errorf("cannot find a default component, too many common components: %+v", commons)
-		}
-		h.needRollComponent = commons[0]
-	} else {
-		// assume that the validator webhook has already guaranteed that there is no more than one component for now
-		// and the component exists in both the target and source app
-		h.needRollComponent = componentList[0]
+	// if user not set ComponentList in AppRollout we find a common component between source and target
+	// we need to find a default component
+	commons := appUtil.FindCommonComponentWithManifest(h.targetWorkloads, h.sourceWorkloads)
+	if len(commons) != 1 {
+		return fmt.Errorf("cannot find a default component, too many common components: %+v", commons)
 	}
+	h.needRollComponents = commons
 	return nil
 }
 
-// fetch source and target workload
-func (h *rolloutHandler) fetchSourceAndTargetWorkload(ctx context.Context) (*unstructured.Unstructured, *unstructured.Unstructured, error) {
+// fetch source and target workload of the component
+func (h *rolloutHandler) fetchSourceAndTargetWorkload(ctx context.Context, comp string) (*unstructured.Unstructured, *unstructured.Unstructured, error) {
 	var sourceWorkload, targetWorkload *unstructured.Unstructured
 	var err error
 	if len(h.sourceRevName) == 0 {
 		klog.Info("source app fields not filled, this is a scale operation")
-	} else if sourceWorkload, err = h.extractWorkload(ctx, *h.sourceWorkloads[h.needRollComponent]); err != nil {
+	} else if h.sourceWorkloads[comp] == nil {
+		return nil, nil, errors.Errorf("source workload for component %s is not found", comp)
+	} else if sourceWorkload, err = h.extractWorkload(ctx, *h.sourceWorkloads[comp]); err != nil {
 		klog.Errorf("specified sourceRevName but cannot fetch source workload %s: %v",
 			h.appRollout.Spec.SourceAppRevisionName, err)
 		return nil, nil, err
 	}
-	if targetWorkload, err = h.extractWorkload(ctx, *h.targetWorkloads[h.needRollComponent]); err != nil {
+	if h.targetWorkloads[comp] == nil {
+		return nil, nil, errors.Errorf("target workload for component %s is not found", comp)
+	}
+	if targetWorkload, err = h.extractWorkload(ctx, *h.targetWorkloads[comp]); err != nil {
 		klog.Errorf("cannot fetch target workload %s: %v", h.appRollout.Spec.TargetAppRevisionName, err)
 		return nil, nil, err
 	}
@@ -164,8 +169,7 @@ func (h *rolloutHandler) handleRolloutModified() {
 	// we are okay to move directly to restart the rollout since we are at the terminal state
 	// however, we need to make sure we properly finalizing the existing rollout before restart if it's
 	// still in the middle of rolling out
-	if h.appRollout.Status.RollingState != v1alpha1.RolloutSucceedState &&
-		h.appRollout.Status.RollingState != v1alpha1.RolloutFailedState {
+	if !h.appRollout.Status.IsTerminated() {
 		// happen when roll forward or revert in middle of rollout, previous rollout haven't finished
 		// continue to handle the previous resources until we are okay to move forward
 		h.targetRevName = h.appRollout.Status.LastUpgradedTargetAppRevision
@@ -177,6 +181,8 @@ func (h *rolloutHandler) handleRolloutModified() {
 		// mark so that we don't think we are modified again
 		h.appRollout.Status.LastUpgradedTargetAppRevision = h.appRollout.Spec.TargetAppRevisionName
 		h.appRollout.Status.LastSourceAppRevision = h.appRollout.Spec.SourceAppRevisionName
+		h.appRollout.Status.RollingBack = false
+		h.appRollout.Status.RollbackReason = ""
 	}
 	h.appRollout.Status.StateTransition(v1alpha1.RollingModifiedEvent)
 }
@@ -188,10 +194,10 @@ func (h *rolloutHandler) templateTargetManifest(ctx context.Context) error {
 	// revision
 	if h.sourceAppRevision != nil {
 		rt = new(v1beta1.ResourceTracker)
-		err := h.Get(ctx, types.NamespacedName{Name: dispatch.ConstructResourceTrackerName(h.appRollout.Spec.SourceAppRevisionName, h.appRollout.Namespace)}, rt)
+		err := h.Get(ctx, types.NamespacedName{Name: dispatch.ConstructResourceTrackerName(h.sourceRevName, h.appRollout.Namespace)}, rt)
 		if err != nil {
 			klog.Errorf("specified sourceAppRevisionName %s but cannot fetch the sourceResourceTracker %v",
-				h.appRollout.Spec.SourceAppRevisionName, err)
+				h.sourceRevName, err)
 			return err
 		}
 	}
@@ -204,24 +210,26 @@ func (h *rolloutHandler) templateTargetManifest(ctx context.Context) error {
 		return err
 	}
 
-	// The workload not in target workloads can be not ready for insertSecret case
-	targetWL := h.targetWorkloads[h.needRollComponent]
-	if targetWL == nil {
-		return errors.Errorf("target workload for component %s for app %s is not ready", h.needRollComponent, h.targetAppRevision.Spec.Application.Name)
-	}
+	for _, comp := range h.needRollComponents {
+		// The workload not in target workloads can be not ready for insertSecret case
+		targetWL := h.targetWorkloads[comp]
+		if targetWL == nil {
+			return errors.Errorf("target workload for component %s for app %s is not ready", comp, h.targetAppRevision.Spec.Application.Name)
+		}
 
-	workload, err := h.extractWorkload(ctx, *targetWL)
-	if err != nil {
-		return err
-	}
-	ref := metav1.GetControllerOfNoCopy(workload)
-	if ref != nil && ref.Kind == v1beta1.ResourceTrackerKind {
-		wlPatch := client.MergeFrom(workload.DeepCopy())
-		// guarantee resourceTracker isn't controller owner of workload
-		disableControllerOwner(workload)
-		if err = h.Client.Patch(ctx, workload, wlPatch, client.FieldOwner(h.appRollout.UID)); err != nil {
+		workload, err := h.extractWorkload(ctx, *targetWL)
+		if err != nil {
 			return err
 		}
+		ref := metav1.GetControllerOfNoCopy(workload)
+		if ref != nil && ref.Kind == v1beta1.ResourceTrackerKind {
+			wlPatch := client.MergeFrom(workload.DeepCopy())
+			// guarantee resourceTracker isn't controller owner of workload
+			disableControllerOwner(workload)
+			if err = h.Client.Patch(ctx, workload, wlPatch, client.FieldOwner(h.appRollout.UID)); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -229,14 +237,16 @@ func (h *rolloutHandler) templateTargetManifest(ctx context.Context) error {
 // handle rollout succeed work left
 func (h *rolloutHandler) finalizeRollingSucceeded(ctx context.Context) error {
 	// yield controller owner back to resourceTracker
-	workload, err := h.extractWorkload(ctx, *h.targetWorkloads[h.needRollComponent])
-	if err != nil {
-		return err
-	}
-	wlPatch := client.MergeFrom(workload.DeepCopy())
-	enableControllerOwner(workload)
-	if err = h.Client.Patch(ctx, workload, wlPatch, client.FieldOwner(h.appRollout.UID)); err != nil {
-		return err
+	for _, comp := range h.needRollComponents {
+		workload, err := h.extractWorkload(ctx, *h.targetWorkloads[comp])
+		if err != nil {
+			return err
+		}
+		wlPatch := client.MergeFrom(workload.DeepCopy())
+		enableControllerOwner(workload)
+		if err = h.Client.Patch(ctx, workload, wlPatch, client.FieldOwner(h.appRollout.UID)); err != nil {
+			return err
+		}
 	}
 
 	// only when sourceAppRevision is not nil, we need gc old revision resources
@@ -252,8 +262,12 @@ func (h *rolloutHandler) finalizeRollingSucceeded(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		gcPolicy, err := appfile.ParseGarbageCollectPolicy(h.targetAppRevision.Spec.Application.Spec.Policies)
+		if err != nil {
+			return err
+		}
 		d := dispatch.NewAppManifestsDispatcher(h.Client, h.targetAppRevision).
-			EndAndGC(oldRT)
+			EndAndGC(oldRT).WithGCPolicy(gcPolicy)
 		// no need to dispatch manifest again, just do GC
 		if _, err := d.Dispatch(ctx, nil); err != nil {
 			return err
@@ -265,8 +279,8 @@ func (h *rolloutHandler) finalizeRollingSucceeded(ctx context.Context) error {
 // this func handle two case
 // 1. handle 1.0.x lagacy workload, their owner is appcontext so let resourceTracker take over it
 // 2. disable resourceTracker controller owner
-func (h *rolloutHandler) handleSourceWorkload(ctx context.Context) error {
-	workload, err := h.extractWorkload(ctx, *h.sourceWorkloads[h.needRollComponent])
+func (h *rolloutHandler) handleSourceWorkload(ctx context.Context, comp string) error {
+	workload, err := h.extractWorkload(ctx, *h.sourceWorkloads[comp])
 	if err != nil {
 		return err
 	}
@@ -321,9 +335,11 @@ func (h *rolloutHandler) assembleManifest(ctx context.Context) error {
 	}
 	var err error
 	// construct a assemble manifest for targetAppRevision
-	targetAssemble := assemble.NewAppManifests(h.targetAppRevision).
-		WithWorkloadOption(rolloutWorkloadName(h.needRollComponent)).
-		WithWorkloadOption(assemble.PrepareWorkloadForRollout(h.needRollComponent)).WithWorkloadOption(handleReplicas(ctx, h.needRollComponent, h))
+	targetAssemble := assemble.NewAppManifests(h.targetAppRevision)
+	for _, comp := range h.needRollComponents {
+		targetAssemble.WithWorkloadOption(rolloutWorkloadName(comp)).
+			WithWorkloadOption(assemble.PrepareWorkloadForRollout(comp)).WithWorkloadOption(handleReplicas(ctx, comp, h))
+	}
 
 	// in template phase, we should use targetManifests including target workloads/traits to
 	h.targetManifests, err = targetAssemble.AssembledManifests()
@@ -0,0 +1,145 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"time"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/clustermanager"
+	oamctrl "github.com/oam-dev/kubevela/pkg/controller/core.oam.dev"
+)
+
+const (
+	// DefaultProbeInterval is the default interval to probe a cluster
+	DefaultProbeInterval = time.Minute
+
+	reasonClusterReady       = "ClusterReady"
+	reasonClusterUnavailable = "ClusterUnavailable"
+)
+
+// Reconciler probes the health of a Cluster and records it in the status
+type Reconciler struct {
+	client.Client
+	Scheme               *runtime.Scheme
+	record               event.Recorder
+	probeInterval        time.Duration
+	concurrentReconciles int
+	// probe is replaceable for testing
+	probe func(ctx context.Context, cli client.Client, cluster *v1beta1.Cluster) (*clustermanager.ClusterInfo, error)
+}
+
+// +kubebuilder:rbac:groups=core.oam.dev,resources=clusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core.oam.dev,resources=clusters/status,verbs=get;update;patch
+
+// Reconcile probes the cluster and requeues it to probe again after the probe interval
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	klog.InfoS("Reconcile cluster", "cluster", klog.KRef(req.Namespace, req.Name))
+	ctx := context.Background()
+
+	cluster := new(v1beta1.Cluster)
+	if err := r.Get(ctx, req.NamespacedName, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			clustermanager.ForgetClusterClient(req.Namespace, req.Name)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if cluster.DeletionTimestamp != nil {
+		clustermanager.ForgetClusterClient(cluster.Namespace, cluster.Name)
+		return ctrl.Result{}, nil
+	}
+
+	probed, wasReady := cluster.Status.LastProbeTime != nil, clustermanager.IsClusterReady(cluster)
+	info, err := r.probe(ctx, r.Client, cluster)
+	now := metav1.Now()
+	cluster.Status.LastProbeTime = &now
+	if err != nil {
+		klog.ErrorS(err, "Failed to probe cluster", "cluster", klog.KObj(cluster))
+		cluster.Status.SetConditions(runtimev1alpha1.Unavailable().WithMessage(err.Error()))
+		if wasReady || !probed {
+			r.record.Event(cluster, event.Warning(reasonClusterUnavailable, err))
+		}
+	} else {
+		cluster.Status.KubernetesVersion = info.KubernetesVersion
+		cluster.Status.NodeCount = info.NodeCount
+		cluster.Status.Capacity = info.Capacity
+		cluster.Status.SetConditions(runtimev1alpha1.Available())
+		if !wasReady {
+			r.record.Event(cluster, event.Normal(reasonClusterReady, "Cluster is reachable"))
+		}
+	}
+	if err := r.updateStatus(ctx, cluster); err != nil {
+		klog.ErrorS(err, "Failed to update cluster status", "cluster", klog.KObj(cluster))
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: r.probeInterval}, nil
+}
+
+// updateStatus updates the status of the cluster with retry on conflict
+func (r *Reconciler) updateStatus(ctx context.Context, cluster *v1beta1.Cluster) error {
+	status := cluster.DeepCopy().Status
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		if err = r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}, cluster); err != nil {
+			return
+		}
+		cluster.Status = status
+		return r.Status().Update(ctx, cluster)
+	})
+}
+
+// SetupWithManager will setup with event recorder
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.record = event.NewAPIRecorder(mgr.GetEventRecorderFor("Cluster")).
+		WithAnnotations("controller", "Cluster")
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.concurrentReconciles,
+		}).
+		For(&v1beta1.Cluster{}).
+		// updating the status after probing must not trigger another probe
+		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		Complete(r)
+}
+
+// Setup adds a controller that reconciles Cluster.
+func Setup(mgr ctrl.Manager, args oamctrl.Args) error {
+	probeInterval := args.ClusterProbeInterval
+	if probeInterval <= 0 {
+		probeInterval = DefaultProbeInterval
+	}
+	r := Reconciler{
+		Client:               mgr.GetClient(),
+		Scheme:               mgr.GetScheme(),
+		probeInterval:        probeInterval,
+		concurrentReconciles: args.ConcurrentReconciles,
+		probe:                clustermanager.ProbeCluster,
+	}
+	return r.SetupWithManager(mgr)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/clustermanager"
+	"github.com/oam-dev/kubevela/pkg/utils/common"
+)
+
+func TestReconcile(t *testing.T) {
+	cluster := &v1beta1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "prod", Namespace: "default"}}
+	cli := fake.NewFakeClientWithScheme(common.Scheme, cluster)
+	var probeErr error
+	r := &Reconciler{
+		Client:        cli,
+		Scheme:        common.Scheme,
+		record:        event.NewNopRecorder(),
+		probeInterval: DefaultProbeInterval,
+		probe: func(ctx context.Context, cli client.Client, cluster *v1beta1.Cluster) (*clustermanager.ClusterInfo, error) {
+			if probeErr != nil {
+				return nil, probeErr
+			}
+			return &clustermanager.ClusterInfo{
+				KubernetesVersion: "v1.20.2",
+				NodeCount:         2,
+				Capacity:          corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8")},
+			}, nil
+		},
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "prod"}}
+	key := client.ObjectKey{Namespace: "default", Name: "prod"}
+
+	res, err := r.Reconcile(req)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultProbeInterval, res.RequeueAfter)
+	got := &v1beta1.Cluster{}
+	assert.NoError(t, cli.Get(context.Background(), key, got))
+	assert.True(t, clustermanager.IsClusterReady(got))
+	assert.Equal(t, "v1.20.2", got.Status.KubernetesVersion)
+	assert.Equal(t, 2, got.Status.NodeCount)
+	assert.Equal(t, "8", got.Status.Capacity.Cpu().String())
+	assert.NotNil(t, got.Status.LastProbeTime)
+
+	probeErr = errors.New("connection refused")
+	_, err = r.Reconcile(req)
+	assert.NoError(t, err)
+	assert.NoError(t, cli.Get(context.Background(), key, got))
+	assert.False(t, clustermanager.IsClusterReady(got))
+	assert.Equal(t, runtimev1alpha1.ReasonUnavailable, got.Status.GetCondition(runtimev1alpha1.TypeReady).Reason)
+	assert.Equal(t, "connection refused", got.Status.GetCondition(runtimev1alpha1.TypeReady).Message)
+	// the last known version is kept
+	assert.Equal(t, "v1.20.2", got.Status.KubernetesVersion)
+}
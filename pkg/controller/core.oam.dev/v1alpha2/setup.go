@@ -24,6 +24,7 @@ import (
 	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/application"
 	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/applicationconfiguration"
 	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/applicationrollout"
+	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/cluster"
 	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/core/components/componentdefinition"
 	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/core/policies/policydefinition"
 	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/core/traits/traitdefinition"
@@ -35,7 +36,7 @@ import (
 func Setup(mgr ctrl.Manager, args controller.Args) error {
 	if args.OAMSpecVer == "v0.3" || args.OAMSpecVer == "all" {
 		for _, setup := range []func(ctrl.Manager, controller.Args) error{
-			application.Setup, applicationrollout.Setup, appdeployment.Setup,
+			application.Setup, applicationrollout.Setup, appdeployment.Setup, cluster.Setup,
 			traitdefinition.Setup, componentdefinition.Setup, policydefinition.Setup, workflowstepdefinition.Setup,
 			initializer.Setup,
 		} {
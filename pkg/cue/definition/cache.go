@@ -0,0 +1,191 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package definition
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/parser"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/cache"
+
+	velacue "github.com/oam-dev/kubevela/pkg/cue"
+	"github.com/oam-dev/kubevela/pkg/cue/packages"
+	"github.com/oam-dev/kubevela/pkg/cue/process"
+	"github.com/oam-dev/kubevela/pkg/monitor/metrics"
+)
+
+const (
+	// templateCacheSize is the max number of the definition templates in the cache
+	templateCacheSize = 1024
+	// templateCacheTTL is the time a template stays in the cache, so that the templates of the outdated
+	// definition revisions are released
+	templateCacheTTL = time.Hour
+	// contextDeclFile declares the context for building a template without the context, the context is filled
+	// into the built template when rendering
+	contextDeclFile = "context: _"
+)
+
+var templateCache = cache.NewLRUExpireCache(templateCacheSize)
+
+type templateCacheKey struct {
+	pd   *packages.PackageDiscover
+	hash string
+}
+
+// parsedTemplate holds the parsed files of a definition template. The files are built into a new CUE runtime
+// each time the template is rendered, a runtime keeps every instance compiled or filled in it, so it isn't reused
+// and nothing of a rendering, e.g., the secrets in the context, is left in the cache.
+type parsedTemplate struct {
+	template string
+	files    []*ast.File
+	err      error
+
+	// the references in the files are resolved in place when building them, so the builds are serialized
+	mutex sync.Mutex
+}
+
+// getParsedTemplate gets the parsed template from the cache by the revision hash of the definition,
+// or by the hash of the template if the definition has no revision.
+func getParsedTemplate(pd *packages.PackageDiscover, revisionHash, template string) *parsedTemplate {
+	hash := revisionHash
+	if hash == "" {
+		sum := sha256.Sum256([]byte(template))
+		hash = hex.EncodeToString(sum[:])
+	}
+	key := templateCacheKey{pd: pd, hash: hash}
+	if v, ok := templateCache.Get(key); ok {
+		// the revision in the status of a definition may be not updated yet after the definition is changed
+		if pt := v.(*parsedTemplate); pt.template == template {
+			metrics.TemplateCacheRequests.WithLabelValues(metrics.CacheHit).Inc()
+			return pt
+		}
+	}
+	metrics.TemplateCacheRequests.WithLabelValues(metrics.CacheMiss).Inc()
+	pt := parseTemplate(template)
+	templateCache.Add(key, pt, templateCacheTTL)
+	return pt
+}
+
+func parseTemplate(template string) *parsedTemplate {
+	pt := &parsedTemplate{template: template}
+	for _, src := range []struct{ name, content string }{{"-", template}, {"context", contextDeclFile}} {
+		f, err := parser.ParseFile(src.name, src.content, parser.ParseComments)
+		if err != nil {
+			pt.err = err
+			return pt
+		}
+		pt.files = append(pt.files, f)
+	}
+	return pt
+}
+
+// build builds the parsed template into a new runtime
+func (pt *parsedTemplate) build(pd *packages.PackageDiscover) (*cue.Runtime, *cue.Instance, error) {
+	if pt.err != nil {
+		return nil, nil, pt.err
+	}
+	pt.mutex.Lock()
+	defer pt.mutex.Unlock()
+	bi := build.NewContext().NewInstance("", nil)
+	for _, f := range pt.files {
+		if err := bi.AddSyntax(f); err != nil {
+			return nil, nil, err
+		}
+	}
+	r := &cue.Runtime{}
+	inst, err := pd.ImportPackagesAndBuildInstanceWithRuntime(r, bi)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, inst, nil
+}
+
+// fill fills the parameter and context into the instance built in the runtime
+func fill(r *cue.Runtime, inst *cue.Instance, paramFile, contextFile string) (*cue.Instance, error) {
+	param, err := r.Compile("parameter", paramFile)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid parameter")
+	}
+	ctxInst, err := r.Compile("context", contextFile)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid context")
+	}
+	inst, err = inst.Fill(param.Value())
+	if err != nil {
+		return nil, errors.WithMessage(err, "fill parameter")
+	}
+	inst, err = inst.Fill(ctxInst.Value())
+	if err != nil {
+		return nil, errors.WithMessage(err, "fill context")
+	}
+	return inst, nil
+}
+
+// render renders the template of the definition with the parameter and context.
+// A template is parsed once and cached, but some templates can't be built without the context, e.g., the ones
+// referring to the secrets in the extended context, they're built together with the parameter and context.
+func (d *def) render(ctx process.Context, kind, abstractTemplate string, params interface{}) (*cue.Instance, error) {
+	paramFile, err := parameterFile(params)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "marshal parameter of %s %s", kind, d.name)
+	}
+	contextFile := ctx.ExtendedContextFile()
+
+	pt := getParsedTemplate(d.pd, d.revisionHash, abstractTemplate)
+	if r, inst, err := pt.build(d.pd); err == nil {
+		inst, err := fill(r, inst, paramFile, contextFile)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "render %s %s", kind, d.name)
+		}
+		return inst, nil
+	}
+
+	bi := build.NewContext().NewInstance("", nil)
+	if err := bi.AddFile("-", abstractTemplate); err != nil {
+		return nil, errors.WithMessagef(err, "invalid template of %s %s", kind, d.name)
+	}
+	if err := bi.AddFile("parameter", paramFile); err != nil {
+		return nil, errors.WithMessagef(err, "invalid parameter of %s %s", kind, d.name)
+	}
+	if err := bi.AddFile("context", contextFile); err != nil {
+		return nil, errors.WithMessagef(err, "invalid context of %s %s", kind, d.name)
+	}
+	return d.pd.ImportPackagesAndBuildInstance(bi)
+}
+
+func parameterFile(params interface{}) (string, error) {
+	var paramFile = "parameter: {}"
+	if params != nil {
+		bt, err := json.Marshal(params)
+		if err != nil {
+			return "", err
+		}
+		if string(bt) != "null" {
+			paramFile = fmt.Sprintf("%s: %s", velacue.ParameterTag, string(bt))
+		}
+	}
+	return paramFile, nil
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package definition
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/oam-dev/kubevela/pkg/cue/packages"
+	"github.com/oam-dev/kubevela/pkg/cue/process"
+)
+
+const cachedWorkloadTemplate = `
+import "strings"
+
+output: {
+	apiVersion: "apps/v1"
+	kind:       "Deployment"
+	metadata: name: strings.ToLower(context.name)
+	spec: {
+		replicas: parameter.replicas
+		template: spec: containers: [{
+			image: parameter.image
+			if parameter["cmd"] != _|_ {
+				command: parameter.cmd
+			}
+			env: [ for k, v in parameter.env {name: k, value: v}]
+		}]
+	}
+}
+parameter: {
+	image:    string
+	replicas: *1 | int
+	cmd?: [...string]
+	env: [string]: string
+}
+`
+
+func renderWorkload(engine AbstractEngine, template, name string, params map[string]interface{}) (*unstructured.Unstructured, error) {
+	ctx := process.NewContext("default", name, "myapp", "myapp-v1")
+	if err := engine.Complete(ctx, template, params); err != nil {
+		return nil, err
+	}
+	base, _ := ctx.Output()
+	return base.Unstructured()
+}
+
+func expectedWorkload(name, image string, replicas int64, cmd []interface{}, env []interface{}) *unstructured.Unstructured {
+	container := map[string]interface{}{"image": image, "env": env}
+	if cmd != nil {
+		container["command"] = cmd
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{container},
+			}},
+		},
+	}}
+}
+
+func TestRenderCachedTemplate(t *testing.T) {
+	pd := &packages.PackageDiscover{}
+	engine := NewWorkloadAbstractEngine("web", pd, WithRevisionHash("render-hash"))
+
+	got, err := renderWorkload(engine, cachedWorkloadTemplate, "Web", map[string]interface{}{
+		"image": "nginx", "cmd": []string{"run"}, "env": map[string]string{"A": "1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, expectedWorkload("web", "nginx", 1, []interface{}{"run"},
+		[]interface{}{map[string]interface{}{"name": "A", "value": "1"}}), got)
+
+	// the parsed template is reused, nothing of the last rendering is left in it
+	got, err = renderWorkload(engine, cachedWorkloadTemplate, "Api", map[string]interface{}{
+		"image": "busybox", "replicas": 3, "env": map[string]string{},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, expectedWorkload("api", "busybox", 3, nil, []interface{}{}), got)
+
+	// a conflicting parameter doesn't break the parsed template
+	_, err = renderWorkload(engine, cachedWorkloadTemplate, "bad", map[string]interface{}{"image": 1, "env": map[string]string{}})
+	assert.Error(t, err)
+	got, err = renderWorkload(engine, cachedWorkloadTemplate, "web", map[string]interface{}{"image": "nginx", "env": map[string]string{}})
+	require.NoError(t, err)
+	assert.Equal(t, expectedWorkload("web", "nginx", 1, nil, []interface{}{}), got)
+
+	// the template is parsed again if it's changed but the revision is not updated yet
+	changed := `output: {apiVersion: "v1", kind: "ConfigMap", metadata: name: context.name}`
+	got, err = renderWorkload(engine, changed, "web", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ConfigMap", got.GetKind())
+}
+
+// runtimeInstances counts the instances kept in the runtime of the instance
+func runtimeInstances(inst *cue.Instance) int {
+	return reflect.ValueOf(inst).Elem().FieldByName("imports").Len()
+}
+
+func TestRenderTemplateInNewRuntime(t *testing.T) {
+	pd := &packages.PackageDiscover{}
+	wd := NewWorkloadAbstractEngine("web", pd, WithRevisionHash("runtime-hash")).(*workloadDef)
+	pt := getParsedTemplate(pd, "runtime-hash", cachedWorkloadTemplate)
+
+	var counts []int
+	for i := 0; i < 10; i++ {
+		ctx := process.NewContext("default", "web", "myapp", "myapp-v1")
+		image := fmt.Sprintf("nginx:%d", i)
+		inst, err := wd.render(ctx, "workload", cachedWorkloadTemplate, map[string]interface{}{"image": image, "env": map[string]string{}})
+		require.NoError(t, err)
+		got, err := inst.Lookup(OutputFieldName, "spec", "template", "spec", "containers").List()
+		require.NoError(t, err)
+		require.True(t, got.Next())
+		gotImage, err := got.Value().Lookup("image").String()
+		require.NoError(t, err)
+		assert.Equal(t, image, gotImage)
+		counts = append(counts, runtimeInstances(inst))
+	}
+	// each rendering is done in a new runtime, so the runtimes don't grow with the renderings
+	for _, n := range counts {
+		assert.Equal(t, counts[0], n)
+	}
+	// only the parsed files are cached
+	assert.Same(t, pt, getParsedTemplate(pd, "runtime-hash", cachedWorkloadTemplate))
+	assert.Len(t, pt.files, 2)
+}
+
+func TestRenderTemplateWithSecrets(t *testing.T) {
+	template := `
+output: {
+	apiVersion: "v1"
+	kind:       "ConfigMap"
+	metadata: name: context.name
+	data: password: dbConn.password
+}
+`
+	ctx := process.NewContext("default", "web", "myapp", "myapp-v1")
+	ctx.InsertSecrets("", []process.RequiredSecrets{{Name: "db", ContextName: "dbConn", Data: map[string]interface{}{"password": "secret"}}})
+	engine := NewWorkloadAbstractEngine("web", &packages.PackageDiscover{})
+	require.NoError(t, engine.Complete(ctx, template, nil))
+	base, _ := ctx.Output()
+	obj, err := base.Unstructured()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "secret"}, obj.Object["data"])
+}
+
+func TestRenderCachedTemplateConcurrently(t *testing.T) {
+	pd := &packages.PackageDiscover{}
+	var wg sync.WaitGroup
+	errs := make([]error, 20)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("web%d", i)
+			engine := NewWorkloadAbstractEngine(name, pd, WithRevisionHash("concurrent-hash"))
+			for j := 0; j < 5; j++ {
+				image := fmt.Sprintf("nginx:%d", j)
+				got, err := renderWorkload(engine, cachedWorkloadTemplate, name, map[string]interface{}{
+					"image": image, "replicas": i, "env": map[string]string{"N": name},
+				})
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				want := expectedWorkload(name, image, int64(i), nil, []interface{}{map[string]interface{}{"name": "N", "value": name}})
+				if !assert.ObjectsAreEqual(want, got) {
+					errs[i] = fmt.Errorf("unexpected workload %v", got.Object)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+}
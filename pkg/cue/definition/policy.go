@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package definition
+
+import (
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/pkg/cue/packages"
+	"github.com/oam-dev/kubevela/pkg/cue/process"
+)
+
+const (
+	// ViolationsFieldName is the name of the list of messages reported by a policy when the component violates it
+	ViolationsFieldName = "violations"
+)
+
+// PolicyViolationError is the error returned when the rendered resources of a component violate a policy.
+type PolicyViolationError struct {
+	Policy     string
+	Component  string
+	Violations []string
+}
+
+func (e *PolicyViolationError) Error() string {
+	return fmt.Sprintf("component %s violates policy %s: %s", e.Component, e.Policy, strings.Join(e.Violations, "; "))
+}
+
+// IsPolicyViolation checks whether the error is caused by a policy violation.
+func IsPolicyViolation(err error) bool {
+	var violation *PolicyViolationError
+	return errors.As(err, &violation)
+}
+
+// IsPatchPolicy checks whether the policy template patches or validates the resources of components,
+// instead of rendering a standalone resource.
+func IsPatchPolicy(abstractTemplate string) bool {
+	f, err := parser.ParseFile("-", abstractTemplate)
+	if err != nil {
+		return false
+	}
+	for _, decl := range f.Decls {
+		field, ok := decl.(*ast.Field)
+		if !ok {
+			continue
+		}
+		if name, _, _ := ast.LabelName(field.Label); name == PatchFieldName || name == ViolationsFieldName {
+			return true
+		}
+	}
+	return false
+}
+
+type policyDef struct {
+	def
+}
+
+// NewPolicyAbstractEngine create Policy Definition AbstractEngine
+// The engine patches the resources of a component with the `patch` of the policy like a trait,
+// and reports the `violations` of the policy.
+func NewPolicyAbstractEngine(name string, pd *packages.PackageDiscover, opts ...EngineOption) AbstractEngine {
+	return &policyDef{def: newDef(name, pd, opts)}
+}
+
+// Complete do policy definition's rendering against the resources of a component
+func (pd *policyDef) Complete(ctx process.Context, abstractTemplate string, params interface{}) error {
+	inst, err := pd.render(ctx, "policy", abstractTemplate, params)
+	if err != nil {
+		return err
+	}
+	if err := inst.Value().Validate(); err != nil {
+		return errors.WithMessagef(err, "invalid template of policy %s after merge with parameter and context", pd.name)
+	}
+
+	// violations are checked against the resources before they are patched
+	if v := inst.Lookup(ViolationsFieldName); v.Exists() {
+		var violations []string
+		if err := v.Decode(&violations); err != nil {
+			return errors.WithMessagef(err, "invalid violations of policy %s", pd.name)
+		}
+		if len(violations) != 0 {
+			return &PolicyViolationError{Policy: pd.name, Component: ctx.BaseContextLabels()[process.ContextName], Violations: violations}
+		}
+	}
+	patcher := inst.Lookup(PatchFieldName)
+	if patcher.Exists() {
+		return patchContext(ctx, patcher, "policy", pd.name)
+	}
+	return nil
+}
+
+// Status is not supported by policies
+func (pd *policyDef) Status(ctx process.Context, cli client.Client, ns string, customStatusTemplate string, parameter interface{}) (string, error) {
+	return "", nil
+}
+
+// HealthCheck is not supported by policies, they're always healthy
+func (pd *policyDef) HealthCheck(ctx process.Context, cli client.Client, ns string, healthPolicyTemplate string) (HealthResult, error) {
+	return HealthResult{Phase: common.HealthPhaseHealthy}, nil
+}
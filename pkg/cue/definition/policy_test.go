@@ -0,0 +1,143 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package definition
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/oam-dev/kubevela/pkg/cue/packages"
+	"github.com/oam-dev/kubevela/pkg/cue/process"
+)
+
+func TestPolicyTemplateComplete(t *testing.T) {
+	workloadTemplate := `
+output: {
+	apiVersion: "apps/v1"
+	kind:       "Deployment"
+	metadata: name: context.name
+	spec: template: spec: containers: [{
+		name:  "main"
+		image: "docker.io/website:0.1"
+	}]
+}
+outputs: service: {
+	apiVersion: "v1"
+	kind:       "Service"
+	metadata: name: context.name
+}
+`
+	testcases := map[string]struct {
+		policyTemplate string
+		params         map[string]interface{}
+		expWorkload    map[string]interface{}
+		violated       bool
+		hasErr         bool
+	}{
+		"patch labels into the workload": {
+			policyTemplate: `
+patch: metadata: labels: team: parameter.team
+parameter: team: string
+`,
+			params: map[string]interface{}{"team": "frontend"},
+			expWorkload: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name":   "test",
+					"labels": map[string]interface{}{"team": "frontend"},
+				},
+				"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "main", "image": "docker.io/website:0.1"}},
+				}}},
+			},
+		},
+		"deny images from unapproved registries": {
+			policyTemplate: `
+import "strings"
+
+violations: [ for c in context.output.spec.template.spec.containers if !strings.HasPrefix(c.image, parameter.registry) {
+	"image \(c.image) of container \(c.name) is not from \(parameter.registry)"
+}]
+parameter: registry: string
+`,
+			params:   map[string]interface{}{"registry": "registry.example.com/"},
+			violated: true,
+		},
+		"approved images": {
+			policyTemplate: `
+import "strings"
+
+violations: [ for c in context.output.spec.template.spec.containers if !strings.HasPrefix(c.image, parameter.registry) {
+	"image \(c.image) of container \(c.name) is not from \(parameter.registry)"
+}]
+parameter: registry: string
+`,
+			params: map[string]interface{}{"registry": "docker.io/"},
+			expWorkload: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"name": "test"},
+				"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "main", "image": "docker.io/website:0.1"}},
+				}}},
+			},
+		},
+		"invalid violations": {
+			policyTemplate: `violations: "not a list"`,
+			hasErr:         true,
+		},
+	}
+	for name, tc := range testcases {
+		ctx := process.NewContext("default", "test", "myapp", "myapp-v1")
+		wt := NewWorkloadAbstractEngine("test", &packages.PackageDiscover{})
+		assert.NoError(t, wt.Complete(ctx, workloadTemplate, nil), name)
+
+		pd := NewPolicyAbstractEngine("policy", &packages.PackageDiscover{})
+		err := pd.Complete(ctx, tc.policyTemplate, tc.params)
+		if tc.violated {
+			assert.Equal(t, true, IsPolicyViolation(errors.WithMessage(err, "render")), name)
+			assert.EqualError(t, err, "component test violates policy policy: image docker.io/website:0.1 of container main is not from registry.example.com/", name)
+			continue
+		}
+		if tc.hasErr {
+			assert.Error(t, err, name)
+			assert.Equal(t, false, IsPolicyViolation(err), name)
+			continue
+		}
+		assert.NoError(t, err, name)
+		base, _ := ctx.Output()
+		wl, err := base.Unstructured()
+		assert.NoError(t, err, name)
+		assert.Equal(t, &unstructured.Unstructured{Object: tc.expWorkload}, wl, name)
+	}
+}
+
+func TestIsPatchPolicy(t *testing.T) {
+	assert.Equal(t, true, IsPatchPolicy(`patch: metadata: labels: team: "a"`))
+	assert.Equal(t, true, IsPatchPolicy(`
+import "strings"
+
+violations: []
+`))
+	assert.Equal(t, false, IsPatchPolicy(`output: {apiVersion: "v1", kind: "ConfigMap"}`))
+	assert.Equal(t, false, IsPatchPolicy(`output: patch: {}`))
+	assert.Equal(t, false, IsPatchPolicy(`invalid: {`))
+}
@@ -19,15 +19,13 @@ package definition
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
 	"cuelang.org/go/cue"
-	"cuelang.org/go/cue/build"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	velacue "github.com/oam-dev/kubevela/pkg/cue"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
 	"github.com/oam-dev/kubevela/pkg/cue/model"
 	"github.com/oam-dev/kubevela/pkg/cue/packages"
 	"github.com/oam-dev/kubevela/pkg/cue/process"
@@ -47,6 +45,9 @@ const (
 	CustomMessage = "message"
 	// HealthCheckPolicy defines the health check policy in definition template
 	HealthCheckPolicy = "isHealth"
+	// HealthFieldName is the name of the struct contains the health phase and reason in the health check policy,
+	// it takes precedence over isHealth
+	HealthFieldName = "health"
 )
 
 const (
@@ -55,16 +56,48 @@ const (
 	AuxiliaryWorkload = "AuxiliaryWorkload"
 )
 
+// HealthResult is the result of the health check policy of a definition
+type HealthResult struct {
+	Phase  common.HealthPhase `json:"phase"`
+	Reason string             `json:"reason,omitempty"`
+}
+
+// Healthy checks whether the health phase is healthy
+func (r HealthResult) Healthy() bool {
+	return r.Phase == common.HealthPhaseHealthy
+}
+
 // AbstractEngine defines Definition's Render interface
 type AbstractEngine interface {
 	Complete(ctx process.Context, abstractTemplate string, params interface{}) error
-	HealthCheck(ctx process.Context, cli client.Client, ns string, healthPolicyTemplate string) (bool, error)
+	HealthCheck(ctx process.Context, cli client.Client, ns string, healthPolicyTemplate string) (HealthResult, error)
 	Status(ctx process.Context, cli client.Client, ns string, customStatusTemplate string, parameter interface{}) (string, error)
 }
 
 type def struct {
 	name string
 	pd   *packages.PackageDiscover
+	// revisionHash is the hash of the definition revision, the parsed template is cached by it
+	revisionHash string
+}
+
+// EngineOption configures the AbstractEngine of a definition
+type EngineOption func(*def)
+
+// WithRevisionHash sets the revision hash of the definition, so that the parsed template is cached by the
+// revision instead of the content of the template
+func WithRevisionHash(hash string) EngineOption {
+	return func(d *def) {
+		d.revisionHash = hash
+	}
+}
+
+func newDef(name string, pd *packages.PackageDiscover, opts []EngineOption) def {
+	d := def{name: name, pd: pd}
+	for _, opt := range opts {
+		opt(&d)
+	}
+	return d
 }
 
 type workloadDef struct {
@@ -72,40 +105,13 @@ type workloadDef struct {
 }
 
 // NewWorkloadAbstractEngine create Workload Definition AbstractEngine
-func NewWorkloadAbstractEngine(name string, pd *packages.PackageDiscover) AbstractEngine {
-	return &workloadDef{
-		def: def{
-			name: name,
-			pd:   pd,
-		},
-	}
+func NewWorkloadAbstractEngine(name string, pd *packages.PackageDiscover, opts ...EngineOption) AbstractEngine {
+	return &workloadDef{def: newDef(name, pd, opts)}
 }
 
 // Complete do workload definition's rendering
 func (wd *workloadDef) Complete(ctx process.Context, abstractTemplate string, params interface{}) error {
-	bi := build.NewContext().NewInstance("", nil)
-	if err := bi.AddFile("-", abstractTemplate); err != nil {
-		return errors.WithMessagef(err, "invalid cue template of workload %s", wd.name)
-	}
-	var paramFile = "parameter: {}"
-	if params != nil {
-		bt, err := json.Marshal(params)
-		if err != nil {
-			return errors.WithMessagef(err, "marshal parameter of workload %s", wd.name)
-		}
-		if string(bt) != "null" {
-			paramFile = fmt.Sprintf("%s: %s", velacue.ParameterTag, string(bt))
-		}
-	}
-	if err := bi.AddFile("parameter", paramFile); err != nil {
-		return errors.WithMessagef(err, "invalid parameter of workload %s", wd.name)
-	}
-
-	if err := bi.AddFile("-", ctx.ExtendedContextFile()); err != nil {
-		return err
-	}
-
-	inst, err := wd.pd.ImportPackagesAndBuildInstance(bi)
+	inst, err := wd.render(ctx, "workload", abstractTemplate, params)
 	if err != nil {
 		return err
 	}
@@ -193,34 +199,52 @@ func (wd *workloadDef) getTemplateContext(ctx process.Context, cli client.Reader
 }
 
 // HealthCheck address health check for workload
-func (wd *workloadDef) HealthCheck(ctx process.Context, cli client.Client, ns string, healthPolicyTemplate string) (bool, error) {
+func (wd *workloadDef) HealthCheck(ctx process.Context, cli client.Client, ns string, healthPolicyTemplate string) (HealthResult, error) {
 	if healthPolicyTemplate == "" {
-		return true, nil
+		return HealthResult{Phase: common.HealthPhaseHealthy}, nil
 	}
 	templateContext, err := wd.getTemplateContext(ctx, cli, ns)
 	if err != nil {
-		return false, errors.WithMessage(err, "get template context")
+		return HealthResult{}, errors.WithMessage(err, "get template context")
 	}
 	return checkHealth(templateContext, healthPolicyTemplate)
 }
 
-func checkHealth(templateContext map[string]interface{}, healthPolicyTemplate string) (bool, error) {
+// checkHealth evaluates the health check policy with the template context. The policy either reports the phase
+// and reason in the health field, or reports a bool in the isHealth field which is healthy or unhealthy.
+func checkHealth(templateContext map[string]interface{}, healthPolicyTemplate string) (HealthResult, error) {
 	bt, err := json.Marshal(templateContext)
 	if err != nil {
-		return false, errors.WithMessage(err, "json marshal template context")
+		return HealthResult{}, errors.WithMessage(err, "json marshal template context")
 	}
 
 	var buff = "context: " + string(bt) + "\n" + healthPolicyTemplate
 	var r cue.Runtime
 	inst, err := r.Compile("-", buff)
 	if err != nil {
-		return false, errors.WithMessage(err, "compile health template")
+		return HealthResult{}, errors.WithMessage(err, "compile health template")
+	}
+	if health := inst.Lookup(HealthFieldName); health.Exists() {
+		var result HealthResult
+		if err := health.Decode(&result); err != nil {
+			return HealthResult{}, errors.WithMessage(err, "evaluate health status")
+		}
+		switch result.Phase {
+		case common.HealthPhaseHealthy, common.HealthPhaseProgressing, common.HealthPhaseUnhealthy:
+			return result, nil
+		default:
+			return HealthResult{}, errors.Errorf("invalid health phase %q, expect %s, %s or %s", result.Phase,
+				common.HealthPhaseHealthy, common.HealthPhaseProgressing, common.HealthPhaseUnhealthy)
+		}
 	}
 	healthy, err := inst.Lookup(HealthCheckPolicy).Bool()
 	if err != nil {
-		return false, errors.WithMessage(err, "evaluate health status")
+		return HealthResult{}, errors.WithMessage(err, "evaluate health status")
 	}
-	return healthy, nil
+	if !healthy {
+		return HealthResult{Phase: common.HealthPhaseUnhealthy}, nil
+	}
+	return HealthResult{Phase: common.HealthPhaseHealthy}, nil
 }
 
 // Status get workload status by customStatusTemplate
@@ -271,39 +295,13 @@ type traitDef struct {
 }
 
 // NewTraitAbstractEngine create Trait Definition AbstractEngine
-func NewTraitAbstractEngine(name string, pd *packages.PackageDiscover) AbstractEngine {
-	return &traitDef{
-		def: def{
-			name: name,
-			pd:   pd,
-		},
-	}
+func NewTraitAbstractEngine(name string, pd *packages.PackageDiscover, opts ...EngineOption) AbstractEngine {
+	return &traitDef{def: newDef(name, pd, opts)}
 }
 
 // Complete do trait definition's rendering
 func (td *traitDef) Complete(ctx process.Context, abstractTemplate string, params interface{}) error {
-	bi := build.NewContext().NewInstance("", nil)
-	if err := bi.AddFile("-", abstractTemplate); err != nil {
-		return errors.WithMessagef(err, "invalid template of trait %s", td.name)
-	}
-	var paramFile = "parameter: {}"
-	if params != nil {
-		bt, err := json.Marshal(params)
-		if err != nil {
-			return errors.WithMessagef(err, "marshal parameter of trait %s", td.name)
-		}
-		if string(bt) != "null" {
-			paramFile = fmt.Sprintf("%s: %s", velacue.ParameterTag, string(bt))
-		}
-	}
-	if err := bi.AddFile("parameter", paramFile); err != nil {
-		return errors.WithMessagef(err, "invalid parameter of trait %s", td.name)
-	}
-	if err := bi.AddFile("context", ctx.ExtendedContextFile()); err != nil {
-		return errors.WithMessagef(err, "invalid context of trait %s", td.name)
-	}
-
-	inst, err := td.pd.ImportPackagesAndBuildInstance(bi)
+	inst, err := td.render(ctx, "trait", abstractTemplate, params)
 	if err != nil {
 		return err
 	}
@@ -340,29 +338,36 @@ func (td *traitDef) Complete(ctx process.Context, abstractTemplate string, param
 
 	patcher := inst.Lookup(PatchFieldName)
 	if patcher.Exists() {
-		base, auxiliaries := ctx.Output()
-		p, err := model.NewOther(patcher)
-		if err != nil {
-			return errors.WithMessagef(err, "invalid patch of trait %s", td.name)
-		}
-		if err := base.Unify(p); err != nil {
-			return errors.WithMessagef(err, "invalid patch trait %s into workload", td.name)
-		}
+		return patchContext(ctx, patcher, "trait", td.name)
+	}
+
+	return nil
+}
+
+// patchContext unifies the patch into the workload and the auxiliary workloads of the context,
+// the patch of an auxiliary workload is in `patch.context.outputs.<name>`.
+func patchContext(ctx process.Context, patcher cue.Value, kind, name string) error {
+	base, auxiliaries := ctx.Output()
+	p, err := model.NewOther(patcher)
+	if err != nil {
+		return errors.WithMessagef(err, "invalid patch of %s %s", kind, name)
+	}
+	if err := base.Unify(p); err != nil {
+		return errors.WithMessagef(err, "invalid patch %s %s into workload", kind, name)
+	}
 
-		for _, auxiliary := range auxiliaries {
-			target := patcher.Lookup("context", "outputs", auxiliary.Name)
-			if target.Exists() {
-				t, err := model.NewOther(target)
-				if err != nil {
-					return errors.WithMessagef(err, "trait=%s, to=%s, invalid trait patch", td.name, auxiliary.Name)
-				}
-				if err := auxiliary.Ins.Unify(t); err != nil {
-					return errors.WithMessagef(err, "trait=%s, to=%s, invalid patch trait into auxiliary workload", td.name, auxiliary.Name)
-				}
+	for _, auxiliary := range auxiliaries {
+		target := patcher.Lookup("context", "outputs", auxiliary.Name)
+		if target.Exists() {
+			t, err := model.NewOther(target)
+			if err != nil {
+				return errors.WithMessagef(err, "%s=%s, to=%s, invalid %s patch", kind, name, auxiliary.Name, kind)
+			}
+			if err := auxiliary.Ins.Unify(t); err != nil {
+				return errors.WithMessagef(err, "%s=%s, to=%s, invalid patch %s into auxiliary workload", kind, name, auxiliary.Name, kind)
 			}
 		}
 	}
-
 	return nil
 }
 
@@ -431,13 +436,13 @@ func (td *traitDef) Status(ctx process.Context, cli client.Client, ns string, cu
 }
 
 // HealthCheck address health check for trait
-func (td *traitDef) HealthCheck(ctx process.Context, cli client.Client, ns string, healthPolicyTemplate string) (bool, error) {
+func (td *traitDef) HealthCheck(ctx process.Context, cli client.Client, ns string, healthPolicyTemplate string) (HealthResult, error) {
 	if healthPolicyTemplate == "" {
-		return true, nil
+		return HealthResult{Phase: common.HealthPhaseHealthy}, nil
 	}
 	templateContext, err := td.getTemplateContext(ctx, cli, ns)
 	if err != nil {
-		return false, errors.WithMessage(err, "get template context")
+		return HealthResult{}, errors.WithMessage(err, "get template context")
 	}
 	return checkHealth(templateContext, healthPolicyTemplate)
 }
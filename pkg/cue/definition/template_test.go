@@ -23,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
 	"github.com/oam-dev/kubevela/apis/types"
 	"github.com/oam-dev/kubevela/pkg/cue/packages"
 	"github.com/oam-dev/kubevela/pkg/cue/process"
@@ -1110,7 +1111,8 @@ func TestCheckHealth(t *testing.T) {
 	cases := map[string]struct {
 		tpContext  map[string]interface{}
 		healthTemp string
-		exp        bool
+		exp        HealthResult
+		hasErr     bool
 	}{
 		"normal-equal": {
 			tpContext: map[string]interface{}{
@@ -1122,7 +1124,7 @@ func TestCheckHealth(t *testing.T) {
 				},
 			},
 			healthTemp: "isHealth:  context.output.status.readyReplicas == context.output.status.replicas",
-			exp:        true,
+			exp:        HealthResult{Phase: common.HealthPhaseHealthy},
 		},
 		"normal-false": {
 			tpContext: map[string]interface{}{
@@ -1134,7 +1136,7 @@ func TestCheckHealth(t *testing.T) {
 				},
 			},
 			healthTemp: "isHealth: context.output.status.readyReplicas == context.output.status.replicas",
-			exp:        false,
+			exp:        HealthResult{Phase: common.HealthPhaseUnhealthy},
 		},
 		"array-case-equal": {
 			tpContext: map[string]interface{}{
@@ -1147,13 +1149,55 @@ func TestCheckHealth(t *testing.T) {
 				},
 			},
 			healthTemp: `isHealth: context.output.status.conditions[0].status == "True"`,
-			exp:        true,
+			exp:        HealthResult{Phase: common.HealthPhaseHealthy},
+		},
+		"progressing-with-reason": {
+			tpContext: map[string]interface{}{
+				"output": map[string]interface{}{
+					"status": map[string]interface{}{
+						"readyReplicas": 2,
+						"replicas":      3,
+					},
+				},
+			},
+			healthTemp: `
+ready: context.output.status.readyReplicas
+replicas: context.output.status.replicas
+health: {
+	if ready == replicas {
+		phase: "healthy"
+	}
+	if ready < replicas {
+		phase:  "progressing"
+		reason: "\(ready)/\(replicas) replicas ready"
+	}
+}
+isHealth: ready == replicas`,
+			exp: HealthResult{Phase: common.HealthPhaseProgressing, Reason: "2/3 replicas ready"},
+		},
+		"unhealthy-with-reason": {
+			tpContext: map[string]interface{}{
+				"output": map[string]interface{}{
+					"status": map[string]interface{}{"phase": "Failed"},
+				},
+			},
+			healthTemp: `health: {phase: "unhealthy", reason: "pod is \(context.output.status.phase)"}`,
+			exp:        HealthResult{Phase: common.HealthPhaseUnhealthy, Reason: "pod is Failed"},
+		},
+		"invalid-phase": {
+			tpContext:  map[string]interface{}{},
+			healthTemp: `health: phase: "ready"`,
+			hasErr:     true,
 		},
 	}
 	for message, ca := range cases {
-		healthy, err := checkHealth(ca.tpContext, ca.healthTemp)
+		result, err := checkHealth(ca.tpContext, ca.healthTemp)
+		if ca.hasErr {
+			assert.Error(t, err, message)
+			continue
+		}
 		assert.NoError(t, err, message)
-		assert.Equal(t, ca.exp, healthy, message)
+		assert.Equal(t, ca.exp, result, message)
 	}
 }
 
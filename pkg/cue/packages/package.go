@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -106,16 +107,47 @@ func (pd *PackageDiscover) ImportBuiltinPackagesFor(bi *build.Instance) {
 
 // ImportPackagesAndBuildInstance Combine import built-in packages and build cue template together to avoid data race
 func (pd *PackageDiscover) ImportPackagesAndBuildInstance(bi *build.Instance) (inst *cue.Instance, err error) {
+	var r cue.Runtime
+	return pd.ImportPackagesAndBuildInstanceWithRuntime(&r, bi)
+}
+
+// ImportPackagesAndBuildInstanceWithRuntime imports the built-in packages and builds the cue template in the runtime.
+// The built-in packages are shared by all the instances and they're resolved in place by the instances importing
+// them, so only such builds are serialized, the templates importing no built-in package are built concurrently.
+func (pd *PackageDiscover) ImportPackagesAndBuildInstanceWithRuntime(r *cue.Runtime, bi *build.Instance) (*cue.Instance, error) {
 	pd.ImportBuiltinPackagesFor(bi)
 
-	var r cue.Runtime
-	pd.mutex.Lock()
-	defer pd.mutex.Unlock()
+	if pd.importsBuiltinPackages(bi) {
+		pd.mutex.Lock()
+		defer pd.mutex.Unlock()
+	}
 	cueInst, err := r.Build(bi)
 	if err != nil {
 		return nil, err
 	}
-	return cueInst, err
+	return cueInst, nil
+}
+
+// importsBuiltinPackages checks if any file of the instance imports the built-in packages,
+// the standard packages of CUE, e.g., "strings", aren't shared so they needn't be serialized
+func (pd *PackageDiscover) importsBuiltinPackages(bi *build.Instance) bool {
+	pd.mutex.RLock()
+	defer pd.mutex.RUnlock()
+	for _, f := range bi.Files {
+		for _, spec := range f.Imports {
+			if spec.Path == nil {
+				continue
+			}
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				path = spec.Path.Value
+			}
+			if _, ok := pd.pkgKinds[path]; ok {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // ListPackageKinds list packages and their kinds
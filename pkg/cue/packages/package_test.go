@@ -476,6 +476,24 @@ func TestMount(t *testing.T) {
 	assert.Equal(t, mypd.velaBuiltinPackages[0], testPkg.Instance)
 }
 
+func TestImportsBuiltinPackages(t *testing.T) {
+	mypd := &PackageDiscover{pkgKinds: make(map[string][]VersionKind)}
+	mypd.mount(newPackage("kube/apps/v1"), []VersionKind{})
+
+	for _, c := range []struct {
+		template string
+		expected bool
+	}{
+		{template: `a: 1`, expected: false},
+		{template: "import \"strings\"\na: strings.ToLower(\"A\")", expected: false},
+		{template: "import apps \"kube/apps/v1\"\na: apps.#Deployment", expected: true},
+	} {
+		bi := build.NewContext().NewInstance("", nil)
+		assert.NilError(t, bi.AddFile("-", c.template))
+		assert.Equal(t, mypd.importsBuiltinPackages(bi), c.expected, c.template)
+	}
+}
+
 func TestGetDGVK(t *testing.T) {
 	srcTmpl := `
 {
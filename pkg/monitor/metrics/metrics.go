@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the Prometheus metrics of KubeVela, they're served by the metrics endpoint of the
+// controller manager.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "kubevela"
+
+// the values of the result label of TemplateCacheRequests
+const (
+	// CacheHit means the parsed template is got from the cache
+	CacheHit = "hit"
+	// CacheMiss means the template is parsed as it's not in the cache
+	CacheMiss = "miss"
+)
+
+var (
+	// TemplateRenderDuration is the duration of rendering a definition template with its parameter and context,
+	// the kind is workload or trait and the definition is the type of the workload or trait.
+	TemplateRenderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "template_render_duration_seconds",
+		Help:      "Duration of rendering a definition template.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, []string{"kind", "definition"})
+
+	// TemplateCacheRequests counts the lookups of the parsed templates by the result.
+	TemplateCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "template_cache_requests_total",
+		Help:      "Number of the lookups of the parsed definition templates by the result, hit or miss.",
+	}, []string{"result"})
+
+	// ApplicationRenderDuration is the duration of rendering all the components of an application in a reconcile.
+	ApplicationRenderDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "application_render_duration_seconds",
+		Help:      "Duration of rendering the components of an application.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16),
+	})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(TemplateRenderDuration, TemplateCacheRequests, ApplicationRenderDuration)
+}
+
+// ObserveSince observes the time elapsed since the start in seconds.
+func ObserveSince(o prometheus.Observer, start time.Time) {
+	o.Observe(time.Since(start).Seconds())
+}
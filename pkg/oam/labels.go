@@ -97,6 +97,10 @@ const (
 	// AnnotationWorkflowContext is used to pass in the workflow context marshalled in json format.
 	AnnotationWorkflowContext = "app.oam.dev/workflow-context"
 
+	// AnnotationWorkflowOperation requests the application controller to suspend, resume, terminate or restart the
+	// workflow of the application, it's removed by the controller once the operation is applied to the workflow status
+	AnnotationWorkflowOperation = "app.oam.dev/workflow-operation"
+
 	// AnnotationKubeVelaVersion is used to record current KubeVela version
 	AnnotationKubeVelaVersion = "oam.dev/kubevela-version"
 
@@ -105,4 +109,29 @@ const (
 
 	// AnnotationFilterLabelKeys is used to filter labels passed to workload and trait, split by comma
 	AnnotationFilterLabelKeys = "filter.oam.dev/label-keys"
+
+	// AnnotationGCStrategy sets the garbage collect strategy of a resource, it overrides the garbage-collect policy
+	AnnotationGCStrategy = "app.oam.dev/gc-strategy"
+
+	// AnnotationApplyMode sets the mode to apply a resource, client-side or server-side. It's set per component on
+	// the workload, e.g., by the annotations trait, and the traits of the component are applied in the same mode
+	// unless they set their own. It's set on the application to apply all resources of the application in the mode.
+	AnnotationApplyMode = "app.oam.dev/apply-mode"
+
+	// AnnotationAutoHeal enables reverting the drift of the resources of an application once it's detected
+	AnnotationAutoHeal = "app.oam.dev/auto-heal"
+
+	// AnnotationGCPending indicates that the resources of the resource tracker are not all garbage collected
+	AnnotationGCPending = "app.oam.dev/gc-pending"
+
+	// AnnotationRolloutApprovedBatch approves the rollout batches up to the index (included) to move on,
+	// it's cleared once the rollout finishes
+	AnnotationRolloutApprovedBatch = "app.oam.dev/rollout-approved-batch"
+
+	// AnnotationRolloutApprover records who approved the rollout batches
+	AnnotationRolloutApprover = "app.oam.dev/rollout-approver"
+
+	// AnnotationRolloutPaused pauses the rollout plan of an application, it doesn't change the application revision
+	// like setting the paused field of the rollout plan does
+	AnnotationRolloutPaused = "app.oam.dev/rollout-paused"
 )
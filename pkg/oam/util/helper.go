@@ -345,12 +345,37 @@ func GetDefinition(ctx context.Context, cli client.Reader, definition runtime.Ob
 // GetCapabilityDefinition can get different versions of ComponentDefinition/TraitDefinition
 func GetCapabilityDefinition(ctx context.Context, cli client.Reader, definition runtime.Object,
 	definitionName string) error {
+	_, err := GetCapabilityDefinitionWithRevision(ctx, cli, definition, definitionName)
+	return err
+}
+
+// GetCapabilityDefinitionWithRevision gets the definition like GetCapabilityDefinition, and returns the revision
+// the definition is resolved to. A definition name with a revision suffix, e.g., worker@v2, is resolved to the
+// DefinitionRevision, otherwise the definition is resolved to its latest revision which can be nil if the
+// revision is not generated yet.
+func GetCapabilityDefinitionWithRevision(ctx context.Context, cli client.Reader, definition runtime.Object,
+	definitionName string) (*common.Revision, error) {
 	isLatestRevision, defRev, err := fetchDefinitionRev(ctx, cli, definitionName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if isLatestRevision {
-		return GetDefinition(ctx, cli, definition, definitionName)
+		if err := GetDefinition(ctx, cli, definition, definitionName); err != nil {
+			return nil, err
+		}
+		var latest *common.Revision
+		switch def := definition.(type) {
+		case *v1beta1.ComponentDefinition:
+			latest = def.Status.LatestRevision
+		case *v1beta1.TraitDefinition:
+			latest = def.Status.LatestRevision
+		case *v1beta1.PolicyDefinition:
+			latest = def.Status.LatestRevision
+		case *v1beta1.WorkflowStepDefinition:
+			latest = def.Status.LatestRevision
+		default:
+		}
+		return latest.DeepCopy(), nil
 	}
 	switch def := definition.(type) {
 	case *v1beta1.ComponentDefinition:
@@ -363,7 +388,17 @@ func GetCapabilityDefinition(ctx context.Context, cli client.Reader, definition
 		*def = defRev.Spec.WorkflowStepDefinition
 	default:
 	}
-	return nil
+	return &common.Revision{
+		Name:         defRev.Name,
+		Revision:     defRev.Spec.Revision,
+		RevisionHash: defRev.Spec.RevisionHash,
+	}, nil
+}
+
+// IsDefinitionRevisionName checks if the definition name refers to a revision of the definition, e.g., worker@v2
+func IsDefinitionRevisionName(definitionName string) bool {
+	_, err := ConvertDefinitionRevName(definitionName)
+	return err == nil
 }
 
 func fetchDefinitionRev(ctx context.Context, cli client.Reader, definitionName string) (bool, *v1beta1.DefinitionRevision, error) {
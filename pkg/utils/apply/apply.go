@@ -108,11 +108,12 @@ func (a *APIApplicator) Apply(ctx context.Context, desired runtime.Object, ao ..
 		return err
 	}
 	loggingApply("patching object", desired)
+	// the object applied in server side has no last-applied-state, the patch records it without deleting any field
 	patch, err := a.patcher.patch(existing, desired)
 	if err != nil {
 		return errors.Wrap(err, "cannot calculate patch by computing a three way diff")
 	}
-	return errors.Wrapf(a.c.Patch(ctx, desired, patch), "cannot patch object")
+	return errors.Wrapf(a.c.Patch(ctx, desired, patch, client.FieldOwner(FieldManager)), "cannot patch object")
 }
 
 // createOrGetExisting will create the object if it does not exist
@@ -132,7 +133,7 @@ func createOrGetExisting(ctx context.Context, c client.Client, desired runtime.O
 			return nil, err
 		}
 		loggingApply("creating object", desired)
-		return nil, errors.Wrap(c.Create(ctx, desired), "cannot create object")
+		return nil, errors.Wrap(c.Create(ctx, desired, client.FieldOwner(FieldManager)), "cannot create object")
 	}
 
 	// allow to create object with only generateName
@@ -0,0 +1,254 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DetectDrift compares the live object with the desired one it's applied from, and returns the paths of the fields
+// whose live values differ from the desired ones, sorted. The last-applied-state recorded in the annotation is compared
+// instead if the desired object is nil, so it returns nil for the objects applied in server side then.
+// Only the fields set by the applicator are compared, so that the fields defaulted or added by others are not
+// regarded as drifted. The fields managed by other controllers, e.g., the replicas scaled by HPA, are not compared
+// either. The metadata except labels and annotations, and the status are ignored.
+func DetectDrift(live, desired runtime.Object) ([]string, error) {
+	var (
+		original []byte
+		err      error
+	)
+	if desired != nil {
+		original, err = getModifiedConfiguration(desired, false)
+	} else {
+		original, err = getOriginalConfiguration(live)
+	}
+	if err != nil || original == nil {
+		return nil, err
+	}
+	var applied map[string]interface{}
+	if err := json.Unmarshal(original, &applied); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal the applied state")
+	}
+	// normalize the live object into the same types as the applied one
+	raw, err := json.Marshal(live)
+	if err != nil {
+		return nil, err
+	}
+	var current map[string]interface{}
+	if err := json.Unmarshal(raw, &current); err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	for key, value := range applied {
+		switch key {
+		case "apiVersion", "kind", "status":
+			continue
+		case "metadata":
+			appliedMeta, _ := value.(map[string]interface{})
+			currentMeta, _ := current["metadata"].(map[string]interface{})
+			for _, metaKey := range []string{"labels", "annotations"} {
+				if appliedValue, ok := appliedMeta[metaKey]; ok {
+					fields = diffField("metadata."+metaKey, appliedValue, currentMeta[metaKey], fields)
+				}
+			}
+		default:
+			fields = diffField(key, value, current[key], fields)
+		}
+	}
+	coManaged, err := coManagedFields(live, current)
+	if err != nil {
+		return nil, err
+	}
+	drifted := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if !isCoManaged(coManaged, field) {
+			drifted = append(drifted, field)
+		}
+	}
+	if len(drifted) == 0 {
+		return nil, nil
+	}
+	sort.Strings(drifted)
+	return drifted, nil
+}
+
+// isCoManager checks whether the field manager manages the fields of the object together with the applicator,
+// the changes made by kubectl are manual changes rather than co-management, so they're still regarded as drift.
+func isCoManager(manager string) bool {
+	return manager != FieldManager && !strings.HasPrefix(manager, "kubectl")
+}
+
+// coManagedFields returns the paths of the fields managed by the co-managers of the live object,
+// in the same format as the paths of the drifted fields.
+func coManagedFields(live runtime.Object, current map[string]interface{}) (map[string]bool, error) {
+	accessor, err := meta.Accessor(live)
+	if err != nil {
+		return nil, err
+	}
+	managed := make(map[string]bool)
+	for _, entry := range accessor.GetManagedFields() {
+		if !isCoManager(entry.Manager) || entry.FieldsV1 == nil {
+			continue
+		}
+		var fieldSet map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fieldSet); err != nil {
+			return nil, errors.Wrapf(err, "cannot unmarshal the fields managed by %s", entry.Manager)
+		}
+		collectManagedFields("", fieldSet, current, managed)
+	}
+	return managed, nil
+}
+
+// collectManagedFields walks the field set of a managed-fields entry with the value it manages, the fields are
+// keyed by `f:<name>`, and the list items are keyed by `i:<index>`, `k:<key fields>` or `v:<value>`.
+func collectManagedFields(path string, fieldSet map[string]interface{}, current interface{}, managed map[string]bool) {
+	if len(fieldSet) == 0 {
+		managed[path] = true
+		return
+	}
+	for key, value := range fieldSet {
+		subSet, _ := value.(map[string]interface{})
+		switch {
+		case strings.HasPrefix(key, "f:"):
+			name := strings.TrimPrefix(key, "f:")
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			currentMap, _ := current.(map[string]interface{})
+			collectManagedFields(fieldPath, subSet, currentMap[name], managed)
+		case strings.HasPrefix(key, "i:"):
+			index, err := strconv.Atoi(strings.TrimPrefix(key, "i:"))
+			currentList, _ := current.([]interface{})
+			if err == nil && index < len(currentList) {
+				collectManagedFields(fmt.Sprintf("%s[%d]", path, index), subSet, currentList[index], managed)
+			}
+		case strings.HasPrefix(key, "k:"), strings.HasPrefix(key, "v:"):
+			var itemKey interface{}
+			if err := json.Unmarshal([]byte(key[2:]), &itemKey); err != nil {
+				continue
+			}
+			currentList, _ := current.([]interface{})
+			for i, item := range currentList {
+				if matchListItem(key[0] == 'k', itemKey, item) {
+					collectManagedFields(fmt.Sprintf("%s[%d]", path, i), subSet, item, managed)
+					break
+				}
+			}
+		}
+	}
+}
+
+// matchListItem checks whether the list item has the key fields, or equals the value for a list of scalars
+func matchListItem(byKey bool, itemKey, item interface{}) bool {
+	if !byKey {
+		return reflect.DeepEqual(itemKey, item)
+	}
+	keyFields, ok := itemKey.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	itemFields, ok := item.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for name, value := range keyFields {
+		if !reflect.DeepEqual(value, itemFields[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isCoManaged checks whether the field or any of its parents is managed by the co-managers
+func isCoManaged(managed map[string]bool, path string) bool {
+	for path != "" {
+		if managed[path] {
+			return true
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			return false
+		}
+		path = path[:i]
+	}
+	return false
+}
+
+// diffField appends the paths of the fields in the applied value which differ from the current value.
+func diffField(path string, applied, current interface{}, fields []string) []string {
+	switch appliedValue := applied.(type) {
+	case map[string]interface{}:
+		currentValue, ok := current.(map[string]interface{})
+		if !ok {
+			return append(fields, path)
+		}
+		for key, value := range appliedValue {
+			fields = diffField(path+"."+key, value, currentValue[key], fields)
+		}
+		return fields
+	case []interface{}:
+		currentValue, ok := current.([]interface{})
+		if !ok || len(currentValue) != len(appliedValue) {
+			return append(fields, path)
+		}
+		for i := range appliedValue {
+			fields = diffField(fmt.Sprintf("%s[%d]", path, i), appliedValue[i], currentValue[i], fields)
+		}
+		return fields
+	default:
+		if !equalValue(applied, current) {
+			return append(fields, path)
+		}
+		return fields
+	}
+}
+
+// equalValue compares two scalar values, quantities in different formats are equal, e.g., 0.5 and 500m.
+func equalValue(applied, current interface{}) bool {
+	if reflect.DeepEqual(applied, current) {
+		return true
+	}
+	appliedStr, ok := applied.(string)
+	if !ok {
+		return false
+	}
+	currentStr, ok := current.(string)
+	if !ok {
+		return false
+	}
+	appliedQuantity, err := resource.ParseQuantity(appliedStr)
+	if err != nil {
+		return false
+	}
+	currentQuantity, err := resource.ParseQuantity(currentStr)
+	if err != nil {
+		return false
+	}
+	return appliedQuantity.Cmp(currentQuantity) == 0
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apply
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDetectDrift(t *testing.T) {
+	applied := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":   "web",
+			"labels": map[string]interface{}{"app": "web"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":      "web",
+							"image":     "nginx:1.20",
+							"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "0.5"}},
+						},
+					},
+				},
+			},
+		},
+	}}
+	if err := addLastAppliedConfigAnnotation(applied); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		reason string
+		mutate func(live *unstructured.Unstructured)
+		want   []string
+	}{
+		"NoDrift": {
+			reason: "Defaulted and normalized fields should not be regarded as drifted",
+			mutate: func(live *unstructured.Unstructured) {
+				_ = unstructured.SetNestedField(live.Object, int64(600), "spec", "progressDeadlineSeconds")
+				_ = unstructured.SetNestedField(live.Object, "abc", "metadata", "uid")
+				containers, _, _ := unstructured.NestedSlice(live.Object, "spec", "template", "spec", "containers")
+				container := containers[0].(map[string]interface{})
+				container["imagePullPolicy"] = "IfNotPresent"
+				container["resources"] = map[string]interface{}{"limits": map[string]interface{}{"cpu": "500m"}}
+				_ = unstructured.SetNestedSlice(live.Object, containers, "spec", "template", "spec", "containers")
+			},
+		},
+		"Drifted": {
+			reason: "The fields changed outside of the applicator should be returned",
+			mutate: func(live *unstructured.Unstructured) {
+				_ = unstructured.SetNestedField(live.Object, int64(3), "spec", "replicas")
+				_ = unstructured.SetNestedField(live.Object, "debug", "metadata", "labels", "app")
+				containers, _, _ := unstructured.NestedSlice(live.Object, "spec", "template", "spec", "containers")
+				containers[0].(map[string]interface{})["image"] = "nginx:latest"
+				_ = unstructured.SetNestedSlice(live.Object, containers, "spec", "template", "spec", "containers")
+			},
+			want: []string{"metadata.labels.app", "spec.replicas", "spec.template.spec.containers[0].image"},
+		},
+		"CoManaged": {
+			reason: "The fields managed by other controllers should not be regarded as drifted, unless changed by kubectl",
+			mutate: func(live *unstructured.Unstructured) {
+				_ = unstructured.SetNestedField(live.Object, int64(3), "spec", "replicas")
+				containers, _, _ := unstructured.NestedSlice(live.Object, "spec", "template", "spec", "containers")
+				container := containers[0].(map[string]interface{})
+				container["image"] = "nginx:latest"
+				container["resources"] = map[string]interface{}{"limits": map[string]interface{}{"cpu": "1"}}
+				_ = unstructured.SetNestedSlice(live.Object, containers, "spec", "template", "spec", "containers")
+				live.SetManagedFields([]metav1.ManagedFieldsEntry{
+					{Manager: FieldManager, Operation: metav1.ManagedFieldsOperationUpdate,
+						FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:labels":{"f:app":{}}}}`)}},
+					{Manager: "kube-controller-manager", Operation: metav1.ManagedFieldsOperationUpdate,
+						FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)}},
+					{Manager: "resources-webhook", Operation: metav1.ManagedFieldsOperationUpdate,
+						FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"web\"}":{".":{},"f:resources":{}}}}}}}`)}},
+					{Manager: "kubectl-edit", Operation: metav1.ManagedFieldsOperationUpdate,
+						FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"web\"}":{"f:image":{}}}}}}}`)}},
+				})
+			},
+			want: []string{"spec.template.spec.containers[0].image"},
+		},
+	}
+	for caseName, tc := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			live := applied.DeepCopy()
+			tc.mutate(live)
+			got, err := DetectDrift(live, nil)
+			if err != nil {
+				t.Fatalf("DetectDrift(...): unexpected error %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nDetectDrift(...): -want , +got \n%s\n", tc.reason, diff)
+			}
+
+			// the objects applied in server side have no last-applied-state, they're compared with the desired ones
+			desired := applied.DeepCopy()
+			desired.SetAnnotations(nil)
+			live.SetAnnotations(nil)
+			got, err = DetectDrift(live, desired)
+			if err != nil {
+				t.Fatalf("DetectDrift(...): unexpected error %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nDetectDrift(...) with the desired object: -want , +got \n%s\n", tc.reason, diff)
+			}
+			if got, err := DetectDrift(live, nil); err != nil || got != nil {
+				t.Errorf("DetectDrift(...): want no drift without the last-applied-state, got %v, %v", got, err)
+			}
+		})
+	}
+}
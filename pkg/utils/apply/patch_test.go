@@ -17,6 +17,7 @@ limitations under the License.
 package apply
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -25,6 +26,8 @@ import (
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/oam-dev/kubevela/pkg/oam"
 )
 
 func TestAddLastAppliedConfig(t *testing.T) {
@@ -92,3 +95,38 @@ func TestGetOriginalConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestThreeWayMergePatchWithoutLastApplied(t *testing.T) {
+	// an object switched back from server-side apply has no last-applied-state
+	current := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web"},
+		"spec":       map[string]interface{}{"replicas": int64(1), "paused": true},
+	}}
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web"},
+		"spec":       map[string]interface{}{"replicas": int64(2)},
+	}}
+	patch, err := threeWayMergePatch(current, desired)
+	if err != nil {
+		t.Fatalf("threeWayMergePatch(...): unexpected error %v", err)
+	}
+	data, err := patch.Data(desired)
+	if err != nil {
+		t.Fatalf("Data(...): unexpected error %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal(...): unexpected error %v", err)
+	}
+	lastApplied, _, _ := unstructured.NestedString(got, "metadata", "annotations", oam.AnnotationLastAppliedConfig)
+	if lastApplied == "" {
+		t.Errorf("threeWayMergePatch(...): the last-applied-state should be recorded, got patch %s", data)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"replicas": float64(2)}, got["spec"]); diff != "" {
+		t.Errorf("threeWayMergePatch(...): no field should be deleted: -want , +got \n%s\n", diff)
+	}
+}
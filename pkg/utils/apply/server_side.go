@@ -0,0 +1,156 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+// Mode is the way to apply an object.
+type Mode string
+
+const (
+	// ModeClientSide applies an object by a three-way merge patch computed in client side like `kubectl apply`.
+	ModeClientSide Mode = "client-side"
+	// ModeServerSide applies an object by Kubernetes server-side apply.
+	ModeServerSide Mode = "server-side"
+)
+
+// FieldManager is the field manager of the fields applied by the applicators, in either mode.
+const FieldManager = "kubevela"
+
+var (
+	// DefaultMode is the mode to apply the objects without the apply-mode annotation, it's set by the controller flag.
+	DefaultMode = ModeClientSide
+	// ForceConflicts takes over the fields managed by other field managers when applying in server side,
+	// otherwise applying fails on conflicts. It's set by the controller flag.
+	ForceConflicts = false
+)
+
+// IsValidMode checks whether the apply mode is supported.
+func IsValidMode(mode Mode) bool {
+	return mode == ModeClientSide || mode == ModeServerSide
+}
+
+// NewApplicator creates an Applicator which applies an object in the mode set by its apply-mode annotation,
+// or in the default mode if the annotation is not set.
+func NewApplicator(c client.Client) Applicator {
+	return &modeApplicator{
+		clientSide: NewAPIApplicator(c),
+		serverSide: NewServerSideApplicator(c, ForceConflicts),
+	}
+}
+
+type modeApplicator struct {
+	clientSide Applicator
+	serverSide Applicator
+}
+
+// Apply applies the object by the Applicator of its mode
+func (a *modeApplicator) Apply(ctx context.Context, desired runtime.Object, ao ...ApplyOption) error {
+	mode, err := modeOf(desired)
+	if err != nil {
+		return err
+	}
+	if mode == ModeServerSide {
+		return a.serverSide.Apply(ctx, desired, ao...)
+	}
+	return a.clientSide.Apply(ctx, desired, ao...)
+}
+
+func modeOf(obj runtime.Object) (Mode, error) {
+	annots, _ := metadataAccessor.Annotations(obj)
+	mode, ok := annots[oam.AnnotationApplyMode]
+	if !ok {
+		return DefaultMode, nil
+	}
+	if !IsValidMode(Mode(mode)) {
+		return "", errors.Errorf("unknown apply mode %q in annotation %s", mode, oam.AnnotationApplyMode)
+	}
+	return Mode(mode), nil
+}
+
+// NewServerSideApplicator creates an Applicator which applies an object by server-side apply with the kubevela
+// field manager. The fields managed by others are taken over if forceConflicts is true, otherwise applying fails
+// on conflicts.
+func NewServerSideApplicator(c client.Client, forceConflicts bool) *ServerSideApplicator {
+	return &ServerSideApplicator{c: c, forceConflicts: forceConflicts}
+}
+
+// ServerSideApplicator implements Applicator by server-side apply
+type ServerSideApplicator struct {
+	c              client.Client
+	forceConflicts bool
+}
+
+// Apply applies the object by server-side apply, the object is created if not exist
+func (a *ServerSideApplicator) Apply(ctx context.Context, desired runtime.Object, ao ...ApplyOption) error {
+	m, ok := desired.(oam.Object)
+	if !ok {
+		return errors.New("cannot access object metadata")
+	}
+	existing := &unstructured.Unstructured{}
+	existing.GetObjectKind().SetGroupVersionKind(desired.GetObjectKind().GroupVersionKind())
+	err := a.c.Get(ctx, types.NamespacedName{Name: m.GetName(), Namespace: m.GetNamespace()}, existing)
+	switch {
+	case kerrors.IsNotFound(err):
+		err = executeApplyOptions(ctx, nil, desired, ao)
+	case err != nil:
+		return errors.Wrap(err, "cannot get object")
+	default:
+		err = executeApplyOptions(ctx, existing, desired, ao)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the last-applied-state is not used by server-side apply, remove it from the objects applied in client side,
+	// it's recorded again by the first client-side apply if the object is switched back
+	if _, ok := existing.GetAnnotations()[oam.AnnotationLastAppliedConfig]; ok && existing.GetResourceVersion() != "" {
+		patch := client.RawPatch(types.MergePatchType,
+			[]byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:null}}}`, oam.AnnotationLastAppliedConfig)))
+		if err := a.c.Patch(ctx, existing, patch); err != nil {
+			return errors.Wrap(err, "cannot remove last-applied-state")
+		}
+	}
+	if annots := m.GetAnnotations(); annots != nil {
+		if _, ok := annots[oam.AnnotationLastAppliedConfig]; ok {
+			delete(annots, oam.AnnotationLastAppliedConfig)
+			m.SetAnnotations(annots)
+		}
+	}
+	// server-side apply rejects the objects with managed fields, and the resource version is not a precondition
+	m.SetManagedFields(nil)
+	m.SetResourceVersion("")
+
+	opts := []client.PatchOption{client.FieldOwner(FieldManager)}
+	if a.forceConflicts {
+		opts = append(opts, client.ForceOwnership)
+	}
+	loggingApply("applying object in server side", desired)
+	return errors.Wrap(a.c.Patch(ctx, desired, client.Apply, opts...), "cannot apply object in server side")
+}
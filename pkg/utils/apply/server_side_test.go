@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apply
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+type modeRecorder struct {
+	mode  Mode
+	calls *[]Mode
+}
+
+func (r modeRecorder) Apply(context.Context, runtime.Object, ...ApplyOption) error {
+	*r.calls = append(*r.calls, r.mode)
+	return nil
+}
+
+func TestModeApplicator(t *testing.T) {
+	var calls []Mode
+	a := &modeApplicator{
+		clientSide: modeRecorder{mode: ModeClientSide, calls: &calls},
+		serverSide: modeRecorder{mode: ModeServerSide, calls: &calls},
+	}
+	newObj := func(mode string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		if mode != "" {
+			obj.SetAnnotations(map[string]string{oam.AnnotationApplyMode: mode})
+		}
+		return obj
+	}
+	for _, mode := range []string{"", "server-side", "client-side"} {
+		if err := a.Apply(ctx, newObj(mode)); err != nil {
+			t.Fatalf("Apply(...): unexpected error %v", err)
+		}
+	}
+	if diff := cmp.Diff([]Mode{ModeClientSide, ModeServerSide, ModeClientSide}, calls); diff != "" {
+		t.Errorf("Apply(...): -want , +got \n%s\n", diff)
+	}
+	if err := a.Apply(ctx, newObj("unknown")); err == nil {
+		t.Errorf("Apply(...): expect an error for unknown apply mode")
+	}
+}
+
+func TestServerSideApplicator(t *testing.T) {
+	desired := &unstructured.Unstructured{}
+	desired.SetGroupVersionKind(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+	desired.SetName("web")
+	desired.SetResourceVersion("1")
+	desired.SetAnnotations(map[string]string{oam.AnnotationLastAppliedConfig: "{}"})
+
+	var patchTypes []types.PatchType
+	var gotOpts client.PatchOptions
+	var applied runtime.Object
+	c := &test.MockClient{
+		MockGet: func(_ context.Context, key client.ObjectKey, obj runtime.Object) error {
+			u := obj.(*unstructured.Unstructured)
+			u.SetName(key.Name)
+			u.SetResourceVersion("1")
+			u.SetAnnotations(map[string]string{oam.AnnotationLastAppliedConfig: "{}"})
+			return nil
+		},
+		MockPatch: func(_ context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+			applied = obj
+			patchTypes = append(patchTypes, patch.Type())
+			gotOpts.ApplyOptions(opts)
+			return nil
+		},
+	}
+	if err := NewServerSideApplicator(c, true).Apply(ctx, desired.DeepCopy()); err != nil {
+		t.Fatalf("Apply(...): unexpected error %v", err)
+	}
+	if diff := cmp.Diff([]types.PatchType{types.MergePatchType, types.ApplyPatchType}, patchTypes); diff != "" {
+		t.Errorf("Apply(...): the last-applied-state should be removed before applying: -want , +got \n%s\n", diff)
+	}
+	if _, ok := applied.(*unstructured.Unstructured).GetAnnotations()[oam.AnnotationLastAppliedConfig]; ok {
+		t.Errorf("Apply(...): the last-applied-state should not be applied in server side")
+	}
+	if gotOpts.FieldManager != FieldManager || gotOpts.Force == nil || !*gotOpts.Force {
+		t.Errorf("Apply(...): want field manager %s with force, got %+v", FieldManager, gotOpts)
+	}
+
+	c.MockGet = test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "web"))
+	patchTypes, gotOpts = nil, client.PatchOptions{}
+	if err := NewServerSideApplicator(c, false).Apply(ctx, desired.DeepCopy()); err != nil {
+		t.Fatalf("Apply(...): unexpected error %v", err)
+	}
+	if diff := cmp.Diff([]types.PatchType{types.ApplyPatchType}, patchTypes); diff != "" {
+		t.Errorf("Apply(...): -want , +got \n%s\n", diff)
+	}
+	if gotOpts.Force != nil {
+		t.Errorf("Apply(...): conflicts should not be forced")
+	}
+}
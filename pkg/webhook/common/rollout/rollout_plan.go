@@ -26,6 +26,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/pkg/controller/common/rollout/metrics"
 )
 
 // DefaultRolloutBatches set the default values for a rollout batches
@@ -96,6 +97,16 @@ func ValidateCreate(client client.Client, rollout *v1alpha1.RolloutPlan, rootPat
 	// validate the rollout batches
 	allErrs = append(allErrs, validateRolloutBatches(rollout, rootPath)...)
 
+	// validate the canary metrics
+	allErrs = append(allErrs, validateCanaryMetrics(rollout.CanaryMetric, rootPath.Child("canaryMetric"))...)
+	for i, rb := range rollout.RolloutBatches {
+		allErrs = append(allErrs, validateCanaryMetrics(rb.CanaryMetric,
+			rootPath.Child("rolloutBatches").Index(i).Child("canaryMetric"))...)
+	}
+
+	// validate the traffic routing
+	allErrs = append(allErrs, validateTrafficRouting(rollout, rootPath)...)
+
 	// TODO: The total number of num in the batches match the current target resource pod size
 	return allErrs
 }
@@ -153,6 +164,65 @@ func validateRolloutBatches(rollout *v1alpha1.RolloutPlan, rootPath *field.Path)
 	return allErrs
 }
 
+func validateCanaryMetrics(canaryMetrics []v1alpha1.CanaryMetric, rootPath *field.Path) (allErrs field.ErrorList) {
+	for i, metric := range canaryMetrics {
+		metricPath := rootPath.Index(i)
+		if len(metric.Name) == 0 {
+			allErrs = append(allErrs, field.Required(metricPath.Child("name"), "the canary metric has to have a name"))
+		}
+		if _, _, err := metrics.ParseRange(metric.MetricsRange); err != nil {
+			allErrs = append(allErrs, field.Invalid(metricPath.Child("metricsRange"), metric.MetricsRange, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+func validateTrafficRouting(rollout *v1alpha1.RolloutPlan, rootPath *field.Path) (allErrs field.ErrorList) {
+	batchesPath := rootPath.Child("rolloutBatches")
+	if rollout.TrafficRouting == nil {
+		for i, rb := range rollout.RolloutBatches {
+			if rb.TrafficWeight != nil {
+				allErrs = append(allErrs, field.Forbidden(batchesPath.Index(i).Child("trafficWeight"),
+					"the traffic weight only works with the traffic routing"))
+			}
+		}
+		return allErrs
+	}
+	routing := rollout.TrafficRouting
+	routingPath := rootPath.Child("trafficRouting")
+	if routing.Provider != v1alpha1.IstioTrafficProvider && routing.Provider != v1alpha1.SMITrafficProvider &&
+		routing.Provider != v1alpha1.GatewayAPITrafficProvider {
+		allErrs = append(allErrs, field.NotSupported(routingPath.Child("provider"), routing.Provider,
+			[]string{string(v1alpha1.IstioTrafficProvider), string(v1alpha1.SMITrafficProvider),
+				string(v1alpha1.GatewayAPITrafficProvider)}))
+	}
+	if len(routing.Service) == 0 {
+		allErrs = append(allErrs, field.Required(routingPath.Child("service"), "the traffic routing has to have a service"))
+	}
+	if routing.Port <= 0 {
+		allErrs = append(allErrs, field.Invalid(routingPath.Child("port"), routing.Port, "the port has to be positive"))
+	}
+	// the traffic weight can only increase batch by batch
+	var prevWeight int32
+	for i, rb := range rollout.RolloutBatches {
+		if rb.TrafficWeight == nil {
+			continue
+		}
+		weight := *rb.TrafficWeight
+		weightPath := batchesPath.Index(i).Child("trafficWeight")
+		switch {
+		case weight < 0 || weight > 100:
+			allErrs = append(allErrs, field.Invalid(weightPath, weight, "the traffic weight has to be between 0 and 100"))
+		case weight < prevWeight:
+			allErrs = append(allErrs, field.Invalid(weightPath, weight,
+				"the traffic weight can not be less than the one of the previous batch"))
+		default:
+			prevWeight = weight
+		}
+	}
+	return allErrs
+}
+
 // ValidateUpdate validate if one can change the rollout plan from the previous psec
 func ValidateUpdate(client client.Client, new *v1alpha1.RolloutPlan, prev *v1alpha1.RolloutPlan,
 	rootPath *field.Path) field.ErrorList {
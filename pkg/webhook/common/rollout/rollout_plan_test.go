@@ -155,3 +155,67 @@ func TestValidateIllegalReplicas(t *testing.T) {
 		t.Error("should invalidate negative replica value")
 	}
 }
+
+func TestValidateCanaryMetrics(t *testing.T) {
+	min, max := intstr.FromString("0.99"), intstr.FromString("0.9")
+	canaryMetrics := []v1alpha1.CanaryMetric{
+		{
+			Name:         "success-rate",
+			MetricsRange: &v1alpha1.MetricsExpectedRange{Min: &min},
+		},
+	}
+	if errList := validateCanaryMetrics(canaryMetrics, field.NewPath("spec")); len(errList) != 0 {
+		t.Errorf("should validate canary metrics, got %v", errList)
+	}
+	// min is greater than max
+	canaryMetrics[0].MetricsRange.Max = &max
+	if errList := validateCanaryMetrics(canaryMetrics, field.NewPath("spec")); len(errList) != 1 {
+		t.Error("should invalidate illegal metrics range")
+	}
+	// not a number
+	invalid := intstr.FromString("high")
+	canaryMetrics[0].MetricsRange = &v1alpha1.MetricsExpectedRange{Max: &invalid}
+	if errList := validateCanaryMetrics(canaryMetrics, field.NewPath("spec")); len(errList) != 1 {
+		t.Error("should invalidate illegal metrics range")
+	}
+}
+
+func TestValidateTrafficRouting(t *testing.T) {
+	weight := func(w int32) *int32 { return &w }
+	plan := &v1alpha1.RolloutPlan{
+		TrafficRouting: &v1alpha1.TrafficRouting{
+			Provider: v1alpha1.IstioTrafficProvider,
+			Service:  "web",
+			Port:     80,
+		},
+		RolloutBatches: []v1alpha1.RolloutBatch{
+			{TrafficWeight: weight(20)},
+			{},
+			{TrafficWeight: weight(100)},
+		},
+	}
+	if errList := validateTrafficRouting(plan, field.NewPath("spec")); len(errList) != 0 {
+		t.Errorf("should validate traffic routing, got %v", errList)
+	}
+	// decreasing weight
+	plan.RolloutBatches[1].TrafficWeight = weight(10)
+	if errList := validateTrafficRouting(plan, field.NewPath("spec")); len(errList) != 1 {
+		t.Error("should invalidate decreasing traffic weight")
+	}
+	// out of range
+	plan.RolloutBatches[1].TrafficWeight = weight(120)
+	if errList := validateTrafficRouting(plan, field.NewPath("spec")); len(errList) != 1 {
+		t.Error("should invalidate traffic weight out of range")
+	}
+	// unknown provider without service and port
+	plan.RolloutBatches[1].TrafficWeight = nil
+	plan.TrafficRouting = &v1alpha1.TrafficRouting{Provider: "linkerd"}
+	if errList := validateTrafficRouting(plan, field.NewPath("spec")); len(errList) != 3 {
+		t.Errorf("should invalidate provider, service and port, got %v", errList)
+	}
+	// weight without routing
+	plan.TrafficRouting = nil
+	if errList := validateTrafficRouting(plan, field.NewPath("spec")); len(errList) != 2 {
+		t.Errorf("should forbid traffic weight without traffic routing, got %v", errList)
+	}
+}
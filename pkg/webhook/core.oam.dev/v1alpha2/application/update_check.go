@@ -0,0 +1,203 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/appfile"
+	"github.com/oam-dev/kubevela/pkg/controller/utils"
+	"github.com/oam-dev/kubevela/pkg/oam"
+	oamutil "github.com/oam-dev/kubevela/pkg/oam/util"
+)
+
+// updateChecker checks the breaking changes in an update of an application, a failed check either denies the update
+// or is reported as a warning according to the update-check policy of the application before the update, so that
+// an update can't relax the policy to let its own breaking changes through.
+type updateChecker struct {
+	policy   types.UpdateCheckPolicySpec
+	errs     field.ErrorList
+	warnings []string
+}
+
+func newUpdateChecker(app *v1beta1.Application) *updateChecker {
+	c := &updateChecker{policy: types.UpdateCheckPolicySpec{
+		ComponentTypeChange:     types.UpdateCheckActionDeny,
+		ComponentRename:         types.UpdateCheckActionWarn,
+		RolloutComponentRemoval: types.UpdateCheckActionDeny,
+	}}
+	// an invalid policy is reported by ValidateCreate, the defaults are used here
+	if policy, err := appfile.ParseUpdateCheckPolicy(app.Spec.Policies); err == nil && policy != nil {
+		if policy.ComponentTypeChange != "" {
+			c.policy.ComponentTypeChange = policy.ComponentTypeChange
+		}
+		if policy.ComponentRename != "" {
+			c.policy.ComponentRename = policy.ComponentRename
+		}
+		if policy.RolloutComponentRemoval != "" {
+			c.policy.RolloutComponentRemoval = policy.RolloutComponentRemoval
+		}
+	}
+	return c
+}
+
+func (c *updateChecker) report(action types.UpdateCheckAction, fldPath *field.Path, msg string) {
+	if action == types.UpdateCheckActionDeny {
+		c.errs = append(c.errs, field.Forbidden(fldPath, msg))
+		return
+	}
+	c.warnings = append(c.warnings, fmt.Sprintf("%s: %s", fldPath, msg))
+}
+
+// checkUpdate checks the components of the new application against the old one:
+// the type of a rolling out component must not change, a component rolled out by an AppRollout must not be removed,
+// and renaming a component owning PersistentVolumeClaims leaves the claims behind. The policy of the old application
+// is applied.
+func (h *ValidatingHandler) checkUpdate(ctx context.Context, newApp, oldApp *v1beta1.Application) (field.ErrorList, []string) {
+	c := newUpdateChecker(oldApp)
+	fldPath := field.NewPath("spec", "components")
+	rolling, err := h.getRollingComponents(ctx, oldApp)
+	if err != nil {
+		return field.ErrorList{field.InternalError(fldPath, err)}, nil
+	}
+
+	oldComps := make(map[string]v1beta1.ApplicationComponent, len(oldApp.Spec.Components))
+	for _, comp := range oldApp.Spec.Components {
+		oldComps[comp.Name] = comp
+	}
+	newComps := make(map[string]bool, len(newApp.Spec.Components))
+	var added []v1beta1.ApplicationComponent
+	for i, comp := range newApp.Spec.Components {
+		newComps[comp.Name] = true
+		oldComp, ok := oldComps[comp.Name]
+		if !ok {
+			added = append(added, comp)
+			continue
+		}
+		if rollout, ok := rolling[comp.Name]; ok && oldComp.Type != comp.Type {
+			c.report(c.policy.ComponentTypeChange, fldPath.Index(i).Child("type"),
+				fmt.Sprintf("the type of component %q cannot be changed from %q to %q while %s rolls it out",
+					comp.Name, oldComp.Type, comp.Type, rollout))
+		}
+	}
+
+	for _, comp := range oldApp.Spec.Components {
+		if newComps[comp.Name] {
+			continue
+		}
+		if rollout, ok := rolling[comp.Name]; ok {
+			c.report(c.policy.RolloutComponentRemoval, fldPath,
+				fmt.Sprintf("component %q cannot be removed while %s rolls it out", comp.Name, rollout))
+		}
+		renamed := renamedTo(comp, added)
+		if renamed == "" {
+			continue
+		}
+		claims, err := h.getComponentClaims(ctx, oldApp, comp.Name)
+		if err != nil {
+			return field.ErrorList{field.InternalError(fldPath, err)}, nil
+		}
+		if len(claims) != 0 {
+			c.report(c.policy.ComponentRename, fldPath,
+				fmt.Sprintf("component %q owning PersistentVolumeClaims %s is removed while component %q of the same type %q "+
+					"is added, it's regarded as renamed to %q and the claims are not carried over",
+					comp.Name, strings.Join(claims, ","), renamed, comp.Type, renamed))
+		}
+	}
+	return c.errs, c.warnings
+}
+
+// renamedTo returns the name of the added component the removed component is renamed to. It's a heuristic, since
+// the application doesn't record renames: a removed component is regarded as renamed if a component of the same
+// type is added in the same update.
+func renamedTo(removed v1beta1.ApplicationComponent, added []v1beta1.ApplicationComponent) string {
+	for _, comp := range added {
+		if comp.Type == removed.Type {
+			return comp.Name
+		}
+	}
+	return ""
+}
+
+// getRollingComponents returns the components of the application which are rolling out, mapped to the rollouts.
+// An AppRollout without componentList rolls out any component of the application.
+func (h *ValidatingHandler) getRollingComponents(ctx context.Context, app *v1beta1.Application) (map[string]string, error) {
+	rolling := make(map[string]string)
+	// the rollout plan of the application only rolls out the first component
+	if app.Spec.RolloutPlan != nil && app.Status.Rollout.RollingState != "" && isRolloutLive(app.Status.Rollout.RollingState) &&
+		len(app.Spec.Components) != 0 {
+		rolling[app.Spec.Components[0].Name] = fmt.Sprintf("the rollout plan of application %q", app.Name)
+	}
+	rollouts := &v1beta1.AppRolloutList{}
+	if err := h.Client.List(ctx, rollouts, client.InNamespace(app.Namespace)); err != nil {
+		return nil, err
+	}
+	for _, rollout := range rollouts.Items {
+		if !isRolloutLive(rollout.Status.RollingState) || !isRevisionOf(rollout.Spec.TargetAppRevisionName, app.Name) {
+			continue
+		}
+		comps := rollout.Spec.ComponentList
+		if len(comps) == 0 {
+			for _, comp := range app.Spec.Components {
+				comps = append(comps, comp.Name)
+			}
+		}
+		for _, comp := range comps {
+			rolling[comp] = fmt.Sprintf("AppRollout %q", rollout.Name)
+		}
+	}
+	return rolling, nil
+}
+
+func isRolloutLive(state v1alpha1.RollingState) bool {
+	switch state {
+	case v1alpha1.RolloutSucceedState, v1alpha1.RolloutFailedState, v1alpha1.RolloutRolledBackState:
+		return false
+	default:
+		return true
+	}
+}
+
+func isRevisionOf(revisionName, appName string) bool {
+	revision, err := oamutil.ExtractRevisionNum(revisionName, "-")
+	return err == nil && revisionName == utils.ConstructRevisionName(appName, int64(revision))
+}
+
+// getComponentClaims returns the names of the PersistentVolumeClaims labeled with the component of the application
+func (h *ValidatingHandler) getComponentClaims(ctx context.Context, app *v1beta1.Application, compName string) ([]string, error) {
+	claims := &corev1.PersistentVolumeClaimList{}
+	if err := h.Client.List(ctx, claims, client.InNamespace(app.Namespace),
+		client.MatchingLabels{oam.LabelAppName: app.Name, oam.LabelAppComponent: compName}); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(claims.Items))
+	for _, claim := range claims.Items {
+		names = append(names, claim.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
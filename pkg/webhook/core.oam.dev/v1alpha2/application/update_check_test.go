@@ -0,0 +1,152 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+func TestCheckUpdate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1beta1.SchemeBuilder.AddToScheme(scheme))
+
+	newApp := func(policy string, comps ...v1beta1.ApplicationComponent) *v1beta1.Application {
+		app := &v1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Spec:       v1beta1.ApplicationSpec{Components: comps},
+		}
+		if policy != "" {
+			app.Spec.Policies = []v1beta1.AppPolicy{{Name: "check", Type: types.PolicyTypeUpdateCheck,
+				Properties: runtime.RawExtension{Raw: []byte(policy)}}}
+		}
+		return app
+	}
+	comp := func(name, typ string) v1beta1.ApplicationComponent {
+		return v1beta1.ApplicationComponent{Name: name, Type: typ}
+	}
+	rollout := func(state v1alpha1.RollingState, comps ...string) *v1beta1.AppRollout {
+		r := &v1beta1.AppRollout{
+			ObjectMeta: metav1.ObjectMeta{Name: "rollout", Namespace: "default"},
+			Spec:       v1beta1.AppRolloutSpec{TargetAppRevisionName: "app-v2", ComponentList: comps},
+		}
+		r.Status.RollingState = state
+		return r
+	}
+	claim := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default",
+		Labels: map[string]string{oam.LabelAppName: "app", oam.LabelAppComponent: "db"}}}
+
+	testCases := map[string]struct {
+		objs         []runtime.Object
+		oldApp       *v1beta1.Application
+		newApp       *v1beta1.Application
+		wantErr      string
+		wantWarnings int
+	}{
+		"change the type of a rolling component": {
+			objs:    []runtime.Object{rollout(v1alpha1.RollingInBatchesState, "web")},
+			oldApp:  newApp("", comp("web", "webservice")),
+			newApp:  newApp("", comp("web", "worker")),
+			wantErr: `the type of component "web" cannot be changed from "webservice" to "worker" while AppRollout "rollout" rolls it out`,
+		},
+		"warn the type change of a rolling component": {
+			objs:         []runtime.Object{rollout(v1alpha1.RollingInBatchesState)},
+			oldApp:       newApp(`{"componentTypeChange":"warn"}`, comp("web", "webservice")),
+			newApp:       newApp(`{"componentTypeChange":"warn"}`, comp("web", "worker")),
+			wantWarnings: 1,
+		},
+		"relax the policy in the same update": {
+			objs:    []runtime.Object{rollout(v1alpha1.RollingInBatchesState)},
+			oldApp:  newApp("", comp("web", "webservice")),
+			newApp:  newApp(`{"componentTypeChange":"warn"}`, comp("web", "worker")),
+			wantErr: `the type of component "web" cannot be changed`,
+		},
+		"change the type of a component after the rollout succeeded": {
+			objs:   []runtime.Object{rollout(v1alpha1.RolloutSucceedState, "web")},
+			oldApp: newApp("", comp("web", "webservice")),
+			newApp: newApp("", comp("web", "worker")),
+		},
+		"change the type of a component rolled out by the rollout plan": {
+			oldApp: func() *v1beta1.Application {
+				app := newApp("", comp("web", "webservice"))
+				app.Spec.RolloutPlan = &v1alpha1.RolloutPlan{}
+				app.Status.Rollout.RollingState = v1alpha1.RollingInBatchesState
+				return app
+			}(),
+			newApp:  newApp("", comp("web", "worker")),
+			wantErr: `while the rollout plan of application "app" rolls it out`,
+		},
+		"remove a rolling component": {
+			objs:    []runtime.Object{rollout(v1alpha1.RollingInBatchesState, "web")},
+			oldApp:  newApp("", comp("web", "webservice"), comp("db", "worker")),
+			newApp:  newApp("", comp("db", "worker")),
+			wantErr: `component "web" cannot be removed while AppRollout "rollout" rolls it out`,
+		},
+		"remove a component of another application's rollout": {
+			objs: []runtime.Object{func() *v1beta1.AppRollout {
+				r := rollout(v1alpha1.RollingInBatchesState, "web")
+				r.Spec.TargetAppRevisionName = "app-other-v2"
+				return r
+			}()},
+			oldApp: newApp("", comp("web", "webservice"), comp("db", "worker")),
+			newApp: newApp("", comp("db", "worker")),
+		},
+		"rename a component owning claims": {
+			objs:         []runtime.Object{claim.DeepCopy()},
+			oldApp:       newApp("", comp("db", "worker")),
+			newApp:       newApp("", comp("database", "worker")),
+			wantWarnings: 1,
+		},
+		"deny renaming a component owning claims": {
+			objs:    []runtime.Object{claim.DeepCopy()},
+			oldApp:  newApp(`{"componentRename":"deny"}`, comp("db", "worker")),
+			newApp:  newApp(`{"componentRename":"deny"}`, comp("database", "worker")),
+			wantErr: `component "db" owning PersistentVolumeClaims data is removed while component "database" of the same type "worker" is added`,
+		},
+		"rename a component without claims": {
+			oldApp: newApp("", comp("db", "worker")),
+			newApp: newApp("", comp("database", "worker")),
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			h := &ValidatingHandler{Client: fake.NewFakeClientWithScheme(scheme, tc.objs...)}
+			errs, warnings := h.checkUpdate(context.Background(), tc.newApp, tc.oldApp)
+			if tc.wantErr == "" {
+				assert.Empty(t, errs)
+			} else {
+				require.Len(t, errs, 1)
+				assert.Contains(t, errs[0].Error(), tc.wantErr)
+			}
+			assert.Len(t, warnings, tc.wantWarnings)
+		})
+	}
+}
@@ -19,8 +19,10 @@ package application
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
@@ -34,6 +36,9 @@ import (
 	"github.com/oam-dev/kubevela/pkg/oam/util"
 )
 
+// auditAnnotationUpdateWarning is the audit annotation which records the warnings of an update
+const auditAnnotationUpdateWarning = "update-warning"
+
 var _ admission.Handler = &ValidatingHandler{}
 
 // ValidatingHandler handles application
@@ -85,9 +90,13 @@ func (h *ValidatingHandler) Handle(ctx context.Context, req admission.Request) a
 			return admission.Errored(http.StatusBadRequest, err)
 		}
 		if app.ObjectMeta.DeletionTimestamp.IsZero() {
-			if allErrs := h.ValidateUpdate(ctx, app, oldApp); len(allErrs) > 0 {
+			allErrs, warnings := h.ValidateUpdate(ctx, app, oldApp)
+			if len(allErrs) > 0 {
 				return admission.Errored(http.StatusUnprocessableEntity, allErrs.ToAggregate())
 			}
+			if len(warnings) > 0 {
+				return warningResponse(app, warnings)
+			}
 		}
 	default:
 		// Do nothing for DELETE and CONNECT
@@ -95,6 +104,16 @@ func (h *ValidatingHandler) Handle(ctx context.Context, req admission.Request) a
 	return admission.ValidationResponse(true, "")
 }
 
+// warningResponse admits the request with the warnings. The admission API in use has no warnings field,
+// so the warnings are logged, and recorded in the result message and the audit annotations.
+func warningResponse(app *v1beta1.Application, warnings []string) admission.Response {
+	msg := strings.Join(warnings, "; ")
+	klog.InfoS("Admit application update with warnings", "application", klog.KObj(app), "warnings", msg)
+	resp := admission.Allowed(msg)
+	resp.AuditAnnotations = map[string]string{auditAnnotationUpdateWarning: msg}
+	return resp
+}
+
 // RegisterValidatingHandler will register application validate handler to the webhook
 func RegisterValidatingHandler(mgr manager.Manager, args controller.Args) {
 	server := mgr.GetWebhookServer()
@@ -46,6 +46,9 @@ func (h *ValidatingHandler) ValidateCreate(ctx context.Context, app *v1beta1.App
 	if err := appParser.ValidateCUESchematicAppfile(af); err != nil {
 		componentErrs = append(componentErrs, field.Invalid(field.NewPath("schematic"), app, err.Error()))
 	}
+	if err := appParser.ValidateTraits(af); err != nil {
+		componentErrs = append(componentErrs, field.Invalid(field.NewPath("spec", "components"), app, err.Error()))
+	}
 	if v := app.GetAnnotations()[oam.AnnotationAppRollout]; len(v) != 0 && v != "true" {
 		componentErrs = append(componentErrs, field.Invalid(field.NewPath("annotation:app.oam.dev/rollout-template"), app, "the annotation value of rollout-template must be true"))
 	}
@@ -55,10 +58,11 @@ func (h *ValidatingHandler) ValidateCreate(ctx context.Context, app *v1beta1.App
 	return componentErrs
 }
 
-// ValidateUpdate validates the Application on update
-func (h *ValidatingHandler) ValidateUpdate(ctx context.Context, newApp, oldApp *v1beta1.Application) field.ErrorList {
+// ValidateUpdate validates the Application on update, the breaking changes which are allowed by the update-check
+// policy are returned as warnings
+func (h *ValidatingHandler) ValidateUpdate(ctx context.Context, newApp, oldApp *v1beta1.Application) (field.ErrorList, []string) {
 	// check if the newApp is valid
 	componentErrs := h.ValidateCreate(ctx, newApp)
-	// TODO: add more validating
-	return componentErrs
+	updateErrs, warnings := h.checkUpdate(ctx, newApp, oldApp)
+	return append(componentErrs, updateErrs...), warnings
 }
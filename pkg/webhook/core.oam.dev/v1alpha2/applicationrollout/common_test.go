@@ -22,7 +22,10 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
 	"github.com/oam-dev/kubevela/apis/types"
 	"github.com/oam-dev/kubevela/pkg/controller/utils"
 )
@@ -69,6 +72,34 @@ var _ = Describe("Application Deployment Common Function Test", func() {
 			Expect(common).Should(BeEquivalentTo([]string{"c", "a"}))
 		})
 	})
+
+	Context("Test Validate Component Function", func() {
+		fldPath := field.NewPath("spec", "componentList")
+		targetApp := fillApplication([]string{"a", "b", "c"})
+		sourceApp := fillApplication([]string{"a", "b"})
+
+		It("Test default component", func() {
+			Expect(validateComponent(nil, targetApp, sourceApp, fldPath)).Should(HaveLen(1))
+			Expect(validateComponent(nil, targetApp, fillApplication([]string{"a"}), fldPath)).Should(BeEmpty())
+		})
+
+		It("Test multiple components", func() {
+			Expect(validateComponent([]string{"a", "b"}, targetApp, sourceApp, fldPath)).Should(BeEmpty())
+			Expect(validateComponent([]string{"a", "c"}, targetApp, sourceApp, fldPath)).Should(HaveLen(1))
+			Expect(validateComponent([]string{"a", "a"}, targetApp, sourceApp, fldPath)).Should(HaveLen(1))
+		})
+
+		It("Test component rollout strategy", func() {
+			appRollout := &v1beta1.AppRollout{Spec: v1beta1.AppRolloutSpec{
+				ComponentList:            []string{"a", "b"},
+				ComponentRolloutStrategy: v1beta1.OrderedComponentRollout,
+			}}
+			Expect(validateComponentRollout(appRollout, field.NewPath("spec"))).Should(BeEmpty())
+			appRollout.Spec.ComponentRolloutStrategy = "Random"
+			appRollout.Spec.RolloutPlan.TrafficRouting = &v1alpha1.TrafficRouting{Service: "web"}
+			Expect(validateComponentRollout(appRollout, field.NewPath("spec"))).Should(HaveLen(2))
+		})
+	})
 })
 
 func fillApplication(componentNames []string) []*types.ComponentManifest {
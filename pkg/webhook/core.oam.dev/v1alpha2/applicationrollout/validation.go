@@ -28,7 +28,6 @@ import (
 	"k8s.io/kubectl/pkg/util/slice"
 
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
-	"github.com/oam-dev/kubevela/apis/standard.oam.dev/v1alpha1"
 	"github.com/oam-dev/kubevela/apis/types"
 	oamutil "github.com/oam-dev/kubevela/pkg/oam/util"
 	"github.com/oam-dev/kubevela/pkg/webhook/common/rollout"
@@ -92,24 +91,20 @@ func (h *ValidatingHandler) ValidateCreate(appRollout *v1beta1.AppRollout) field
 			fldPath.Child("componentList"))...)
 	}
 
+	allErrs = append(allErrs, validateComponentRollout(appRollout, fldPath)...)
+
 	// validate the rollout plan spec
 	allErrs = append(allErrs, rollout.ValidateCreate(h, &appRollout.Spec.RolloutPlan, fldPath.Child("rolloutPlan"))...)
 	return allErrs
 }
 
 // validateComponent validate the ComponentList
-// 1. there can only be one component or less
-// 2. if there are no components, make sure the applications has only one common component so that's the default
-// 3. it is contained in both source and target application
-// 4. the common component has the same type
+// 1. if there are no components, make sure the applications has only one common component so that's the default
+// 2. every component is contained in both source and target application and is listed only once
+// 3. the common component has the same type
 func validateComponent(componentList []string, targetApp, sourceApp []*types.ComponentManifest,
 	fldPath *field.Path) field.ErrorList {
 	var componentErrs field.ErrorList
-	var commonComponentName string
-	if len(componentList) > 1 {
-		componentErrs = append(componentErrs, field.TooLong(fldPath, componentList, 1))
-		return componentErrs
-	}
 	commons := FindCommonComponent(targetApp, sourceApp)
 	if len(componentList) == 0 {
 		// we need to find the default
@@ -117,31 +112,43 @@ func validateComponent(componentList []string, targetApp, sourceApp []*types.Com
 			// we cannot find a default component if there are multiple
 			klog.Error("there are more than one common component", "common component", commons)
 			componentErrs = append(componentErrs, field.TooMany(fldPath, len(commons), 1))
-			return componentErrs
 		}
-		commonComponentName = commons[0]
-	} else {
+		return componentErrs
+	}
+	listed := make(map[string]bool, len(componentList))
+	for i, comp := range componentList {
+		if listed[comp] {
+			componentErrs = append(componentErrs, field.Duplicate(fldPath.Index(i), comp))
+			continue
+		}
+		listed[comp] = true
 		// the component need to be one of the common components
-		if !slice.ContainsString(commons, componentList[0], nil) {
+		if !slice.ContainsString(commons, comp, nil) {
 			klog.Error("The component does not belong to the application",
-				"common components", commons, "component to upgrade", componentList[0])
-			componentErrs = append(componentErrs, field.Invalid(fldPath, componentList[0],
+				"common components", commons, "component to upgrade", comp)
+			componentErrs = append(componentErrs, field.Invalid(fldPath.Index(i), comp,
 				"it is not a common component in the application"))
-			return componentErrs
 		}
-		commonComponentName = componentList[0]
-	}
-	// check if the workload type are the same in the source and target application
-	if len(commonComponentName) == 0 {
-		klog.Error("the common component have different types in the application",
-			"common component", commonComponentName)
-		componentErrs = append(componentErrs, field.Invalid(fldPath, componentList[0],
-			"the common component have different types in the application"))
 	}
-
 	return componentErrs
 }
 
+// validateComponentRollout validates how to roll out multiple components
+func validateComponentRollout(appRollout *v1beta1.AppRollout, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	strategy := appRollout.Spec.ComponentRolloutStrategy
+	if len(strategy) != 0 && strategy != v1beta1.LockstepComponentRollout && strategy != v1beta1.OrderedComponentRollout {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("componentRolloutStrategy"), strategy,
+			[]string{string(v1beta1.LockstepComponentRollout), string(v1beta1.OrderedComponentRollout)}))
+	}
+	// the traffic of a service can only be shifted between the workloads of one component
+	if len(appRollout.Spec.ComponentList) > 1 && appRollout.Spec.RolloutPlan.TrafficRouting != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("rolloutPlan", "trafficRouting"),
+			"the traffic routing only works with a single component"))
+	}
+	return allErrs
+}
+
 // ValidateUpdate validates the AppRollout on update
 func (h *ValidatingHandler) ValidateUpdate(new, old *v1beta1.AppRollout) field.ErrorList {
 	klog.InfoS("validate update", "name", new.Name)
@@ -152,8 +159,7 @@ func (h *ValidatingHandler) ValidateUpdate(new, old *v1beta1.AppRollout) field.E
 		return errList
 	}
 	// we can only reuse the rollout after reaching terminating state if the target and source has changed
-	if old.Status.RollingState == v1alpha1.RolloutSucceedState ||
-		old.Status.RollingState == v1alpha1.RolloutFailedState {
+	if old.Status.IsTerminated() {
 		if old.Spec.SourceAppRevisionName == new.Spec.SourceAppRevisionName &&
 			old.Spec.TargetAppRevisionName == new.Spec.TargetAppRevisionName {
 			if !apiequality.Semantic.DeepEqual(&old.Spec.RolloutPlan, &new.Spec.RolloutPlan) {
@@ -163,7 +169,7 @@ func (h *ValidatingHandler) ValidateUpdate(new, old *v1beta1.AppRollout) field.E
 					return errList
 				}
 				errList = append(errList, field.Invalid(fldPath, new.Spec,
-					"a successful or failed rollout cannot be modified without changing the target or the source"))
+					"a successful, failed or rolled back rollout cannot be modified without changing the target or the source"))
 				return errList
 			}
 		}
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/parser"
+	"github.com/pkg/errors"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	oamcore "github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/cue/process"
+)
+
+const (
+	// ConditionOutputsFieldName is the field of values exported by previous steps in the condition of a step
+	ConditionOutputsFieldName = "outputs"
+	// ConditionStatusFieldName is the field of the status of executed steps in the condition of a step
+	ConditionStatusFieldName = "status"
+)
+
+// evalCondition evaluates the `if` condition of the step with the application context,
+// the values exported by previous steps and the status of executed steps.
+func (w *workflow) evalCondition(step oamcore.WorkflowStep, rev string, wfCtx *Context) (bool, error) {
+	vars, err := wfCtx.Vars()
+	if err != nil {
+		return false, err
+	}
+	statuses := map[string]common.WorkflowStepStatus{}
+	for _, status := range w.app.Status.Workflow.Steps {
+		statuses[status.Name] = status
+	}
+	bOutputs, err := json.Marshal(vars)
+	if err != nil {
+		return false, errors.WithMessagef(err, "marshal outputs for condition of workflow step %s", step.Name)
+	}
+	bStatus, err := json.Marshal(statuses)
+	if err != nil {
+		return false, errors.WithMessagef(err, "marshal status for condition of workflow step %s", step.Name)
+	}
+	pCtx := process.NewContext(w.app.Namespace, step.Name, w.app.Name, rev)
+	src := fmt.Sprintf("%s\n%s: %s\n%s: %s\n", pCtx.BaseContextFile(),
+		ConditionOutputsFieldName, string(bOutputs), ConditionStatusFieldName, string(bStatus))
+
+	r := cue.Runtime{}
+	inst, err := r.Compile("-", src)
+	if err != nil {
+		return false, errors.WithMessagef(err, "compile condition of workflow step %s", step.Name)
+	}
+	expr, err := parser.ParseExpr("if", step.If)
+	if err != nil {
+		return false, errors.WithMessagef(err, "invalid condition of workflow step %s", step.Name)
+	}
+	ok, err := inst.Eval(expr).Bool()
+	if err != nil {
+		return false, errors.WithMessagef(err, "evaluate condition of workflow step %s", step.Name)
+	}
+	return ok, nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oamcore "github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+// Context is the workflow context of an application revision.
+// It keeps the values exported by the outputs of workflow steps, so that later steps can use them as inputs.
+// The values are stored in a ConfigMap, one key per value in json format, so that they are not lost
+// when the controller restarts.
+// It's safe to be used by steps executed concurrently.
+type Context struct {
+	cli   client.Client
+	mu    sync.Mutex
+	cm    *corev1.ConfigMap
+	dirty bool
+}
+
+// GenerateContextName returns the name of the ConfigMap storing the workflow context of an application revision.
+func GenerateContextName(appRevName string) string {
+	return fmt.Sprintf("workflow-%s", appRevName)
+}
+
+// LoadContext loads the workflow context of the application revision.
+// An empty context is returned if it doesn't exist yet, the ConfigMap is only created when a value is committed.
+func LoadContext(ctx context.Context, cli client.Client, app *oamcore.Application, appRevName string) (*Context, error) {
+	cm := &corev1.ConfigMap{}
+	err := cli.Get(ctx, client.ObjectKey{Namespace: app.Namespace, Name: GenerateContextName(appRevName)}, cm)
+	if err == nil {
+		return &Context{cli: cli, cm: cm}, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, errors.WithMessagef(err, "get workflow context of %s", appRevName)
+	}
+	cm = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GenerateContextName(appRevName),
+			Namespace: app.Namespace,
+			Labels: map[string]string{
+				oam.LabelAppName:     app.Name,
+				oam.LabelAppRevision: appRevName,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(app, oamcore.ApplicationKindVersionKind),
+			},
+		},
+	}
+	return &Context{cli: cli, cm: cm}, nil
+}
+
+// GetVar returns the value exported with the name.
+func (c *Context) GetVar(name string) (interface{}, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	raw, ok := c.cm.Data[name]
+	if !ok {
+		return nil, false, nil
+	}
+	var v interface{}
+	if err := utiljson.Unmarshal([]byte(raw), &v); err != nil {
+		return nil, false, errors.WithMessagef(err, "invalid value of %s in workflow context", name)
+	}
+	return v, true, nil
+}
+
+// Vars returns all values exported into the context.
+func (c *Context) Vars() (map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	vars := make(map[string]interface{}, len(c.cm.Data))
+	for name, raw := range c.cm.Data {
+		var v interface{}
+		if err := utiljson.Unmarshal([]byte(raw), &v); err != nil {
+			return nil, errors.WithMessagef(err, "invalid value of %s in workflow context", name)
+		}
+		vars[name] = v
+	}
+	return vars, nil
+}
+
+// SetVar exports the value with the name. The value is not stored until Commit is called.
+func (c *Context) SetVar(name string, value interface{}) error {
+	if errs := validation.IsConfigMapKey(name); len(errs) != 0 {
+		return errors.Errorf("invalid name %q of workflow context value: %v", name, errs)
+	}
+	b, err := utiljson.Marshal(value)
+	if err != nil {
+		return errors.WithMessagef(err, "marshal value of %s", name)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cm.Data == nil {
+		c.cm.Data = map[string]string{}
+	}
+	if c.cm.Data[name] != string(b) {
+		c.cm.Data[name] = string(b)
+		c.dirty = true
+	}
+	return nil
+}
+
+// Commit stores the changed values of the context.
+func (c *Context) Commit(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	var err error
+	if c.cm.ResourceVersion == "" {
+		err = c.cli.Create(ctx, c.cm)
+	} else {
+		err = c.cli.Update(ctx, c.cm)
+	}
+	if err != nil {
+		return errors.WithMessagef(err, "store workflow context %s", c.cm.Name)
+	}
+	c.dirty = false
+	return nil
+}
+
+// deleteContext deletes the workflow context of an application revision which is no longer executed.
+func deleteContext(ctx context.Context, cli client.Client, namespace, appRevName string) error {
+	cm := &corev1.ConfigMap{}
+	cm.SetNamespace(namespace)
+	cm.SetName(GenerateContextName(appRevName))
+	if err := cli.Delete(ctx, cm); err != nil && !apierrors.IsNotFound(err) {
+		return errors.WithMessagef(err, "delete workflow context of %s", appRevName)
+	}
+	return nil
+}
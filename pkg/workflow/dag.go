@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	oamcore "github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+)
+
+// dag is the dependency graph of the steps of a workflow in dag mode.
+type dag struct {
+	// Deps maps the name of a step to the names of the steps it depends on.
+	Deps map[string][]string
+}
+
+// newDAG creates the dependency graph of the workflow steps.
+// It returns an error if a step depends on an unknown step or the dependencies form a cycle.
+func newDAG(steps []oamcore.WorkflowStep) (*dag, error) {
+	d := &dag{
+		Deps: make(map[string][]string, len(steps)),
+	}
+	for _, step := range steps {
+		if _, ok := d.Deps[step.Name]; ok {
+			return nil, errors.Errorf("duplicated workflow step name %s", step.Name)
+		}
+		d.Deps[step.Name] = step.DependsOn
+	}
+	for _, step := range steps {
+		for _, dep := range step.DependsOn {
+			if _, ok := d.Deps[dep]; !ok {
+				return nil, errors.Errorf("workflow step %s depends on unknown step %s", step.Name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make(map[string]int, len(steps))
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch states[name] {
+		case visited:
+			return nil
+		case visiting:
+			// report the cycle starting from the first occurrence of the step in the path
+			for i, p := range path {
+				if p == name {
+					return errors.Errorf("dependency cycle in workflow steps: %s", strings.Join(append(path[i:], name), " -> "))
+				}
+			}
+		}
+		states[name] = visiting
+		path = append(path, name)
+		for _, dep := range d.Deps[name] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		states[name] = visited
+		return nil
+	}
+	for _, step := range steps {
+		if err := visit(step.Name); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Ready checks whether all dependencies of the step are done.
+func (d *dag) Ready(name string, done func(name string) bool) bool {
+	for _, dep := range d.Deps[name] {
+		if !done(dep) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	oamcore "github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+)
+
+func TestNewDAG(t *testing.T) {
+	testcases := map[string]struct {
+		steps []oamcore.WorkflowStep
+		err   string
+	}{
+		"valid graph": {
+			steps: []oamcore.WorkflowStep{
+				{Name: "a"},
+				{Name: "b", DependsOn: []string{"a"}},
+				{Name: "c", DependsOn: []string{"a", "b"}},
+			},
+		},
+		"duplicated step": {
+			steps: []oamcore.WorkflowStep{{Name: "a"}, {Name: "a"}},
+			err:   "duplicated workflow step name a",
+		},
+		"unknown dependency": {
+			steps: []oamcore.WorkflowStep{{Name: "a", DependsOn: []string{"b"}}},
+			err:   "workflow step a depends on unknown step b",
+		},
+		"self dependency": {
+			steps: []oamcore.WorkflowStep{{Name: "a", DependsOn: []string{"a"}}},
+			err:   "dependency cycle in workflow steps: a -> a",
+		},
+		"cycle": {
+			steps: []oamcore.WorkflowStep{
+				{Name: "a"},
+				{Name: "b", DependsOn: []string{"a", "d"}},
+				{Name: "c", DependsOn: []string{"b"}},
+				{Name: "d", DependsOn: []string{"c"}},
+			},
+			err: "dependency cycle in workflow steps: b -> d -> c -> b",
+		},
+	}
+	for name, tc := range testcases {
+		_, err := newDAG(tc.steps)
+		if tc.err == "" {
+			assert.NoError(t, err, name)
+			continue
+		}
+		assert.EqualError(t, err, tc.err, name)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oamcore "github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/controller/utils"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+// RecordHistory records the workflow status of the application into the status of the application revision
+// it's executed for, so that the execution can be audited after the application is updated to a new revision.
+func RecordHistory(ctx context.Context, cli client.Client, app *oamcore.Application) error {
+	wfStatus := app.Status.Workflow
+	if wfStatus == nil || wfStatus.AppRevision == "" {
+		return nil
+	}
+	appRev := &oamcore.ApplicationRevision{}
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: app.Namespace, Name: wfStatus.AppRevision}, appRev); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the revision is garbage collected
+			return nil
+		}
+		return errors.WithMessagef(err, "get application revision %s", wfStatus.AppRevision)
+	}
+	if apiequality.Semantic.DeepEqual(appRev.Status.Workflow, wfStatus) {
+		return nil
+	}
+	if prev := appRev.Status.Workflow; prev != nil && prev.Restarts != wfStatus.Restarts {
+		// the workflow is restarted, the previous execution is kept as a separate entry
+		appRev.Status.RestartedWorkflows = append(appRev.Status.RestartedWorkflows, *prev)
+	}
+	appRev.Status.Workflow = wfStatus.DeepCopy()
+	if err := cli.Status().Update(ctx, appRev); err != nil {
+		return errors.WithMessagef(err, "record workflow history in application revision %s", appRev.Name)
+	}
+	return nil
+}
+
+// ListHistory returns the revisions of the application which have executed the workflow, sorted from the oldest to the latest.
+// Only the revisions which are not garbage collected are returned.
+func ListHistory(ctx context.Context, cli client.Client, app *oamcore.Application) ([]oamcore.ApplicationRevision, error) {
+	appRevList := &oamcore.ApplicationRevisionList{}
+	if err := cli.List(ctx, appRevList, client.InNamespace(app.Namespace),
+		client.MatchingLabels{oam.LabelAppName: app.Name}); err != nil {
+		return nil, errors.WithMessagef(err, "list revisions of application %s", app.Name)
+	}
+	var history []oamcore.ApplicationRevision
+	for _, appRev := range appRevList.Items {
+		if appRev.Status.Workflow != nil {
+			history = append(history, appRev)
+		}
+	}
+	sort.Slice(history, func(i, j int) bool {
+		// revision names are generated by the controller, so the error is ignored
+		ri, _ := utils.ExtractRevision(history[i].Name)
+		rj, _ := utils.ExtractRevision(history[j].Name)
+		return ri < rj
+	})
+	return history, nil
+}
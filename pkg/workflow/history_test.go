@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	oamcore "github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+func TestWorkflowHistory(t *testing.T) {
+	app := &oamcore.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+		},
+		Spec: oamcore.ApplicationSpec{
+			Workflow: &oamcore.Workflow{
+				Steps: []oamcore.WorkflowStep{{
+					Name: "deploy",
+					Type: "test",
+				}},
+			},
+		},
+	}
+	newAppRev := func(name string) *oamcore.ApplicationRevision {
+		return &oamcore.ApplicationRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{oam.LabelAppName: "app"},
+			},
+		}
+	}
+	cli := newFakeClient(newAppRev("app-v1"), newAppRev("app-v2"), newAppRev("app-v10"))
+	runners := []TaskRunner{&fakeTaskRunner{name: "deploy", phase: common.WorkflowStepPhaseSucceeded}}
+	ctx := context.Background()
+
+	// nothing is recorded before the workflow is executed
+	assert.NoError(t, RecordHistory(ctx, cli, app))
+	history, err := ListHistory(ctx, cli, app)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(history))
+
+	for _, rev := range []string{"app-v10", "app-v1", "app-v2"} {
+		done, err := NewWorkflow(app, cli, nil).ExecuteSteps(ctx, rev, runners)
+		assert.NoError(t, err)
+		assert.Equal(t, true, done)
+		assert.NoError(t, RecordHistory(ctx, cli, app))
+	}
+	// the revision is garbage collected
+	app.Status.Workflow.AppRevision = "app-v3"
+	assert.NoError(t, RecordHistory(ctx, cli, app))
+
+	history, err = ListHistory(ctx, cli, app)
+	assert.NoError(t, err)
+	var revs []string
+	for _, appRev := range history {
+		revs = append(revs, appRev.Name)
+		assert.Equal(t, appRev.Name, appRev.Status.Workflow.AppRevision)
+		assert.Equal(t, 1, len(appRev.Status.Workflow.Steps))
+		assert.Equal(t, common.WorkflowStepPhaseSucceeded, appRev.Status.Workflow.Steps[0].Phase)
+		assert.NotNil(t, appRev.Status.Workflow.Steps[0].FinishedAt)
+	}
+	assert.Equal(t, []string{"app-v1", "app-v2", "app-v10"}, revs)
+
+	// the restarted execution is appended to the history of the revision
+	app.Status.Workflow.AppRevision = "app-v10"
+	app.Status.Workflow.Restarts = 1
+	app.Status.Workflow.Steps = nil
+	assert.NoError(t, RecordHistory(ctx, cli, app))
+	history, err = ListHistory(ctx, cli, app)
+	assert.NoError(t, err)
+	latest := history[len(history)-1]
+	assert.Equal(t, 1, latest.Status.Workflow.Restarts)
+	assert.Equal(t, 0, len(latest.Status.Workflow.Steps))
+	assert.Equal(t, 1, len(latest.Status.RestartedWorkflows))
+	assert.Equal(t, 0, latest.Status.RestartedWorkflows[0].Restarts)
+	assert.Equal(t, 1, len(latest.Status.RestartedWorkflows[0].Steps))
+}
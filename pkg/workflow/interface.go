@@ -19,14 +19,36 @@ package workflow
 import (
 	"context"
 
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/types"
 )
 
 // Workflow is used to execute the workflow steps of Application.
 type Workflow interface {
-	// ExecuteSteps executes the steps of an Application with given steps of rendered resources.
+	// ExecuteSteps executes the steps of an Application with given task runners,
+	// the task runners must be in the same order as the steps.
 	// It returns done=true only if all steps are executed and succeeded.
-	ExecuteSteps(ctx context.Context, appRevName string, steps []*unstructured.Unstructured) (done bool, err error)
+	// A suspended or terminated workflow is not executed and returns done=false.
+	ExecuteSteps(ctx context.Context, appRevName string, taskRunners []TaskRunner) (done bool, err error)
+}
+
+// RollbackFunc rolls the resources of the application back to its previous revision.
+type RollbackFunc func(ctx context.Context) error
+
+// TaskRunner executes one workflow step inside the controller.
+type TaskRunner interface {
+	// Name returns the name of the workflow step.
+	Name() string
+	// Run executes the workflow step and returns its current status.
+	// Inputs of the step are read from and outputs are exported to wfCtx.
+	// The returned Operation, if not nil, asks the workflow to take an action after the step.
+	Run(ctx context.Context, wctx *types.WorkflowContext, wfCtx *Context) (common.WorkflowStepStatus, *Operation, error)
+}
+
+// Operation is an action that a workflow step asks the workflow to take.
+type Operation struct {
+	// Suspend pauses the workflow after the current step.
+	Suspend bool
 }
 
 // SucceededMessage is the data json-marshalled into the message of `workflow-progress` condition
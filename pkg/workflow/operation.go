@@ -0,0 +1,159 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	oamcore "github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+// the operations requested by the annotation oam.AnnotationWorkflowOperation
+const (
+	operationSuspend   = "suspend"
+	operationResume    = "resume"
+	operationTerminate = "terminate"
+	operationRestart   = "restart"
+)
+
+// SuspendWorkflow pauses the running workflow of the application after the current steps.
+func SuspendWorkflow(ctx context.Context, cli client.Client, app *oamcore.Application) error {
+	return requestOperation(ctx, cli, app, operationSuspend, func(wfStatus *common.WorkflowStatus) error {
+		if wfStatus == nil {
+			return errors.Errorf("the workflow of application %s is not started", app.Name)
+		}
+		if wfStatus.Terminated {
+			return errors.Errorf("the workflow of application %s is terminated", app.Name)
+		}
+		return nil
+	})
+}
+
+// ResumeWorkflow continues the suspended workflow of the application from the next step.
+func ResumeWorkflow(ctx context.Context, cli client.Client, app *oamcore.Application) error {
+	return requestOperation(ctx, cli, app, operationResume, func(wfStatus *common.WorkflowStatus) error {
+		if wfStatus == nil || !wfStatus.Suspend {
+			return errors.Errorf("the workflow of application %s is not suspended", app.Name)
+		}
+		if wfStatus.Terminated {
+			return errors.Errorf("the workflow of application %s is terminated, restart it instead", app.Name)
+		}
+		return nil
+	})
+}
+
+// TerminateWorkflow stops the workflow of the application, the running steps are marked as stopped.
+// A terminated workflow can only be restarted.
+func TerminateWorkflow(ctx context.Context, cli client.Client, app *oamcore.Application) error {
+	return requestOperation(ctx, cli, app, operationTerminate, func(wfStatus *common.WorkflowStatus) error {
+		if wfStatus == nil {
+			return errors.Errorf("the workflow of application %s is not started", app.Name)
+		}
+		return nil
+	})
+}
+
+// RestartWorkflow executes the workflow of the application from the first step again.
+// The workflow is executed for the current application revision, and the values exported
+// by the previous execution are dropped.
+func RestartWorkflow(ctx context.Context, cli client.Client, app *oamcore.Application) error {
+	return requestOperation(ctx, cli, app, operationRestart, func(wfStatus *common.WorkflowStatus) error {
+		return nil
+	})
+}
+
+// requestOperation validates the operation against the workflow status of the latest application and requests it
+// by annotation. The workflow status is owned by the controller, which applies the operation in the next reconciliation.
+func requestOperation(ctx context.Context, cli client.Client, app *oamcore.Application, operation string, validate func(wfStatus *common.WorkflowStatus) error) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if err := cli.Get(ctx, client.ObjectKey{Namespace: app.Namespace, Name: app.Name}, app); err != nil {
+			return err
+		}
+		if app.Spec.Workflow == nil || len(app.Spec.Workflow.Steps) == 0 {
+			return errors.Errorf("application %s has no workflow", app.Name)
+		}
+		if err := validate(app.Status.Workflow); err != nil {
+			return err
+		}
+		annotations := app.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[oam.AnnotationWorkflowOperation] = operation
+		app.SetAnnotations(annotations)
+		return cli.Update(ctx, app)
+	})
+}
+
+// applyOperation applies the operation requested by annotation to the workflow status, and removes the annotation
+// once the status is persisted so that the operation is neither lost nor overwritten by the controller.
+func (w *workflow) applyOperation(ctx context.Context) error {
+	operation, ok := w.app.GetAnnotations()[oam.AnnotationWorkflowOperation]
+	if !ok {
+		return nil
+	}
+	wfStatus := w.app.Status.Workflow
+	switch operation {
+	case operationSuspend:
+		if !wfStatus.Terminated {
+			wfStatus.Suspend = true
+		}
+	case operationResume:
+		if !wfStatus.Terminated {
+			wfStatus.Suspend = false
+		}
+	case operationTerminate:
+		wfStatus.Terminated = true
+		for i := range wfStatus.Steps {
+			if wfStatus.Steps[i].Phase == common.WorkflowStepPhaseRunning {
+				wfStatus.Steps[i].Phase = common.WorkflowStepPhaseStopped
+				wfStatus.Steps[i].Message = "terminated by operator"
+			}
+		}
+	case operationRestart:
+		if err := deleteContext(ctx, w.cli, w.app.Namespace, wfStatus.AppRevision); err != nil {
+			return err
+		}
+		// keep the revision so that it's not treated as a new one, the restart is counted
+		// so that the previous execution is kept in the history
+		*wfStatus = common.WorkflowStatus{
+			AppRevision: wfStatus.AppRevision,
+			Restarts:    wfStatus.Restarts + 1,
+			Steps:       []common.WorkflowStepStatus{},
+		}
+	default:
+		klog.InfoS("Ignore unknown workflow operation", "application", klog.KObj(w.app), "operation", operation)
+	}
+	if err := w.cli.Status().Update(ctx, w.app); err != nil {
+		return errors.WithMessagef(err, "apply workflow operation %s", operation)
+	}
+	patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:null}}}`,
+		oam.AnnotationWorkflowOperation)))
+	if err := w.cli.Patch(ctx, w.app, patch); err != nil {
+		return errors.WithMessagef(err, "remove workflow operation %s", operation)
+	}
+	return nil
+}
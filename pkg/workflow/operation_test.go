@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	oamcore "github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+func TestWorkflowOperations(t *testing.T) {
+	ctx := context.Background()
+	app := &oamcore.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+		},
+		Spec: oamcore.ApplicationSpec{
+			Workflow: &oamcore.Workflow{
+				Steps: []oamcore.WorkflowStep{{Name: "s1", Type: "test"}, {Name: "s2", Type: "test"}},
+			},
+		},
+		Status: common.AppStatus{
+			Workflow: &common.WorkflowStatus{
+				AppRevision: "app-v1",
+				Steps: []common.WorkflowStepStatus{
+					{Name: "s1", Phase: common.WorkflowStepPhaseSucceeded},
+					{Name: "s2", Phase: common.WorkflowStepPhaseRunning},
+				},
+			},
+		},
+	}
+	wfCtx := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: GenerateContextName("app-v1"), Namespace: "default"}}
+	cli := newFakeClient(app.DeepCopy(), wfCtx)
+	latest := func() *oamcore.Application {
+		got := &oamcore.Application{}
+		assert.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "app"}, got))
+		return got
+	}
+
+	// the operation is requested by annotation and applied to the status by the workflow
+	operate := func(operation func(context.Context, client.Client, *oamcore.Application) error) *common.WorkflowStatus {
+		assert.NoError(t, operation(ctx, cli, app.DeepCopy()))
+		got := latest()
+		assert.NotEmpty(t, got.Annotations[oam.AnnotationWorkflowOperation])
+		assert.NoError(t, NewWorkflow(got, cli, nil).(*workflow).applyOperation(ctx))
+		got = latest()
+		_, requested := got.Annotations[oam.AnnotationWorkflowOperation]
+		assert.Equal(t, false, requested)
+		return got.Status.Workflow
+	}
+
+	assert.Error(t, ResumeWorkflow(ctx, cli, app.DeepCopy()))
+	assert.Equal(t, true, operate(SuspendWorkflow).Suspend)
+	assert.Equal(t, false, operate(ResumeWorkflow).Suspend)
+
+	wfStatus := operate(TerminateWorkflow)
+	assert.Equal(t, true, wfStatus.Terminated)
+	assert.Equal(t, common.WorkflowStepPhaseSucceeded, wfStatus.Steps[0].Phase)
+	assert.Equal(t, common.WorkflowStepPhaseStopped, wfStatus.Steps[1].Phase)
+	assert.Error(t, SuspendWorkflow(ctx, cli, app.DeepCopy()))
+
+	wfStatus = operate(RestartWorkflow)
+	assert.Equal(t, "app-v1", wfStatus.AppRevision)
+	assert.Equal(t, false, wfStatus.Terminated)
+	assert.Equal(t, 1, wfStatus.Restarts)
+	assert.Equal(t, 0, len(wfStatus.Steps))
+	err := cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: GenerateContextName("app-v1")}, &corev1.ConfigMap{})
+	assert.Equal(t, true, apierrors.IsNotFound(err))
+
+	noWorkflow := app.DeepCopy()
+	noWorkflow.Name = "no-workflow"
+	noWorkflow.Spec.Workflow = nil
+	assert.NoError(t, cli.Create(ctx, noWorkflow))
+	assert.Error(t, SuspendWorkflow(ctx, cli, noWorkflow))
+}
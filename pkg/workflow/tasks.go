@@ -0,0 +1,446 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/parser"
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	oamcore "github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/types"
+	velacue "github.com/oam-dev/kubevela/pkg/cue"
+	"github.com/oam-dev/kubevela/pkg/cue/packages"
+	"github.com/oam-dev/kubevela/pkg/cue/process"
+	"github.com/oam-dev/kubevela/pkg/cue/task"
+	"github.com/oam-dev/kubevela/pkg/utils/apply"
+)
+
+const (
+	// HealthFieldName is the field of a step template deciding whether the applied resources are ready.
+	// The step keeps running until it is evaluated to true.
+	HealthFieldName = "health"
+	// SuspendFieldName is the field of a step template which suspends the workflow after the step if it's true.
+	SuspendFieldName = "suspend"
+	// ProcessingFieldName is the field of a step template which calls out to an external service.
+	ProcessingFieldName = "processing"
+)
+
+// ComponentApplier applies the rendered resources of the given components.
+// All components of the application are applied if no component is given.
+type ComponentApplier func(ctx context.Context, components ...string) error
+
+// TaskDiscover generates the TaskRunner of workflow steps.
+type TaskDiscover struct {
+	app             *oamcore.Application
+	applicator      apply.Applicator
+	pd              *packages.PackageDiscover
+	applyComponents ComponentApplier
+}
+
+// NewTaskDiscover creates a TaskDiscover.
+func NewTaskDiscover(app *oamcore.Application, applicator apply.Applicator, pd *packages.PackageDiscover, applyComponents ComponentApplier) *TaskDiscover {
+	return &TaskDiscover{
+		app:             app,
+		applicator:      applicator,
+		pd:              pd,
+		applyComponents: applyComponents,
+	}
+}
+
+// GetTaskRunner returns the TaskRunner of a workflow step.
+// Built-in step types are executed by the controller directly, other steps are executed
+// by evaluating the CUE template of their WorkflowStepDefinition.
+func (td *TaskDiscover) GetTaskRunner(step oamcore.WorkflowStep, template string, params map[string]interface{}) (TaskRunner, error) {
+	if types.IsBuiltinWorkflowStepType(step.Type) && (len(step.Inputs) != 0 || len(step.Outputs) != 0) {
+		return nil, errors.Errorf("inputs and outputs are not supported by built-in workflow step %s", step.Name)
+	}
+	switch step.Type {
+	case types.WorkflowStepTypeApplyComponent:
+		comp, _ := params["component"].(string)
+		if comp == "" {
+			return nil, errors.Errorf("the component to apply is not specified in workflow step %s", step.Name)
+		}
+		return &applyComponentsTask{step: step, components: []string{comp}, apply: td.applyComponents}, nil
+	case types.WorkflowStepTypeApplyApplication:
+		return &applyComponentsTask{step: step, apply: td.applyComponents}, nil
+	case types.WorkflowStepTypeSuspend:
+		return &suspendTask{step: step}, nil
+	default:
+		return &templateTask{
+			step:       step,
+			template:   template,
+			params:     params,
+			app:        td.app,
+			applicator: td.applicator,
+			pd:         td.pd,
+		}, nil
+	}
+}
+
+// applyComponentsTask applies the resources of components rendered in the application revision.
+type applyComponentsTask struct {
+	step       oamcore.WorkflowStep
+	components []string
+	apply      ComponentApplier
+}
+
+func (t *applyComponentsTask) Name() string {
+	return t.step.Name
+}
+
+func (t *applyComponentsTask) Run(ctx context.Context, _ *types.WorkflowContext, _ *Context) (common.WorkflowStepStatus, *Operation, error) {
+	status := common.WorkflowStepStatus{
+		Name: t.step.Name,
+		Type: t.step.Type,
+	}
+	if err := t.apply(ctx, t.components...); err != nil {
+		return status, nil, errors.WithMessagef(err, "workflow step %s", t.step.Name)
+	}
+	status.Phase = common.WorkflowStepPhaseSucceeded
+	return status, nil, nil
+}
+
+// suspendTask suspends the workflow until it's resumed, e.g. to wait for manual approval.
+type suspendTask struct {
+	step oamcore.WorkflowStep
+}
+
+func (t *suspendTask) Name() string {
+	return t.step.Name
+}
+
+func (t *suspendTask) Run(_ context.Context, _ *types.WorkflowContext, _ *Context) (common.WorkflowStepStatus, *Operation, error) {
+	// the step is succeeded once executed, so the workflow continues from the next step after resumed
+	return common.WorkflowStepStatus{
+		Name:  t.step.Name,
+		Type:  t.step.Type,
+		Phase: common.WorkflowStepPhaseSucceeded,
+	}, &Operation{Suspend: true}, nil
+}
+
+// templateTask executes a step by evaluating the CUE template of its WorkflowStepDefinition.
+// The template can apply resources by `output` and `outputs`, wait for them by `health`,
+// call out by `processing` and suspend the workflow by `suspend`.
+// Without `health`, the step waits for the `workflow-progress` condition of its `output`.
+// Inputs of the step are filled into `parameter` and outputs are evaluated once the step succeeded.
+type templateTask struct {
+	step       oamcore.WorkflowStep
+	template   string
+	params     map[string]interface{}
+	app        *oamcore.Application
+	applicator apply.Applicator
+	pd         *packages.PackageDiscover
+}
+
+func (t *templateTask) Name() string {
+	return t.step.Name
+}
+
+func (t *templateTask) Run(ctx context.Context, wctx *types.WorkflowContext, wfCtx *Context) (common.WorkflowStepStatus, *Operation, error) {
+	status := common.WorkflowStepStatus{
+		Name: t.step.Name,
+		Type: t.step.Type,
+	}
+	params, err := t.fillInputs(wfCtx)
+	if err != nil {
+		status.Phase = common.WorkflowStepPhaseFailed
+		status.Message = err.Error()
+		return status, nil, nil
+	}
+	inst, err := t.render(wctx, params)
+	if err != nil {
+		status.Phase = common.WorkflowStepPhaseFailed
+		status.Message = err.Error()
+		return status, nil, nil
+	}
+
+	if suspend, err := inst.Lookup(SuspendFieldName).Bool(); err == nil && suspend {
+		status.Phase = common.WorkflowStepPhaseSucceeded
+		t.exportOutputs(inst, wfCtx, &status)
+		if status.Phase != common.WorkflowStepPhaseSucceeded {
+			return status, nil, nil
+		}
+		return status, &Operation{Suspend: true}, nil
+	}
+
+	output, outputs, err := t.getObjects(inst)
+	if err != nil {
+		status.Phase = common.WorkflowStepPhaseFailed
+		status.Message = err.Error()
+		return status, nil, nil
+	}
+	for _, obj := range append([]*unstructured.Unstructured{output}, mapValues(outputs)...) {
+		if obj == nil {
+			continue
+		}
+		if err := addWorkflowContextToAnnotation(obj, wctx); err != nil {
+			return status, nil, err
+		}
+		if err := t.applicator.Apply(ctx, obj); err != nil {
+			return status, nil, errors.WithMessagef(err, "workflow step %s apply %s %s", t.step.Name, obj.GetKind(), obj.GetName())
+		}
+	}
+	if output != nil {
+		status.ResourceRef = runtimev1alpha1.TypedReference{
+			APIVersion: output.GetAPIVersion(),
+			Kind:       output.GetKind(),
+			Name:       output.GetName(),
+			UID:        output.GetUID(),
+		}
+	}
+
+	hasHealth := inst.Lookup(HealthFieldName).Exists()
+	if hasHealth || len(t.step.Outputs) != 0 {
+		if inst, err = fillContextOutputs(inst, output, outputs); err != nil {
+			status.Phase = common.WorkflowStepPhaseRunning
+			status.Message = err.Error()
+			return status, nil, nil
+		}
+	}
+
+	switch {
+	case hasHealth:
+		healthy, err := inst.Lookup(HealthFieldName).Bool()
+		if err != nil {
+			status.Phase = common.WorkflowStepPhaseRunning
+			status.Message = errors.WithMessage(err, "evaluate health").Error()
+			return status, nil, nil
+		}
+		if !healthy {
+			status.Phase = common.WorkflowStepPhaseRunning
+			return status, nil, nil
+		}
+		status.Phase = common.WorkflowStepPhaseSucceeded
+	case output != nil:
+		phase, err := getProgressPhase(output)
+		if err != nil {
+			return status, nil, err
+		}
+		status.Phase = phase
+	default:
+		status.Phase = common.WorkflowStepPhaseSucceeded
+	}
+
+	if status.Phase == common.WorkflowStepPhaseSucceeded {
+		t.exportOutputs(inst, wfCtx, &status)
+	}
+	return status, nil, nil
+}
+
+// fillInputs returns the parameter of the step with the values of its inputs filled in.
+func (t *templateTask) fillInputs(wfCtx *Context) (map[string]interface{}, error) {
+	if len(t.step.Inputs) == 0 {
+		return t.params, nil
+	}
+	params := map[string]interface{}{}
+	if t.params != nil {
+		// copy the parameter with the apimachinery json package, so that it can be set by unstructured helpers
+		bt, err := utiljson.Marshal(t.params)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "marshal parameter of workflow step %s", t.step.Name)
+		}
+		if err := utiljson.Unmarshal(bt, &params); err != nil {
+			return nil, errors.WithMessagef(err, "unmarshal parameter of workflow step %s", t.step.Name)
+		}
+	}
+	for _, input := range t.step.Inputs {
+		v, ok, err := wfCtx.GetVar(input.From)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.Errorf("input %s of workflow step %s is not exported by previous steps", input.From, t.step.Name)
+		}
+		if err := unstructured.SetNestedField(params, v, strings.Split(input.ParameterKey, ".")...); err != nil {
+			return nil, errors.WithMessagef(err, "fill input %s into parameter %s of workflow step %s", input.From, input.ParameterKey, t.step.Name)
+		}
+	}
+	return params, nil
+}
+
+// exportOutputs evaluates the outputs of the succeeded step and exports them into the workflow context.
+// The step keeps running if an output can't be evaluated yet, e.g. the status it refers to is not reported,
+// and fails if the output is not a valid expression.
+func (t *templateTask) exportOutputs(inst *cue.Instance, wfCtx *Context, status *common.WorkflowStepStatus) {
+	for _, output := range t.step.Outputs {
+		expr, err := parser.ParseExpr(output.Name, output.ValueFrom)
+		if err != nil {
+			status.Phase = common.WorkflowStepPhaseFailed
+			status.Message = errors.WithMessagef(err, "invalid output %s of workflow step %s", output.Name, t.step.Name).Error()
+			return
+		}
+		bt, err := inst.Eval(expr).MarshalJSON()
+		if err != nil {
+			status.Phase = common.WorkflowStepPhaseRunning
+			status.Message = errors.WithMessagef(err, "evaluate output %s of workflow step %s", output.Name, t.step.Name).Error()
+			return
+		}
+		var v interface{}
+		if err := utiljson.Unmarshal(bt, &v); err != nil {
+			status.Phase = common.WorkflowStepPhaseFailed
+			status.Message = errors.WithMessagef(err, "invalid output %s of workflow step %s", output.Name, t.step.Name).Error()
+			return
+		}
+		if err := wfCtx.SetVar(output.Name, v); err != nil {
+			status.Phase = common.WorkflowStepPhaseFailed
+			status.Message = err.Error()
+			return
+		}
+		if status.Outputs == nil {
+			status.Outputs = map[string]string{}
+		}
+		status.Outputs[output.Name] = string(bt)
+	}
+}
+
+func (t *templateTask) render(wctx *types.WorkflowContext, params map[string]interface{}) (*cue.Instance, error) {
+	bi := build.NewContext().NewInstance("", nil)
+	if err := bi.AddFile("-", t.template); err != nil {
+		return nil, errors.WithMessagef(err, "invalid template of workflow step %s", t.step.Name)
+	}
+	var paramFile = "parameter: {}"
+	if params != nil {
+		bt, err := json.Marshal(params)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "marshal parameter of workflow step %s", t.step.Name)
+		}
+		if string(bt) != "null" {
+			paramFile = fmt.Sprintf("%s: %s", velacue.ParameterTag, string(bt))
+		}
+	}
+	if err := bi.AddFile("parameter", paramFile); err != nil {
+		return nil, errors.WithMessagef(err, "invalid parameter of workflow step %s", t.step.Name)
+	}
+	pCtx := process.NewContext(t.app.Namespace, t.step.Name, t.app.Name, wctx.AppRevision)
+	if err := bi.AddFile("context", pCtx.BaseContextFile()); err != nil {
+		return nil, errors.WithMessagef(err, "invalid context of workflow step %s", t.step.Name)
+	}
+
+	inst, err := t.pd.ImportPackagesAndBuildInstance(bi)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "invalid template of workflow step %s", t.step.Name)
+	}
+	if err := inst.Value().Validate(); err != nil {
+		return nil, errors.WithMessagef(err, "invalid template of workflow step %s after merge parameter and context", t.step.Name)
+	}
+	if inst.Lookup(ProcessingFieldName).Exists() {
+		if inst, err = task.Process(inst); err != nil {
+			return nil, errors.WithMessagef(err, "invalid process of workflow step %s", t.step.Name)
+		}
+	}
+	return inst, nil
+}
+
+// getObjects returns the resources rendered in `output` and `outputs` of the step template.
+func (t *templateTask) getObjects(inst *cue.Instance) (*unstructured.Unstructured, map[string]*unstructured.Unstructured, error) {
+	var output *unstructured.Unstructured
+	if v := inst.Lookup(process.OutputFieldName); v.Exists() {
+		obj, err := t.toObject(v, t.step.Name)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "invalid output of workflow step %s", t.step.Name)
+		}
+		output = obj
+	}
+
+	outputs := map[string]*unstructured.Unstructured{}
+	v := inst.Lookup(process.OutputsFieldName)
+	if !v.Exists() {
+		return output, outputs, nil
+	}
+	st, err := v.Struct()
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "invalid outputs of workflow step %s", t.step.Name)
+	}
+	for i := 0; i < st.Len(); i++ {
+		fieldInfo := st.Field(i)
+		if fieldInfo.IsDefinition || fieldInfo.IsHidden || fieldInfo.IsOptional {
+			continue
+		}
+		obj, err := t.toObject(fieldInfo.Value, fmt.Sprintf("%s-%s", t.step.Name, fieldInfo.Name))
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "invalid outputs(%s) of workflow step %s", fieldInfo.Name, t.step.Name)
+		}
+		outputs[fieldInfo.Name] = obj
+	}
+	return output, outputs, nil
+}
+
+func (t *templateTask) toObject(v cue.Value, defaultName string) (*unstructured.Unstructured, error) {
+	bt, err := v.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{}
+	// decode with the apimachinery json package so that numbers are kept as int64 like other unstructured objects
+	if err := utiljson.Unmarshal(bt, &obj.Object); err != nil {
+		return nil, err
+	}
+	if obj.GetName() == "" {
+		obj.SetName(defaultName)
+	}
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(t.app.Namespace)
+	}
+	obj.SetOwnerReferences([]metav1.OwnerReference{
+		*metav1.NewControllerRef(t.app, oamcore.ApplicationKindVersionKind),
+	})
+	return obj, nil
+}
+
+// fillContextOutputs fills the applied resources into `context.output` and `context.outputs` of the template,
+// so that the health and outputs of the step can refer to their status.
+func fillContextOutputs(inst *cue.Instance, output *unstructured.Unstructured, outputs map[string]*unstructured.Unstructured) (*cue.Instance, error) {
+	var err error
+	if output != nil {
+		if inst, err = inst.Fill(output.Object, "context", process.OutputFieldName); err != nil {
+			return nil, errors.WithMessage(err, "fill output into context")
+		}
+	}
+	for name, obj := range outputs {
+		if inst, err = inst.Fill(obj.Object, "context", process.OutputsFieldName, name); err != nil {
+			return nil, errors.WithMessagef(err, "fill outputs(%s) into context", name)
+		}
+	}
+	return inst, nil
+}
+
+// mapValues returns the values of the map sorted by keys, so that resources are always applied in the same order.
+func mapValues(m map[string]*unstructured.Unstructured) []*unstructured.Unstructured {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]*unstructured.Unstructured, 0, len(m))
+	for _, k := range keys {
+		values = append(values, m[k])
+	}
+	return values
+}
@@ -19,11 +19,16 @@ package workflow
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
 
-	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
 	oamcore "github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
@@ -31,66 +36,132 @@ import (
 	"github.com/oam-dev/kubevela/pkg/controller/utils"
 	"github.com/oam-dev/kubevela/pkg/oam"
 	oamutil "github.com/oam-dev/kubevela/pkg/oam/util"
-	"github.com/oam-dev/kubevela/pkg/utils/apply"
+)
+
+const (
+	// retryBackoffBase is the time to wait before the first retry of a failed step, it doubles for every later retry.
+	retryBackoffBase = 5 * time.Second
+	// retryBackoffMax is the max time to wait before retrying a failed step.
+	retryBackoffMax = 5 * time.Minute
 )
 
 type workflow struct {
-	app        *oamcore.Application
-	applicator apply.Applicator
+	app      *oamcore.Application
+	cli      client.Client
+	rollback RollbackFunc
+	timeouts map[string]time.Duration
+	now      func() metav1.Time
 }
 
 // NewWorkflow returns a Workflow implementation.
-func NewWorkflow(app *oamcore.Application, applicator apply.Applicator) Workflow {
+// The rollback function is called when a step with `onFailure: rollback` fails, it can be nil
+// if the workflow doesn't support rollback.
+func NewWorkflow(app *oamcore.Application, cli client.Client, rollback RollbackFunc) Workflow {
 	return &workflow{
-		app:        app,
-		applicator: applicator,
+		app:      app,
+		cli:      cli,
+		rollback: rollback,
+		timeouts: map[string]time.Duration{},
+		now:      metav1.Now,
 	}
 }
 
-func (w *workflow) ExecuteSteps(ctx context.Context, rev string, objects []*unstructured.Unstructured) (bool, error) {
-	if w.app.Spec.Workflow == nil {
+func (w *workflow) ExecuteSteps(ctx context.Context, rev string, taskRunners []TaskRunner) (bool, error) {
+	if len(taskRunners) == 0 {
 		return true, nil
 	}
 
-	steps := w.app.Spec.Workflow.Steps
-	if len(steps) == 0 {
-		return true, nil
+	var d *dag
+	if w.app.Spec.Workflow.Mode == oamcore.WorkflowModeDAG {
+		var err error
+		if d, err = newDAG(w.app.Spec.Workflow.Steps); err != nil {
+			return false, errors.WithMessage(err, "invalid workflow")
+		}
+	}
+	for _, step := range w.app.Spec.Workflow.Steps {
+		if d == nil && len(step.DependsOn) != 0 {
+			return false, errors.Errorf("invalid workflow: dependsOn of step %s is only supported in dag mode", step.Name)
+		}
+		if step.Timeout != "" {
+			timeout, err := time.ParseDuration(step.Timeout)
+			if err != nil {
+				return false, errors.WithMessagef(err, "invalid workflow: invalid timeout of step %s", step.Name)
+			}
+			w.timeouts[step.Name] = timeout
+		}
+	}
+
+	wfStatus := w.app.Status.Workflow
+	if wfStatus == nil || wfStatus.AppRevision != rev {
+		// a new revision always executes the workflow from the first step
+		if wfStatus != nil && wfStatus.AppRevision != "" {
+			if err := deleteContext(ctx, w.cli, w.app.Namespace, wfStatus.AppRevision); err != nil {
+				return false, err
+			}
+		}
+		wfStatus = &common.WorkflowStatus{
+			AppRevision: rev,
+			Steps:       []common.WorkflowStepStatus{},
+		}
+		w.app.Status.Workflow = wfStatus
+	}
+	if err := w.applyOperation(ctx); err != nil {
+		return false, err
+	}
+	wfStatus = w.app.Status.Workflow
+	if wfStatus.Terminated {
+		w.app.Status.Phase = common.ApplicationWorkflowTerminated
+		return false, nil
+	}
+	if wfStatus.Suspend {
+		w.app.Status.Phase = common.ApplicationWorkflowSuspending
+		return false, nil
 	}
 
 	w.app.Status.Phase = common.ApplicationRunningWorkflow
 
-	w.app.Status.Workflow = &common.WorkflowStatus{
-		Steps: []common.WorkflowStepStatus{},
-	}
-	for i, step := range steps {
-		obj := objects[i].DeepCopy()
-		obj.SetName(step.Name)
-		obj.SetNamespace(w.app.Namespace)
-		obj.SetOwnerReferences([]metav1.OwnerReference{
-			*metav1.NewControllerRef(w.app, oamcore.ApplicationKindVersionKind),
-		})
-		err := w.applyWorkflowStep(ctx, obj, &types.WorkflowContext{
-			AppName:       w.app.Name,
-			AppRevision:   rev,
-			WorkflowIndex: i,
-			ResourceConfigMap: corev1.LocalObjectReference{
-				Name: rev,
-			},
-		})
-		if err != nil {
-			return false, err
-		}
+	wfCtx, err := LoadContext(ctx, w.cli, w.app, rev)
+	if err != nil {
+		return false, err
+	}
+	if d != nil {
+		return w.executeDAG(ctx, rev, taskRunners, wfCtx, d)
+	}
+	return w.executeInOrder(ctx, rev, taskRunners, wfCtx)
+}
 
-		status, err := w.syncWorkflowStatus(step, obj)
-		if err != nil {
-			return false, err
+// executeInOrder executes the steps one by one and stops at the first step which is not finished.
+func (w *workflow) executeInOrder(ctx context.Context, rev string, taskRunners []TaskRunner, wfCtx *Context) (bool, error) {
+	wfStatus := w.app.Status.Workflow
+	for i, runner := range taskRunners {
+		status := getStepStatus(wfStatus, runner.Name())
+		if status == nil || status.Phase == common.WorkflowStepPhaseRunning {
+			// steps are not executed again once finished in the same revision
+			newStatus, operation, err := w.executeStep(ctx, rev, i, runner, wfCtx)
+			setStepStatus(wfStatus, newStatus)
+			if err != nil {
+				return false, err
+			}
+			// outputs must be stored before the step is recorded as succeeded
+			if err := wfCtx.Commit(ctx); err != nil {
+				return false, err
+			}
+			if operation != nil && operation.Suspend {
+				wfStatus.Suspend = true
+				w.app.Status.Phase = common.ApplicationWorkflowSuspending
+				return false, nil
+			}
+			status = getStepStatus(wfStatus, runner.Name())
 		}
 
-		w.app.Status.Workflow.Steps = append(w.app.Status.Workflow.Steps, *status)
 		switch status.Phase {
-		case common.WorkflowStepPhaseSucceeded: // This one is done. Continue
+		case common.WorkflowStepPhaseSucceeded, common.WorkflowStepPhaseSkipped: // This one is done. Continue
 		case common.WorkflowStepPhaseRunning: // Need to retry shortly.
 			return false, nil
+		case common.WorkflowStepPhaseFailed:
+			if continued, err := w.handleFailure(ctx, w.app.Spec.Workflow.Steps[i]); !continued {
+				return false, err
+			}
 		default:
 			return true, nil
 		}
@@ -98,12 +169,255 @@ func (w *workflow) ExecuteSteps(ctx context.Context, rev string, objects []*unst
 	return true, nil // all steps done
 }
 
-func (w *workflow) applyWorkflowStep(ctx context.Context, obj *unstructured.Unstructured, wctx *types.WorkflowContext) error {
-	if err := addWorkflowContextToAnnotation(obj, wctx); err != nil {
-		return err
+type stepResult struct {
+	index     int
+	status    common.WorkflowStepStatus
+	operation *Operation
+	err       error
+}
+
+// executeDAG executes all steps whose dependencies have succeeded concurrently.
+// Once some steps succeed, the steps depending on them are executed in the same round of reconciliation.
+func (w *workflow) executeDAG(ctx context.Context, rev string, taskRunners []TaskRunner, wfCtx *Context, d *dag) (bool, error) {
+	wfStatus := w.app.Status.Workflow
+	executed := make(map[string]bool, len(taskRunners))
+	for {
+		var ready []int
+		for i, runner := range taskRunners {
+			name := runner.Name()
+			status := getStepStatus(wfStatus, name)
+			if executed[name] || (status != nil && status.Phase != common.WorkflowStepPhaseRunning) {
+				// steps which are finished or already executed in this round are skipped
+				continue
+			}
+			if d.Ready(name, w.stepDone) {
+				ready = append(ready, i)
+			}
+		}
+		if len(ready) == 0 {
+			break
+		}
+
+		results := make([]stepResult, len(ready))
+		var wg sync.WaitGroup
+		wg.Add(len(ready))
+		for j, i := range ready {
+			executed[taskRunners[i].Name()] = true
+			go func(j, i int) {
+				defer wg.Done()
+				status, operation, err := w.executeStep(ctx, rev, i, taskRunners[i], wfCtx)
+				results[j] = stepResult{index: i, status: status, operation: operation, err: err}
+			}(j, i)
+		}
+		wg.Wait()
+		// outputs must be stored before the steps are recorded as succeeded
+		if err := wfCtx.Commit(ctx); err != nil {
+			return false, err
+		}
+
+		var errs []error
+		var failed []int
+		var progressed, suspend bool
+		for _, result := range results {
+			setStepStatus(wfStatus, result.status)
+			if result.err != nil {
+				errs = append(errs, result.err)
+				continue
+			}
+			if result.operation != nil && result.operation.Suspend {
+				suspend = true
+			}
+			switch result.status.Phase {
+			case common.WorkflowStepPhaseRunning:
+			case common.WorkflowStepPhaseFailed:
+				failed = append(failed, result.index)
+				progressed = true
+			case common.WorkflowStepPhaseSucceeded, common.WorkflowStepPhaseSkipped:
+				progressed = true
+			default:
+				// the workflow is stopped by the step
+				return true, nil
+			}
+		}
+		if len(errs) != 0 {
+			return false, utilerrors.NewAggregate(errs)
+		}
+		for _, i := range failed {
+			if continued, err := w.handleFailure(ctx, w.app.Spec.Workflow.Steps[i]); !continued {
+				return false, err
+			}
+		}
+		if suspend {
+			wfStatus.Suspend = true
+			w.app.Status.Phase = common.ApplicationWorkflowSuspending
+			return false, nil
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	for _, runner := range taskRunners {
+		if !w.stepDone(runner.Name()) {
+			return false, nil
+		}
+	}
+	return true, nil // all steps done
+}
+
+// executeStep executes the step at the index of the workflow, and returns its new status.
+// A step which is not finished before its timeout fails, and a failed step keeps running
+// until it's retried with exponential backoff if it has retries left.
+func (w *workflow) executeStep(ctx context.Context, rev string, index int, runner TaskRunner, wfCtx *Context) (common.WorkflowStepStatus, *Operation, error) {
+	step := w.app.Spec.Workflow.Steps[index]
+	now := w.now()
+	status := common.WorkflowStepStatus{
+		Name:  runner.Name(),
+		Type:  step.Type,
+		Phase: common.WorkflowStepPhaseRunning,
+	}
+	if prev := getStepStatus(w.app.Status.Workflow, runner.Name()); prev != nil {
+		status = *prev.DeepCopy()
+	} else if step.If != "" {
+		// the condition is only evaluated before the step is executed for the first time
+		ok, err := w.evalCondition(step, rev, wfCtx)
+		if err != nil {
+			status.Phase = common.WorkflowStepPhaseFailed
+			status.Message = err.Error()
+			status.LastError = status.Message
+			status.FinishedAt = &now
+			return status, nil, nil
+		}
+		if !ok {
+			status.Phase = common.WorkflowStepPhaseSkipped
+			status.Message = "condition is false"
+			status.FinishedAt = &now
+			return status, nil, nil
+		}
+	}
+	if status.StartedAt == nil {
+		status.StartedAt = &now
+		status.Attempts = 1
 	}
 
-	return w.applicator.Apply(ctx, obj)
+	if timeout := w.timeouts[step.Name]; timeout > 0 && now.Sub(status.StartedAt.Time) > timeout {
+		status.Phase = common.WorkflowStepPhaseFailed
+		status.Message = fmt.Sprintf("step is not finished in %s", timeout)
+		status.LastError = status.Message
+		status.FinishedAt = &now
+		return status, nil, nil
+	}
+	if status.FinishedAt != nil {
+		// the last attempt failed, wait for the backoff before retrying
+		if now.Time.Before(status.FinishedAt.Add(retryBackoff(status.Attempts))) {
+			return status, nil, nil
+		}
+		status.Attempts++
+		status.FinishedAt = nil
+	}
+
+	result, operation, err := runner.Run(ctx, &types.WorkflowContext{
+		AppName:       w.app.Name,
+		AppRevision:   rev,
+		WorkflowIndex: index,
+		ResourceConfigMap: corev1.LocalObjectReference{
+			Name: rev,
+		},
+		ContextConfigMap: corev1.LocalObjectReference{
+			Name: GenerateContextName(rev),
+		},
+	}, wfCtx)
+	if err != nil {
+		status.LastError = err.Error()
+		return status, nil, err
+	}
+	status.Phase = result.Phase
+	status.Message = result.Message
+	status.ResourceRef = result.ResourceRef
+	status.Outputs = result.Outputs
+
+	switch result.Phase {
+	case common.WorkflowStepPhaseSucceeded, common.WorkflowStepPhaseStopped:
+		status.FinishedAt = &now
+	case common.WorkflowStepPhaseFailed:
+		status.LastError = result.Message
+		status.FinishedAt = &now
+		if status.Attempts <= step.Retries {
+			status.Phase = common.WorkflowStepPhaseRunning
+			status.Message = fmt.Sprintf("attempt %d failed, retry in %s", status.Attempts, retryBackoff(status.Attempts))
+		}
+	default:
+	}
+	return status, operation, nil
+}
+
+// handleFailure applies the failure policy of the failed step. It returns true if the workflow continues.
+func (w *workflow) handleFailure(ctx context.Context, step oamcore.WorkflowStep) (bool, error) {
+	switch step.OnFailure {
+	case oamcore.WorkflowStepFailureContinue:
+		return true, nil
+	case oamcore.WorkflowStepFailureRollback:
+		if w.rollback == nil {
+			return false, errors.Errorf("rollback is not supported by workflow step %s", step.Name)
+		}
+		if err := w.rollback(ctx); err != nil {
+			return false, errors.WithMessagef(err, "rollback for failed workflow step %s", step.Name)
+		}
+	default:
+	}
+	w.app.Status.Workflow.Terminated = true
+	w.app.Status.Phase = common.ApplicationWorkflowTerminated
+	return false, nil
+}
+
+// stepDone checks whether the step is succeeded or skipped, or failed but the workflow continues.
+func (w *workflow) stepDone(name string) bool {
+	status := getStepStatus(w.app.Status.Workflow, name)
+	if status == nil {
+		return false
+	}
+	if status.Phase == common.WorkflowStepPhaseSucceeded || status.Phase == common.WorkflowStepPhaseSkipped {
+		return true
+	}
+	if status.Phase != common.WorkflowStepPhaseFailed {
+		return false
+	}
+	for _, step := range w.app.Spec.Workflow.Steps {
+		if step.Name == name {
+			return step.OnFailure == oamcore.WorkflowStepFailureContinue
+		}
+	}
+	return false
+}
+
+// retryBackoff returns the time to wait before retrying the step which failed the given attempts.
+func retryBackoff(attempts int) time.Duration {
+	backoff := retryBackoffBase
+	for i := 1; i < attempts && backoff < retryBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > retryBackoffMax {
+		backoff = retryBackoffMax
+	}
+	return backoff
+}
+
+// getStepStatus returns the status of the step with the name, or nil if it's not executed yet.
+func getStepStatus(wfStatus *common.WorkflowStatus, name string) *common.WorkflowStepStatus {
+	for i := range wfStatus.Steps {
+		if wfStatus.Steps[i].Name == name {
+			return &wfStatus.Steps[i]
+		}
+	}
+	return nil
+}
+
+func setStepStatus(wfStatus *common.WorkflowStatus, status common.WorkflowStepStatus) {
+	if s := getStepStatus(wfStatus, status.Name); s != nil {
+		*s = status
+		return
+	}
+	wfStatus.Steps = append(wfStatus.Steps, status)
 }
 
 func addWorkflowContextToAnnotation(obj *unstructured.Unstructured, wc *types.WorkflowContext) error {
@@ -133,47 +447,34 @@ const (
 	CondStatusTrue = "True"
 )
 
-func (w *workflow) syncWorkflowStatus(step oamcore.WorkflowStep, obj *unstructured.Unstructured) (*common.WorkflowStepStatus, error) {
-	status := &common.WorkflowStepStatus{
-		Name: step.Name,
-		Type: step.Type,
-		ResourceRef: runtimev1alpha1.TypedReference{
-			APIVersion: obj.GetAPIVersion(),
-			Kind:       obj.GetKind(),
-			Name:       obj.GetName(),
-			UID:        obj.GetUID(),
-		},
-	}
-
+// getProgressPhase computes the phase of a step from the `workflow-progress` condition of the applied object.
+func getProgressPhase(obj *unstructured.Unstructured) (common.WorkflowStepPhase, error) {
 	cond, found, err := utils.GetUnstructuredObjectStatusCondition(obj, CondTypeWorkflowFinish)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	if !found || cond.Status != CondStatusTrue {
-		status.Phase = common.WorkflowStepPhaseRunning
-		return status, nil
+		return common.WorkflowStepPhaseRunning, nil
 	}
 
 	switch cond.Reason {
 	case CondReasonSucceeded:
 		observedG, err := parseGeneration(cond.Message)
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 		if observedG != obj.GetGeneration() {
-			status.Phase = common.WorkflowStepPhaseRunning
-		} else {
-			status.Phase = common.WorkflowStepPhaseSucceeded
+			return common.WorkflowStepPhaseRunning, nil
 		}
+		return common.WorkflowStepPhaseSucceeded, nil
 	case CondReasonFailed:
-		status.Phase = common.WorkflowStepPhaseFailed
+		return common.WorkflowStepPhaseFailed, nil
 	case CondReasonStopped:
-		status.Phase = common.WorkflowStepPhaseStopped
+		return common.WorkflowStepPhaseStopped, nil
 	default:
-		status.Phase = common.WorkflowStepPhaseRunning
+		return common.WorkflowStepPhaseRunning, nil
 	}
-	return status, nil
 }
 
 func parseGeneration(message string) (int64, error) {
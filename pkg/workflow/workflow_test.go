@@ -20,13 +20,22 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
 	oamcore "github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/cue/packages"
 	"github.com/oam-dev/kubevela/pkg/utils/apply"
 )
 
@@ -168,7 +177,7 @@ func TestExecuteSteps(t *testing.T) {
 	}}
 	for _, tc := range testcases {
 		t.Logf("%s", tc.desc)
-		done, err := NewWorkflow(tc.app, mockApplicator()).ExecuteSteps(context.Background(), "app-v1", tc.steps)
+		done, err := NewWorkflow(tc.app, newFakeClient(), nil).ExecuteSteps(context.Background(), "app-v1", mockTaskRunners(tc.app, tc.steps))
 		if err != nil {
 			assert.Equal(t, tc.want.err, err)
 			continue
@@ -177,13 +186,610 @@ func TestExecuteSteps(t *testing.T) {
 	}
 }
 
+func TestExecuteStepsInOrder(t *testing.T) {
+	app := &oamcore.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Spec: oamcore.ApplicationSpec{
+			Workflow: &oamcore.Workflow{
+				Steps: []oamcore.WorkflowStep{{
+					Name: "s1",
+					Type: "test",
+				}, {
+					Name: "s2",
+					Type: "test",
+				}},
+			},
+		},
+	}
+	s1 := &fakeTaskRunner{name: "s1", phase: common.WorkflowStepPhaseRunning}
+	s2 := &fakeTaskRunner{name: "s2", phase: common.WorkflowStepPhaseSucceeded}
+	runners := []TaskRunner{s1, s2}
+	cli := newFakeClient()
+
+	done, err := NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, false, done)
+	assert.Equal(t, common.ApplicationRunningWorkflow, app.Status.Phase)
+	assert.Equal(t, 1, len(app.Status.Workflow.Steps))
+	assert.Equal(t, 0, s2.runs)
+
+	s1.phase = common.WorkflowStepPhaseSucceeded
+	done, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, true, done)
+	assert.Equal(t, 2, len(app.Status.Workflow.Steps))
+
+	// succeeded steps are not executed again in the same revision
+	done, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, true, done)
+	assert.Equal(t, 2, s1.runs)
+	assert.Equal(t, 1, s2.runs)
+
+	// a new revision executes the workflow from the first step
+	done, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v2", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, true, done)
+	assert.Equal(t, "app-v2", app.Status.Workflow.AppRevision)
+	assert.Equal(t, 3, s1.runs)
+	assert.Equal(t, 2, s2.runs)
+}
+
+func TestExecuteStepsInDAG(t *testing.T) {
+	app := &oamcore.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Spec: oamcore.ApplicationSpec{
+			Workflow: &oamcore.Workflow{
+				Mode: oamcore.WorkflowModeDAG,
+				Steps: []oamcore.WorkflowStep{{
+					Name:      "c",
+					Type:      "test",
+					DependsOn: []string{"a", "b"},
+				}, {
+					Name: "a",
+					Type: "test",
+				}, {
+					Name: "b",
+					Type: "test",
+				}},
+			},
+		},
+	}
+	a := &fakeTaskRunner{name: "a", phase: common.WorkflowStepPhaseSucceeded}
+	b := &fakeTaskRunner{name: "b", phase: common.WorkflowStepPhaseRunning}
+	c := &fakeTaskRunner{name: "c", phase: common.WorkflowStepPhaseSucceeded}
+	runners := []TaskRunner{c, a, b}
+	cli := newFakeClient()
+
+	// independent steps are executed in the same reconciliation
+	done, err := NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, false, done)
+	assert.Equal(t, 1, a.runs)
+	assert.Equal(t, 1, b.runs)
+	assert.Equal(t, 0, c.runs)
+
+	// the step is executed once all steps it depends on have succeeded
+	b.phase = common.WorkflowStepPhaseSucceeded
+	done, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, true, done)
+	assert.Equal(t, 1, a.runs)
+	assert.Equal(t, 2, b.runs)
+	assert.Equal(t, 1, c.runs)
+	assert.Equal(t, 3, len(app.Status.Workflow.Steps))
+
+	// a failed step terminates the workflow
+	app.Status.Workflow = nil
+	a.phase = common.WorkflowStepPhaseFailed
+	done, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, false, done)
+	assert.Equal(t, true, app.Status.Workflow.Terminated)
+	assert.Equal(t, 1, c.runs)
+
+	// the steps depending on a failed step continue if its failure policy is continue
+	app.Status.Workflow = nil
+	app.Spec.Workflow.Steps[1].OnFailure = oamcore.WorkflowStepFailureContinue
+	done, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, true, done)
+	assert.Equal(t, 2, c.runs)
+	app.Spec.Workflow.Steps[1].OnFailure = ""
+
+	// dependency cycles are reported before any step is executed
+	app.Status.Workflow = nil
+	app.Spec.Workflow.Steps[1].DependsOn = []string{"c"}
+	_, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.EqualError(t, err, "invalid workflow: dependency cycle in workflow steps: c -> a -> c")
+	assert.Equal(t, 3, a.runs)
+
+	// dependsOn is not allowed in step mode
+	app.Spec.Workflow.Mode = oamcore.WorkflowModeStep
+	_, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.Error(t, err)
+}
+
+func TestStepFailurePolicy(t *testing.T) {
+	app := &oamcore.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+		},
+		Spec: oamcore.ApplicationSpec{
+			Workflow: &oamcore.Workflow{
+				Steps: []oamcore.WorkflowStep{{
+					Name:    "s1",
+					Type:    "test",
+					Timeout: "1m",
+					Retries: 2,
+				}, {
+					Name: "s2",
+					Type: "test",
+				}},
+			},
+		},
+	}
+	s1 := &fakeTaskRunner{name: "s1", phase: common.WorkflowStepPhaseFailed}
+	s2 := &fakeTaskRunner{name: "s2", phase: common.WorkflowStepPhaseSucceeded}
+	runners := []TaskRunner{s1, s2}
+	cli := newFakeClient()
+	start := time.Now()
+	now := start
+	var rollbacks int
+	execute := func() (bool, error) {
+		w := NewWorkflow(app, cli, func(ctx context.Context) error {
+			rollbacks++
+			return nil
+		}).(*workflow)
+		w.now = func() metav1.Time { return metav1.NewTime(now) }
+		return w.ExecuteSteps(context.Background(), "app-v1", runners)
+	}
+
+	// a failed step is retried after the backoff
+	done, err := execute()
+	assert.NoError(t, err)
+	assert.Equal(t, false, done)
+	status := app.Status.Workflow.Steps[0]
+	assert.Equal(t, common.WorkflowStepPhaseRunning, status.Phase)
+	assert.Equal(t, 1, status.Attempts)
+	assert.Equal(t, "attempt 1 failed, retry in 5s", status.Message)
+	now = start.Add(time.Second)
+	_, err = execute()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, s1.runs)
+	now = start.Add(6 * time.Second)
+	_, err = execute()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, s1.runs)
+	assert.Equal(t, 2, app.Status.Workflow.Steps[0].Attempts)
+
+	// the backoff doubles for every retry
+	now = start.Add(12 * time.Second)
+	_, err = execute()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, s1.runs)
+	now = start.Add(17 * time.Second)
+	done, err = execute()
+	assert.NoError(t, err)
+	assert.Equal(t, false, done)
+	assert.Equal(t, 3, s1.runs)
+
+	// the workflow is terminated once the step runs out of retries
+	status = app.Status.Workflow.Steps[0]
+	assert.Equal(t, common.WorkflowStepPhaseFailed, status.Phase)
+	assert.Equal(t, 3, status.Attempts)
+	assert.NotNil(t, status.FinishedAt)
+	assert.Equal(t, true, app.Status.Workflow.Terminated)
+	assert.Equal(t, 0, s2.runs)
+
+	// the step fails if it's not finished before the timeout
+	app.Status.Workflow = nil
+	s1.phase = common.WorkflowStepPhaseRunning
+	now = start
+	_, err = execute()
+	assert.NoError(t, err)
+	now = start.Add(2 * time.Minute)
+	done, err = execute()
+	assert.NoError(t, err)
+	assert.Equal(t, false, done)
+	assert.Equal(t, "step is not finished in 1m0s", app.Status.Workflow.Steps[0].LastError)
+	assert.Equal(t, true, app.Status.Workflow.Terminated)
+
+	// the workflow continues with the next step
+	app.Status.Workflow = nil
+	app.Spec.Workflow.Steps[0].Retries = 0
+	app.Spec.Workflow.Steps[0].OnFailure = oamcore.WorkflowStepFailureContinue
+	s1.phase = common.WorkflowStepPhaseFailed
+	done, err = execute()
+	assert.NoError(t, err)
+	assert.Equal(t, true, done)
+	assert.Equal(t, 1, s2.runs)
+
+	// the application is rolled back before the workflow is terminated
+	app.Status.Workflow = nil
+	app.Spec.Workflow.Steps[0].OnFailure = oamcore.WorkflowStepFailureRollback
+	done, err = execute()
+	assert.NoError(t, err)
+	assert.Equal(t, false, done)
+	assert.Equal(t, 1, rollbacks)
+	assert.Equal(t, true, app.Status.Workflow.Terminated)
+	assert.Equal(t, 1, s2.runs)
+
+	// an invalid timeout is rejected
+	app.Status.Workflow = nil
+	app.Spec.Workflow.Steps[0].Timeout = "1 minute"
+	_, err = execute()
+	assert.Error(t, err)
+}
+
+func TestConditionalStep(t *testing.T) {
+	app := &oamcore.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+		},
+		Spec: oamcore.ApplicationSpec{
+			Workflow: &oamcore.Workflow{
+				Steps: []oamcore.WorkflowStep{{
+					Name:      "deploy",
+					Type:      "test",
+					OnFailure: oamcore.WorkflowStepFailureContinue,
+				}, {
+					Name: "notify",
+					Type: "test",
+					If:   `status.deploy.phase == "failed" && context.appRevision == "app-v1"`,
+				}, {
+					Name: "verify",
+					Type: "test",
+					If:   `outputs.verify == true`,
+				}},
+			},
+		},
+	}
+	deploy := &fakeTaskRunner{name: "deploy", phase: common.WorkflowStepPhaseFailed}
+	notify := &fakeTaskRunner{name: "notify", phase: common.WorkflowStepPhaseSucceeded}
+	verify := &fakeTaskRunner{name: "verify", phase: common.WorkflowStepPhaseSucceeded}
+	runners := []TaskRunner{deploy, notify, verify}
+	cli := newFakeClient()
+	setVar := func(rev string, v bool) {
+		wfCtx, err := LoadContext(context.Background(), cli, app, rev)
+		assert.NoError(t, err)
+		assert.NoError(t, wfCtx.SetVar("verify", v))
+		assert.NoError(t, wfCtx.Commit(context.Background()))
+	}
+
+	setVar("app-v1", false)
+	done, err := NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, true, done)
+	assert.Equal(t, 1, notify.runs)
+	assert.Equal(t, 0, verify.runs)
+	assert.Equal(t, common.WorkflowStepPhaseSkipped, getStepStatus(app.Status.Workflow, "verify").Phase)
+
+	// the condition can refer to the values exported by previous steps
+	setVar("app-v2", true)
+	done, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v2", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, true, done)
+	assert.Equal(t, 1, notify.runs)
+	assert.Equal(t, 1, verify.runs)
+
+	// the step whose condition can't be evaluated to a bool fails
+	app.Spec.Workflow.Steps[2].If = `status.deploy.phase`
+	done, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v3", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, false, done)
+	assert.Equal(t, common.WorkflowStepPhaseFailed, getStepStatus(app.Status.Workflow, "verify").Phase)
+	assert.Equal(t, 1, verify.runs)
+}
+
+func TestSuspendStep(t *testing.T) {
+	app := &oamcore.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+		},
+		Spec: oamcore.ApplicationSpec{
+			Workflow: &oamcore.Workflow{
+				Steps: []oamcore.WorkflowStep{{
+					Name: "approve",
+					Type: types.WorkflowStepTypeSuspend,
+				}, {
+					Name: "deploy",
+					Type: "test",
+				}},
+			},
+		},
+	}
+	suspend, err := NewTaskDiscover(app, mockApplicator(), nil, nil).GetTaskRunner(app.Spec.Workflow.Steps[0], "", nil)
+	assert.NoError(t, err)
+	deploy := &fakeTaskRunner{name: "deploy", phase: common.WorkflowStepPhaseSucceeded}
+	runners := []TaskRunner{suspend, deploy}
+	cli := newFakeClient()
+
+	done, err := NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, false, done)
+	assert.Equal(t, true, app.Status.Workflow.Suspend)
+	assert.Equal(t, common.ApplicationWorkflowSuspending, app.Status.Phase)
+	assert.Equal(t, 0, deploy.runs)
+
+	// nothing is executed until the workflow is resumed
+	done, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, false, done)
+	assert.Equal(t, 0, deploy.runs)
+
+	// the workflow continues from the step after the suspend step once resumed
+	app.Status.Workflow.Suspend = false
+	done, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, true, done)
+	assert.Equal(t, 1, deploy.runs)
+
+	// a terminated workflow is not executed
+	app.Status.Workflow = &common.WorkflowStatus{AppRevision: "app-v1", Terminated: true}
+	done, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, false, done)
+	assert.Equal(t, common.ApplicationWorkflowTerminated, app.Status.Phase)
+	assert.Equal(t, 1, deploy.runs)
+}
+
+func TestTemplateTask(t *testing.T) {
+	app := &oamcore.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+		},
+	}
+	wctx := &types.WorkflowContext{AppName: "app", AppRevision: "app-v1"}
+
+	testcases := map[string]struct {
+		template  string
+		params    map[string]interface{}
+		phase     common.WorkflowStepPhase
+		suspend   bool
+		applied   []string
+		withError bool
+	}{
+		"health of applied output": {
+			template: `
+output: {
+	apiVersion: "v1"
+	kind:       "ConfigMap"
+	data: key: parameter.value
+}
+health: context.output.data.key == "ready"
+parameter: value: string
+`,
+			params:  map[string]interface{}{"value": "ready"},
+			phase:   common.WorkflowStepPhaseSucceeded,
+			applied: []string{"step"},
+		},
+		"unhealthy outputs keep running": {
+			template: `
+outputs: cm: {
+	apiVersion: "v1"
+	kind:       "ConfigMap"
+	metadata: name: "cm-\(context.appName)"
+}
+health: context.outputs.cm.status.ready
+`,
+			phase:   common.WorkflowStepPhaseRunning,
+			applied: []string{"cm-app"},
+		},
+		"suspend the workflow": {
+			template: `suspend: true`,
+			phase:    common.WorkflowStepPhaseSucceeded,
+			suspend:  true,
+		},
+		"nothing to apply": {
+			template: `parameter: {}`,
+			phase:    common.WorkflowStepPhaseSucceeded,
+		},
+		"invalid template fails the step": {
+			template: `output: parameter.missing`,
+			phase:    common.WorkflowStepPhaseFailed,
+		},
+	}
+	for name, tc := range testcases {
+		applicator := &testmockApplicator{}
+		runner, err := NewTaskDiscover(app, applicator, &packages.PackageDiscover{}, nil).
+			GetTaskRunner(oamcore.WorkflowStep{Name: "step", Type: "test"}, tc.template, tc.params)
+		assert.NoError(t, err, name)
+		status, operation, err := runner.Run(context.Background(), wctx, newTestContext(app))
+		assert.NoError(t, err, name)
+		assert.Equal(t, tc.phase, status.Phase, name)
+		assert.Equal(t, tc.suspend, operation != nil && operation.Suspend, name)
+		assert.Equal(t, tc.applied, applicator.applied, name)
+	}
+}
+
+func TestApplyComponentsTask(t *testing.T) {
+	var applied []string
+	discover := NewTaskDiscover(&oamcore.Application{}, mockApplicator(), nil, func(ctx context.Context, components ...string) error {
+		applied = append(applied, components...)
+		return nil
+	})
+
+	_, err := discover.GetTaskRunner(oamcore.WorkflowStep{Name: "deploy", Type: types.WorkflowStepTypeApplyComponent}, "", nil)
+	assert.Error(t, err)
+
+	runner, err := discover.GetTaskRunner(oamcore.WorkflowStep{Name: "deploy", Type: types.WorkflowStepTypeApplyComponent}, "",
+		map[string]interface{}{"component": "frontend"})
+	assert.NoError(t, err)
+	status, _, err := runner.Run(context.Background(), &types.WorkflowContext{}, newTestContext(&oamcore.Application{}))
+	assert.NoError(t, err)
+	assert.Equal(t, common.WorkflowStepPhaseSucceeded, status.Phase)
+	assert.Equal(t, []string{"frontend"}, applied)
+}
+
+func TestDataPassing(t *testing.T) {
+	app := &oamcore.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+		},
+		Spec: oamcore.ApplicationSpec{
+			Workflow: &oamcore.Workflow{
+				Steps: []oamcore.WorkflowStep{{
+					Name: "db",
+					Type: "create-db",
+					Outputs: []oamcore.WorkflowStepOutput{{
+						Name:      "db-endpoint",
+						ValueFrom: `"\(context.output.data.host):\(context.output.data.port)"`,
+					}},
+				}, {
+					Name: "migrate",
+					Type: "migrate",
+					Inputs: []oamcore.WorkflowStepInput{{
+						From:         "db-endpoint",
+						ParameterKey: "db.endpoint",
+					}},
+				}},
+			},
+		},
+	}
+	templates := []string{`
+output: {
+	apiVersion: "v1"
+	kind:       "ConfigMap"
+	data: {
+		host: "mysql"
+		port: "3306"
+	}
+}
+health: true
+`, `
+output: {
+	apiVersion: "v1"
+	kind:       "ConfigMap"
+	data: endpoint: parameter.db.endpoint
+}
+health: context.output.data.endpoint == "mysql:3306"
+parameter: {
+	db: endpoint: string
+	user: string
+}
+`}
+	cli := newFakeClient()
+	applicator := &testmockApplicator{}
+	discover := NewTaskDiscover(app, applicator, &packages.PackageDiscover{}, nil)
+	var runners []TaskRunner
+	for i, step := range app.Spec.Workflow.Steps {
+		runner, err := discover.GetTaskRunner(step, templates[i], map[string]interface{}{"user": "admin"})
+		assert.NoError(t, err)
+		runners = append(runners, runner)
+	}
+
+	done, err := NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, true, done)
+	assert.Equal(t, common.WorkflowStepPhaseSucceeded, app.Status.Workflow.Steps[1].Phase, app.Status.Workflow.Steps[1].Message)
+	assert.Equal(t, []string{"db", "migrate"}, applicator.applied)
+	assert.Equal(t, map[string]string{"db-endpoint": `"mysql:3306"`}, app.Status.Workflow.Steps[0].Outputs)
+
+	// the outputs are persisted in the workflow context of the revision
+	wfCtx, err := LoadContext(context.Background(), cli, app, "app-v1")
+	assert.NoError(t, err)
+	v, ok, err := wfCtx.GetVar("db-endpoint")
+	assert.NoError(t, err)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "mysql:3306", v)
+
+	// inputs which are not exported fail the step
+	app.Status.Workflow.Steps = app.Status.Workflow.Steps[:1]
+	done, err = NewWorkflow(app, newFakeClient(), nil).ExecuteSteps(context.Background(), "app-v1", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, false, done)
+	assert.Equal(t, common.WorkflowStepPhaseFailed, app.Status.Workflow.Steps[1].Phase)
+	assert.Equal(t, true, app.Status.Workflow.Terminated)
+
+	// the workflow context of the previous revision is deleted when a new revision is executed
+	done, err = NewWorkflow(app, cli, nil).ExecuteSteps(context.Background(), "app-v2", runners)
+	assert.NoError(t, err)
+	assert.Equal(t, true, done)
+	err = cli.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: GenerateContextName("app-v1")}, &corev1.ConfigMap{})
+	assert.Equal(t, true, apierrors.IsNotFound(err))
+}
+
+func TestBuiltinStepWithOutputs(t *testing.T) {
+	discover := NewTaskDiscover(&oamcore.Application{}, mockApplicator(), nil, nil)
+	_, err := discover.GetTaskRunner(oamcore.WorkflowStep{
+		Name:    "deploy",
+		Type:    types.WorkflowStepTypeApplyApplication,
+		Outputs: []oamcore.WorkflowStepOutput{{Name: "a", ValueFrom: "b"}},
+	}, "", nil)
+	assert.Error(t, err)
+}
+
+type fakeTaskRunner struct {
+	name  string
+	phase common.WorkflowStepPhase
+	runs  int
+}
+
+func (f *fakeTaskRunner) Name() string {
+	return f.name
+}
+
+func (f *fakeTaskRunner) Run(ctx context.Context, wctx *types.WorkflowContext, wfCtx *Context) (common.WorkflowStepStatus, *Operation, error) {
+	f.runs++
+	return common.WorkflowStepStatus{Name: f.name, Phase: f.phase}, nil, nil
+}
+
+// mockTaskRunners generates task runners whose templates output the given objects.
+func mockTaskRunners(app *oamcore.Application, objects []*unstructured.Unstructured) []TaskRunner {
+	discover := NewTaskDiscover(app, mockApplicator(), &packages.PackageDiscover{}, nil)
+	var runners []TaskRunner
+	for i, step := range app.Spec.Workflow.Steps {
+		b, err := json.Marshal(objects[i].Object)
+		if err != nil {
+			panic(err)
+		}
+		runner, err := discover.GetTaskRunner(step, "output: "+string(b), nil)
+		if err != nil {
+			panic(err)
+		}
+		runners = append(runners, runner)
+	}
+	return runners
+}
+
 type testmockApplicator struct {
+	applied []string
 }
 
 func (t *testmockApplicator) Apply(ctx context.Context, object runtime.Object, option ...apply.ApplyOption) error {
+	if obj, ok := object.(metav1.Object); ok {
+		t.applied = append(t.applied, obj.GetName())
+	}
 	return nil
 }
 
+func newFakeClient(objs ...runtime.Object) client.Client {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	_ = oamcore.SchemeBuilder.AddToScheme(s)
+	return fake.NewFakeClientWithScheme(s, objs...)
+}
+
+func newTestContext(app *oamcore.Application) *Context {
+	wfCtx, err := LoadContext(context.Background(), newFakeClient(), app, "app-v1")
+	if err != nil {
+		panic(err)
+	}
+	return wfCtx
+}
+
 func mockApplicator() apply.Applicator {
 	return &testmockApplicator{}
 }
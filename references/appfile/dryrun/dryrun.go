@@ -63,6 +63,9 @@ func (d *Option) ExecuteDryRun(ctx context.Context, app *v1beta1.Application) ([
 	if err != nil {
 		return nil, errors.WithMessage(err, "cannot generate appFile from application")
 	}
+	if err := parser.ValidateTraits(appFile); err != nil {
+		return nil, errors.WithMessage(err, "invalid traits")
+	}
 	comps, err := appFile.GenerateComponentManifests()
 	if err != nil {
 		return nil, errors.WithMessage(err, "cannot generate AppConfig and Components")
@@ -79,6 +79,8 @@ func NewCommand() *cobra.Command {
 		NewListCommand(commandArgs, ioStream),
 		NewDeleteCommand(commandArgs, ioStream),
 		NewAppStatusCommand(commandArgs, ioStream),
+		NewWorkflowCommand(commandArgs, ioStream),
+		NewRolloutCommand(commandArgs, ioStream),
 		NewExecCommand(commandArgs, ioStream),
 		NewPortForwardCommand(commandArgs, ioStream),
 		NewLogsCommand(commandArgs, ioStream),
@@ -92,9 +92,12 @@ func printApplicationList(ctx context.Context, c client.Reader, namespace string
 			}
 			var healthy, status string
 			if len(a.Status.Services) > idx {
-				if a.Status.Services[idx].Healthy {
+				switch {
+				case a.Status.Services[idx].HealthPhase != "":
+					healthy = string(a.Status.Services[idx].HealthPhase)
+				case a.Status.Services[idx].Healthy:
 					healthy = "healthy"
-				} else {
+				default:
 					healthy = "unhealthy"
 				}
 				status = a.Status.Services[idx].Message
@@ -0,0 +1,131 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os/user"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/controller/core.oam.dev/v1alpha2/applicationrollout"
+	"github.com/oam-dev/kubevela/pkg/utils/common"
+	cmdutil "github.com/oam-dev/kubevela/pkg/utils/util"
+)
+
+// NewRolloutCommand creates `rollout` command
+func NewRolloutCommand(c common.Args, ioStreams cmdutil.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollout",
+		Short: "Operate the rollout of an application",
+		Long: "Pause, resume the rollout of an application or approve its batches. The AppRollout with the name is " +
+			"operated, or the rollout plan of the application if there is no such AppRollout.",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return c.SetConfig()
+		},
+		Annotations: map[string]string{
+			types.TagCommandType: types.TypeApp,
+		},
+	}
+	cmd.SetOut(ioStreams.Out)
+	cmd.AddCommand(
+		newRolloutOperationCommand(c, ioStreams, "pause", "Pause the rollout of an application before the next batch",
+			"paused", applicationrollout.PauseRollout),
+		newRolloutOperationCommand(c, ioStreams, "resume", "Resume the paused rollout of an application",
+			"resumed", applicationrollout.ResumeRollout),
+		newRolloutApproveCommand(c, ioStreams),
+	)
+	return cmd
+}
+
+type rolloutOperation func(ctx context.Context, cli client.Client, namespace, name string) error
+
+func newRolloutOperationCommand(c common.Args, ioStreams cmdutil.IOStreams, name, short, done string, operate rolloutOperation) *cobra.Command {
+	return &cobra.Command{
+		Use:     fmt.Sprintf("%s APP_NAME", name),
+		Short:   short,
+		Long:    short,
+		Example: fmt.Sprintf("vela rollout %s frontend", name),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("must specify name for the app")
+			}
+			env, err := GetEnv(cmd)
+			if err != nil {
+				return err
+			}
+			newClient, err := c.GetClient()
+			if err != nil {
+				return err
+			}
+			if err := operate(context.Background(), newClient, env.Namespace, args[0]); err != nil {
+				return err
+			}
+			ioStreams.Infof("Rollout of application %s %s\n", args[0], done)
+			return nil
+		},
+	}
+}
+
+func newRolloutApproveCommand(c common.Args, ioStreams cmdutil.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "approve APP_NAME",
+		Short: "Approve the batches of a rollout to move on",
+		Long: "Approve the batches of a rollout up to the given one to move on, the batches which require approval " +
+			"wait until they are approved. The approval is recorded in the rollout status.",
+		Example: "vela rollout approve frontend --batch 1",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("must specify name for the app")
+			}
+			env, err := GetEnv(cmd)
+			if err != nil {
+				return err
+			}
+			batch, err := cmd.Flags().GetInt32("batch")
+			if err != nil {
+				return err
+			}
+			approver, err := cmd.Flags().GetString("approver")
+			if err != nil {
+				return err
+			}
+			if approver == "" {
+				if u, err := user.Current(); err == nil {
+					approver = u.Username
+				}
+			}
+			newClient, err := c.GetClient()
+			if err != nil {
+				return err
+			}
+			if err := applicationrollout.ApproveRolloutBatch(context.Background(), newClient, env.Namespace, args[0],
+				batch, approver); err != nil {
+				return err
+			}
+			ioStreams.Infof("Batch %d of the rollout of application %s approved\n", batch, args[0])
+			return nil
+		},
+	}
+	cmd.Flags().Int32P("batch", "b", 0, "approve the batches up to this one, it starts from 0")
+	cmd.Flags().String("approver", "", "who approves the batches, default is the current user")
+	return cmd
+}
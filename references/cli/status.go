@@ -54,6 +54,8 @@ const (
 	HealthStatusUnhealthy = v1alpha2.StatusUnhealthy
 	// HealthStatusUnknown represents unknown status.
 	HealthStatusUnknown = v1alpha2.StatusUnknown
+	// HealthStatusProgressing means the component is on the way to be healthy
+	HealthStatusProgressing HealthStatus = "PROGRESSING"
 )
 
 // WorkloadHealthCondition holds health status of any resource
@@ -95,7 +97,8 @@ func NewAppStatusCommand(c common.Args, ioStreams cmdutil.IOStreams) *cobra.Comm
 		Use:     "status APP_NAME",
 		Short:   "Show status of an application",
 		Long:    "Show status of an application, including workloads and traits of each service.",
-		Example: `vela status APP_NAME`,
+		Example: `vela status APP_NAME
+vela status APP_NAME --drift`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			return c.SetConfig()
 		},
@@ -122,6 +125,7 @@ func NewAppStatusCommand(c common.Args, ioStreams cmdutil.IOStreams) *cobra.Comm
 		},
 	}
 	cmd.Flags().StringP("svc", "s", "", "service name")
+	cmd.Flags().Bool("drift", false, "show the resources changed outside of the application and the changed fields")
 	cmd.SetOut(ioStreams.Out)
 	return cmd
 }
@@ -140,10 +144,46 @@ func printAppStatus(ctx context.Context, c client.Client, ioStreams cmdutil.IOSt
 	table.AddRow("  Created at:", app.CreationTimestamp.String())
 	cmd.Printf("%s\n\n", table.String())
 
+	if showDrift, _ := cmd.Flags().GetBool("drift"); showDrift {
+		remoteApp, err := loadRemoteApplication(c, namespace, appName)
+		if err != nil {
+			return err
+		}
+		printAppDrift(cmd, remoteApp)
+		return nil
+	}
+
 	cmd.Printf("Services:\n\n")
 	return loopCheckStatus(ctx, c, ioStreams, appName, env)
 }
 
+// printAppDrift prints the drifted resources found by the latest drift detection of the application.
+func printAppDrift(cmd *cobra.Command, app *v1beta1.Application) {
+	cmd.Printf("Drift:\n\n")
+	if len(app.Status.Drift) == 0 {
+		if cond := app.Status.GetCondition("DriftCheck"); cond.Type == "" {
+			cmd.Printf("  Drift has not been detected for this application yet\n")
+		} else {
+			cmd.Printf("  No drift detected\n")
+		}
+		return
+	}
+	table := newUITable()
+	table.AddRow("  CLUSTER", "KIND", "NAMESPACE", "NAME", "CHANGED FIELDS")
+	for _, drift := range app.Status.Drift {
+		cluster := drift.Cluster
+		if cluster == "" {
+			cluster = "local"
+		}
+		fields := strings.Join(drift.Fields, ", ")
+		if drift.Missing {
+			fields = "<deleted>"
+		}
+		table.AddRow("  "+cluster, drift.Kind, drift.Namespace, drift.Name, fields)
+	}
+	cmd.Printf("%s\n", table.String())
+}
+
 func loadRemoteApplication(c client.Client, ns string, name string) (*v1beta1.Application, error) {
 	app := new(v1beta1.Application)
 	err := c.Get(context.Background(), client.ObjectKey{
@@ -182,11 +222,11 @@ func loopCheckStatus(ctx context.Context, c client.Client, ioStreams cmdutil.IOS
 		ioStreams.Infof("    Traits:\n")
 		workloadStatus, _ := getWorkloadStatusFromApp(remoteApp, compName)
 		for _, tr := range workloadStatus.Traits {
-			if tr.Message != "" {
+			if message := traitStatusMessage(tr); message != "" {
 				if tr.Healthy {
-					ioStreams.Infof("      - %s%s: %s", emojiSucceed, white.Sprint(tr.Type), tr.Message)
+					ioStreams.Infof("      - %s%s: %s", emojiSucceed, white.Sprint(tr.Type), message)
 				} else {
-					ioStreams.Infof("      - %s%s: %s", emojiFail, white.Sprint(tr.Type), tr.Message)
+					ioStreams.Infof("      - %s%s: %s", emojiFail, white.Sprint(tr.Type), message)
 				}
 				continue
 			}
@@ -334,9 +374,36 @@ func trackHealthCheckingStatus(ctx context.Context, c client.Client, compName, a
 			return compStatusHealthCheckDone, healthStatus, wlhc.Diagnosis, nil
 		}
 	}
+	// use the health reported by the health policy of the component definition
+	if compStatus, ok := getWorkloadStatusFromApp(app, compName); ok && compStatus.HealthPhase != "" {
+		return compStatusHealthCheckDone, healthStatusOfPhase(compStatus.HealthPhase), compStatus.HealthReason, nil
+	}
 	return compStatusHealthCheckDone, HealthStatusNotDiagnosed, "", nil
 }
 
+func healthStatusOfPhase(phase commontypes.HealthPhase) HealthStatus {
+	switch phase {
+	case commontypes.HealthPhaseHealthy:
+		return HealthStatusHealthy
+	case commontypes.HealthPhaseProgressing:
+		return HealthStatusProgressing
+	default:
+		return HealthStatusUnhealthy
+	}
+}
+
+// traitStatusMessage shows the health reason of the trait before its status message, e.g., "progressing: 2/3 replicas ready"
+func traitStatusMessage(tr commontypes.ApplicationTraitStatus) string {
+	if tr.HealthReason == "" {
+		return tr.Message
+	}
+	reason := fmt.Sprintf("%s: %s", tr.HealthPhase, tr.HealthReason)
+	if tr.Message == "" {
+		return reason + "\n"
+	}
+	return reason + "\n\t\t" + tr.Message
+}
+
 func getWorkloadStatusFromApp(app *v1beta1.Application, compName string) (commontypes.ApplicationComponentStatus, bool) {
 	foundWlStatus := false
 	wlStatus := commontypes.ApplicationComponentStatus{}
@@ -358,7 +425,7 @@ func getHealthStatusColor(s HealthStatus) *color.Color {
 	switch s {
 	case HealthStatusHealthy:
 		c = green
-	case HealthStatusUnknown, HealthStatusNotDiagnosed:
+	case HealthStatusUnknown, HealthStatusNotDiagnosed, HealthStatusProgressing:
 		c = yellow
 	default:
 		c = red
@@ -0,0 +1,188 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	commontypes "github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/utils/common"
+	cmdutil "github.com/oam-dev/kubevela/pkg/utils/util"
+	"github.com/oam-dev/kubevela/pkg/workflow"
+)
+
+// NewWorkflowCommand creates `workflow` command
+func NewWorkflowCommand(c common.Args, ioStreams cmdutil.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workflow",
+		Short: "Operate the workflow of an application",
+		Long:  "Suspend, resume, terminate or restart the workflow of an application, or show its execution history.",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return c.SetConfig()
+		},
+		Annotations: map[string]string{
+			types.TagCommandType: types.TypeApp,
+		},
+	}
+	cmd.SetOut(ioStreams.Out)
+	cmd.AddCommand(
+		newWorkflowOperationCommand(c, ioStreams, "suspend", "Suspend the workflow of an application after the running steps",
+			"suspended", workflow.SuspendWorkflow),
+		newWorkflowOperationCommand(c, ioStreams, "resume", "Resume the suspended workflow of an application",
+			"resumed", workflow.ResumeWorkflow),
+		newWorkflowOperationCommand(c, ioStreams, "terminate", "Terminate the workflow of an application",
+			"terminated", workflow.TerminateWorkflow),
+		newWorkflowOperationCommand(c, ioStreams, "restart", "Restart the workflow of an application from the first step",
+			"restarted", workflow.RestartWorkflow),
+		newWorkflowHistoryCommand(c, ioStreams),
+	)
+	return cmd
+}
+
+type workflowOperation func(ctx context.Context, cli client.Client, app *v1beta1.Application) error
+
+func newWorkflowOperationCommand(c common.Args, ioStreams cmdutil.IOStreams, name, short, done string, operate workflowOperation) *cobra.Command {
+	return &cobra.Command{
+		Use:     fmt.Sprintf("%s APP_NAME", name),
+		Short:   short,
+		Long:    short,
+		Example: fmt.Sprintf("vela workflow %s frontend", name),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("must specify name for the app")
+			}
+			env, err := GetEnv(cmd)
+			if err != nil {
+				return err
+			}
+			newClient, err := c.GetClient()
+			if err != nil {
+				return err
+			}
+			app := &v1beta1.Application{}
+			app.SetNamespace(env.Namespace)
+			app.SetName(args[0])
+			if err := operate(context.Background(), newClient, app); err != nil {
+				return err
+			}
+			ioStreams.Infof("Workflow of application %s %s\n", app.Name, done)
+			return nil
+		},
+	}
+}
+
+func newWorkflowHistoryCommand(c common.Args, ioStreams cmdutil.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "history APP_NAME",
+		Short:   "Show the workflow execution history of an application",
+		Long:    "Show the workflow steps executed for each revision of an application.",
+		Example: "vela workflow history frontend",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("must specify name for the app")
+			}
+			env, err := GetEnv(cmd)
+			if err != nil {
+				return err
+			}
+			revision, err := cmd.Flags().GetString("revision")
+			if err != nil {
+				return err
+			}
+			newClient, err := c.GetClient()
+			if err != nil {
+				return err
+			}
+			app := &v1beta1.Application{}
+			app.SetNamespace(env.Namespace)
+			app.SetName(args[0])
+			history, err := workflow.ListHistory(context.Background(), newClient, app)
+			if err != nil {
+				return err
+			}
+			printWorkflowHistory(history, revision, ioStreams)
+			return nil
+		},
+	}
+	cmd.Flags().StringP("revision", "r", "", "only show the history of the given revision")
+	return cmd
+}
+
+func printWorkflowHistory(history []v1beta1.ApplicationRevision, revision string, ioStreams cmdutil.IOStreams) {
+	table := newUITable()
+	table.AddRow("REVISION", "STEP", "TYPE", "PHASE", "ATTEMPTS", "STARTED-TIME", "FINISHED-TIME", "MESSAGE", "OUTPUTS")
+	for _, appRev := range history {
+		if revision != "" && appRev.Name != revision {
+			continue
+		}
+		// the executions restarted by an operator are shown before the latest one
+		executions := append(append([]commontypes.WorkflowStatus{}, appRev.Status.RestartedWorkflows...), *appRev.Status.Workflow)
+		for _, wfStatus := range executions {
+			addWorkflowHistoryRows(table, appRev.Name, wfStatus)
+		}
+	}
+	ioStreams.Info(table.String())
+}
+
+func addWorkflowHistoryRows(table *uitable.Table, revision string, wfStatus commontypes.WorkflowStatus) {
+	if wfStatus.Restarts > 0 {
+		revision = fmt.Sprintf("%s(restart %d)", revision, wfStatus.Restarts)
+	}
+	if len(wfStatus.Steps) == 0 {
+		table.AddRow(revision, "", "", "", "", "", "", "", "")
+		return
+	}
+	for idx, step := range wfStatus.Steps {
+		var revName = revision
+		if idx > 0 {
+			revName = "├─"
+			if idx == len(wfStatus.Steps)-1 {
+				revName = "└─"
+			}
+		}
+		var outputs []string
+		for name, value := range step.Outputs {
+			outputs = append(outputs, fmt.Sprintf("%s=%s", name, value))
+		}
+		sort.Strings(outputs)
+		message := step.Message
+		if message == "" {
+			message = step.LastError
+		}
+		table.AddRow(revName, step.Name, step.Type, step.Phase, step.Attempts,
+			formatStepTime(step.StartedAt), formatStepTime(step.FinishedAt), message, strings.Join(outputs, ","))
+	}
+}
+
+func formatStepTime(t *metav1.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}